@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/go-portfolio/rest-api/internal/auth"   // выпуск и проверка JWT
 	"github.com/go-portfolio/rest-api/internal/config" // загрузка конфигурации приложения
+	"github.com/go-portfolio/rest-api/internal/mail"   // отправка писем
+	"github.com/go-portfolio/rest-api/internal/oidc"   // вход через OpenID Connect
 	"github.com/go-portfolio/rest-api/internal/seed"
 	"github.com/go-portfolio/rest-api/internal/server"   // HTTP-сервер и handler’ы
 	"github.com/go-portfolio/rest-api/internal/services" // сервисы для работы с БД
@@ -25,6 +28,8 @@ import (
 func main() {
 	// Флаг командной строки: если true, применяем миграции перед запуском сервера
 	withMigrations := flag.Bool("with-migrations", false, "Применить все миграции до старта приложения")
+	// Флаг командной строки: если true, создаём тестовых пользователей (только для разработки)
+	withSeed := flag.Bool("seed", false, "Создать тестовых пользователей с известными паролями (только для разработки)")
 	flag.Parse() // читаем флаги
 
 	// Загружаем конфигурацию (например, из YAML + .env)
@@ -33,6 +38,12 @@ func main() {
 		log.Fatal(err) // если не удалось загрузить конфиг — завершаем приложение
 	}
 
+	// Загружаем ключи подписи JWT
+	tokenManager, err := auth.NewTokenManager(cfg.Jwt)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Получаем строку подключения к базе данных (DSN)
 	dbURL := cfg.DSN()
 
@@ -51,15 +62,77 @@ func main() {
 		log.Fatal(err) // завершаем приложение, если не удалось подключиться
 	}
 
-	seed.SeedUsers(db)
+	// Хэширование паролей: алгоритм и параметры из cfg.Password
+	hasher, err := auth.NewPasswordHasher(cfg.Password)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Требования к новым паролям и список утёкших паролей
+	policy, err := auth.NewPasswordPolicy(cfg.Password.Policy)
+	if err != nil {
+		log.Fatal(err)
+	}
+	policy.LimitBytes(hasher.MaxPasswordBytes())
+
+	// Тестовые пользователи имеют известные пароли, в том числе администратор,
+	// поэтому создаются только по явному флагу
+	if *withSeed {
+		seed.SeedUsers(db, hasher)
+	}
+
 	// Создаём сервис для работы с задачами, используя реальную базу
 	// Этот сервис реализует интерфейс TaskService
 	taskSvc := services.NewPostgresTaskService(db)
 	userSvc := services.NewPostgresUserService(db)
+	userSvc.Hasher = hasher
+	tokenSvc := services.NewPostgresTokenService(db)
+	if cfg.Jwt.RefreshTTL > 0 {
+		tokenSvc.RefreshTTL = cfg.Jwt.RefreshTTL
+	}
+	apiKeySvc := services.NewPostgresAPIKeyService(db)
+	resetSvc := services.NewPostgresPasswordResetService(db)
+	if cfg.Account.ResetTTL > 0 {
+		resetSvc.TTL = cfg.Account.ResetTTL
+	}
+
+	verifySvc := services.NewPostgresEmailVerificationService(db)
+	if cfg.Account.VerifyTTL > 0 {
+		verifySvc.TTL = cfg.Account.VerifyTTL
+	}
+
+	// Отправка писем: SMTP, файлы или память — в зависимости от cfg.Mail.Driver
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Провайдеры OpenID Connect; метаданные загружаются при первом входе
+	oidcProviders, err := oidc.NewProviders(cfg.OIDC.Providers)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	fmt.Println("Starting application...")
-	// Передаём сервис в сервер и запускаем HTTP-сервер
-	server.StartServer(taskSvc, userSvc, cfg)
+	// Передаём сервисы в сервер и запускаем HTTP-сервер
+	server.StartServer(server.Deps{
+		Tasks:          taskSvc,
+		Users:          userSvc,
+		Tokens:         tokenSvc,
+		APIKeys:        apiKeySvc,
+		PasswordResets: resetSvc,
+		Verifications:  verifySvc,
+		TwoFactor:      services.NewPostgresTwoFactorService(db),
+		Identities:     services.NewPostgresIdentityService(db),
+		OAuth:          services.NewPostgresOAuthService(db),
+		Sessions:       services.NewPostgresSessionService(db),
+		Impersonations: services.NewPostgresImpersonationAuditService(db),
+		OIDCProviders:  oidcProviders,
+		Mailer:         mailer,
+		TokenManager:   tokenManager,
+		Passwords:      hasher,
+		PasswordPolicy: policy,
+	}, cfg)
 }
 
 // applyMigrations применяет все миграции из указанной папки к базе данных
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// Principal описывает аутентифицированного пользователя запроса
+type Principal struct {
+	UserID int
+	// Role — роль пользователя (claim role)
+	Role string
+	// Scopes — разрешения токена (claim scope)
+	Scopes []string
+	// TokenID — jti access-токена, которым выполнен запрос
+	TokenID string
+	// ExpiresAt — время истечения access-токена
+	ExpiresAt time.Time
+	// APIKeyID — ID API-ключа, если запрос аутентифицирован ключом, а не JWT
+	APIKeyID int
+	// ClientID — OAuth-клиент, которому выдан токен (claim client_id)
+	ClientID string
+	// SessionID — сессия входа (claim sid); 0 у API-ключей и токенов OAuth-клиентов
+	SessionID int
+	// ImpersonatorID — администратор, действующий от имени пользователя (claim act)
+	ImpersonatorID int
+}
+
+// Delegated сообщает, что запрос выполнен не самим пользователем, а API-ключом,
+// OAuth-клиентом или администратором от его имени. Такими учётными данными
+// нельзя управлять учётной записью (пароль, ключи, сессии, 2FA).
+func (p *Principal) Delegated() bool {
+	return p.APIKeyID != 0 || p.ClientID != "" || p.ImpersonatorID != 0
+}
+
+// principalKey — ключ контекста, под которым хранится Principal
+type principalKey struct{}
+
+// WithPrincipal возвращает копию контекста с сохранённым пользователем
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext достаёт пользователя, сохранённого middleware VerifyToken
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
@@ -1,56 +1,538 @@
 package auth
 
 import (
-    "time"
-    "github.com/golang-jwt/jwt/v5"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
-	"errors"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+	"github.com/go-portfolio/rest-api/internal/problem"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateToken(userID int, secret string) (string, error) {
-    claims := jwt.MapClaims{
-        "user_id": userID,
-        "exp":     time.Now().Add(time.Hour * 1).Unix(), // токен на 1 час
-    }
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-    return token.SignedString([]byte(secret))
+// Denylist хранит идентификаторы (jti) досрочно отозванных access-токенов
+type Denylist interface {
+	IsRevoked(jti string) (bool, error)
+}
+
+// SessionChecker проверяет, что сессия входа, к которой относится токен, не отозвана
+type SessionChecker interface {
+	SessionActive(id int) (bool, error)
+}
+
+// ClientChecker проверяет, что OAuth-клиент, получивший токен, не отозван
+type ClientChecker interface {
+	ClientActive(clientID string) (bool, error)
+}
+
+// APIKeyFunc проверяет API-ключ и возвращает его владельца
+type APIKeyFunc func(key string) (*Principal, error)
+
+// Option настраивает middleware VerifyToken
+type Option func(*verifyOptions)
+
+type verifyOptions struct {
+	denylist     Denylist
+	sessions     SessionChecker
+	clients      ClientChecker
+	apiKeyPrefix string
+	apiKeys      APIKeyFunc
+}
+
+// WithDenylist включает проверку jti по списку отозванных токенов
+func WithDenylist(d Denylist) Option {
+	return func(o *verifyOptions) {
+		o.denylist = d
+	}
 }
 
-// VerifyToken возвращает middleware-обёртку для проверки JWT
-func VerifyToken(secret string) func(http.Handler) http.Handler {
+// WithSessions отклоняет токены отозванных сессий (claim sid)
+func WithSessions(s SessionChecker) Option {
+	return func(o *verifyOptions) {
+		o.sessions = s
+	}
+}
+
+// WithClients отклоняет токены отозванных OAuth-клиентов (claim client_id)
+func WithClients(c ClientChecker) Option {
+	return func(o *verifyOptions) {
+		o.clients = c
+	}
+}
+
+// WithAPIKeys разрешает аутентификацию API-ключами наряду с JWT.
+// Ключ передаётся в заголовке X-API-Key или как Bearer-токен;
+// Bearer-значения, начинающиеся с prefix, считаются ключами, а не JWT.
+func WithAPIKeys(prefix string, f APIKeyFunc) Option {
+	return func(o *verifyOptions) {
+		o.apiKeyPrefix = prefix
+		o.apiKeys = f
+	}
+}
+
+// DefaultAccessTokenTTL — срок жизни access-токена, если access_ttl не задан
+const DefaultAccessTokenTTL = time.Hour
+
+// DefaultImpersonationTTL — срок жизни токена имперсонации, если impersonation_ttl не задан;
+// MaxImpersonationTTL — верхняя граница, которую нельзя превысить настройкой
+const (
+	DefaultImpersonationTTL = 15 * time.Minute
+	MaxImpersonationTTL     = time.Hour
+)
+
+// ImpersonationHeader — заголовок ответа на каждый запрос, выполненный
+// администратором от имени пользователя; содержит ID администратора
+const ImpersonationHeader = "X-Impersonated-By"
+
+// ChallengeTokenTTL — срок жизни токена второго шага входа (2FA)
+const ChallengeTokenTTL = 5 * time.Minute
+
+// purposeTwoFactor — назначение токена второго шага входа; такой токен
+// не даёт доступа к API и принимается только ParseChallengeToken
+const purposeTwoFactor = "2fa"
+
+// Claims — содержимое access-токена.
+// Стандартные claims (iss, aud, sub, iat, nbf, exp, jti) дополнены
+// user_id (для совместимости со старыми клиентами), ролью и scope —
+// списком разрешений через пробел, как в OAuth 2.0.
+type Claims struct {
+	jwt.RegisteredClaims
+	UserID int    `json:"user_id"`
+	Role   string `json:"role,omitempty"`
+	Scope  string `json:"scope,omitempty"`
+	// ClientID — OAuth-клиент, получивший токен (RFC 9068)
+	ClientID string `json:"client_id,omitempty"`
+	// SessionID — сессия входа, в рамках которой выдан токен
+	SessionID int `json:"sid,omitempty"`
+	// Act — администратор, действующий от имени пользователя sub (RFC 8693)
+	Act *ActorClaim `json:"act,omitempty"`
+	// Purpose заполнен у служебных токенов (например, второй шаг входа);
+	// access-токены его не содержат
+	Purpose string `json:"purpose,omitempty"`
+}
+
+// ActorClaim — claim act: кто фактически выполняет запросы
+type ActorClaim struct {
+	Subject string `json:"sub"`
+}
+
+// -----------------------------
+// TokenManager
+// -----------------------------
+// Выпускает и проверяет JWT.
+// Подписывает токены одним ключом (signing), а проверяет любым из
+// активных ключей по заголовку kid — так ключ подписи можно сменить,
+// не инвалидируя уже выданные токены.
+//
+// Если в конфигурации заданы issuer и audience, они записываются в
+// выпускаемые токены и обязательны при проверке.
+type TokenManager struct {
+	signing *key
+	keys    map[string]*key // ключи проверки по kid
+	legacy  *key            // HS256-ключ из jwtkey для токенов без kid
+	methods []string        // допустимые алгоритмы
+
+	issuer           string
+	audience         string
+	accessTTL        time.Duration
+	impersonationTTL time.Duration
+	leeway           time.Duration // допуск на расхождение часов при проверке exp/nbf/iat
+}
+
+// NewTokenManager создаёт TokenManager по конфигурации jwt
+func NewTokenManager(cfg config.JwtConfig) (*TokenManager, error) {
+	m := &TokenManager{
+		keys:             map[string]*key{},
+		issuer:           cfg.Issuer,
+		audience:         cfg.Audience,
+		accessTTL:        cfg.AccessTTL,
+		impersonationTTL: cfg.ImpersonationTTL,
+		leeway:           cfg.Leeway,
+	}
+	if m.accessTTL <= 0 {
+		m.accessTTL = DefaultAccessTokenTTL
+	}
+	if m.impersonationTTL <= 0 {
+		m.impersonationTTL = DefaultImpersonationTTL
+	}
+	if m.impersonationTTL > MaxImpersonationTTL {
+		return nil, fmt.Errorf("jwt: impersonation_ttl must not exceed %s", MaxImpersonationTTL)
+	}
+
+	if cfg.JwtSecretKey != "" {
+		m.legacy = &key{
+			method: jwt.SigningMethodHS256,
+			sign:   []byte(cfg.JwtSecretKey),
+			verify: []byte(cfg.JwtSecretKey),
+		}
+		m.methods = append(m.methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	for _, kc := range cfg.Keys {
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := m.keys[k.id]; dup {
+			return nil, fmt.Errorf("jwt key %s: duplicate kid", k.id)
+		}
+		m.keys[k.id] = k
+		m.methods = append(m.methods, k.method.Alg())
+	}
+
+	switch {
+	case cfg.SigningKey != "":
+		k, ok := m.keys[cfg.SigningKey]
+		if !ok {
+			return nil, fmt.Errorf("jwt signing key %s not found", cfg.SigningKey)
+		}
+		if k.sign == nil {
+			return nil, fmt.Errorf("jwt signing key %s has no private key", cfg.SigningKey)
+		}
+		m.signing = k
+	case m.legacy != nil:
+		m.signing = m.legacy
+	default:
+		return nil, errors.New("jwt: no signing key configured")
+	}
+
+	return m, nil
+}
+
+// AccessTTL возвращает срок жизни выпускаемых access-токенов
+func (m *TokenManager) AccessTTL() time.Duration { return m.accessTTL }
+
+// ImpersonationTTL возвращает срок жизни токенов имперсонации
+func (m *TokenManager) ImpersonationTTL() time.Duration { return m.impersonationTTL }
+
+// GenerateToken выпускает access-токен для пользователя p.
+// Используются поля UserID, Role, Scopes, ClientID, SessionID и ImpersonatorID; TokenID и ExpiresAt
+// выставляются менеджером и видны в claims выпущенного токена.
+// Токен с ImpersonatorID получает claim act и живёт ImpersonationTTL.
+func (m *TokenManager) GenerateToken(p Principal) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	ttl := m.accessTTL
+	if p.ImpersonatorID != 0 {
+		ttl = m.impersonationTTL
+	}
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   strconv.Itoa(p.UserID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			ID:        jti, // идентификатор для досрочного отзыва
+		},
+		UserID:    p.UserID,
+		Role:      p.Role,
+		Scope:     strings.Join(p.Scopes, " "),
+		ClientID:  p.ClientID,
+		SessionID: p.SessionID,
+	}
+	if p.ImpersonatorID != 0 {
+		claims.Act = &ActorClaim{Subject: strconv.Itoa(p.ImpersonatorID)}
+	}
+	if m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
+	}
+	return m.sign(claims)
+}
+
+// sign подписывает claims текущим ключом подписи
+func (m *TokenManager) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(m.signing.method, claims)
+	if m.signing.id != "" {
+		token.Header["kid"] = m.signing.id
+	}
+	return token.SignedString(m.signing.sign)
+}
+
+// GenerateChallengeToken выпускает короткоживущий токен второго шага входа.
+// Он подтверждает, что пароль уже проверен, но не даёт доступа к API.
+func (m *TokenManager) GenerateChallengeToken(userID int) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ChallengeTokenTTL)),
+			ID:        jti,
+		},
+		Purpose: purposeTwoFactor,
+	}
+	if m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
+	}
+	return m.sign(claims)
+}
+
+// ParseChallengeToken проверяет токен второго шага входа и возвращает его claims
+func (m *TokenManager) ParseChallengeToken(tokenString string) (*Claims, error) {
+	claims, err := m.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purposeTwoFactor {
+		return nil, errors.New("not a challenge token")
+	}
+	return claims, nil
+}
+
+// ParseToken проверяет access-токен и возвращает его claims.
+// Служебные токены (с purpose) отклоняются.
+func (m *TokenManager) ParseToken(tokenString string) (*Claims, error) {
+	claims, err := m.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, errors.New("not an access token")
+	}
+	return claims, nil
+}
+
+// parse проверяет подпись, срок действия, issuer и audience токена и возвращает его claims
+func (m *TokenManager) parse(tokenString string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(m.methods),
+		jwt.WithLeeway(m.leeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
+	}
+	if m.audience != "" {
+		opts = append(opts, jwt.WithAudience(m.audience))
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, m.keyFunc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	// sub — основной идентификатор; user_id остаётся для старых токенов
+	if claims.Subject != "" {
+		sub, err := strconv.Atoi(claims.Subject)
+		if err != nil || (claims.UserID != 0 && claims.UserID != sub) {
+			return nil, errors.New("invalid subject")
+		}
+		claims.UserID = sub
+	}
+	if claims.UserID <= 0 {
+		return nil, errors.New("missing subject")
+	}
+
+	// Токен имперсонации не может жить дольше ImpersonationTTL, даже если подписан
+	// при другой настройке
+	if claims.Act != nil {
+		actor, err := strconv.Atoi(claims.Act.Subject)
+		if err != nil || actor <= 0 || actor == claims.UserID {
+			return nil, errors.New("invalid actor")
+		}
+		if claims.IssuedAt == nil || claims.ExpiresAt.Sub(claims.IssuedAt.Time) > m.impersonationTTL {
+			return nil, errors.New("impersonation token lifetime exceeded")
+		}
+	}
+	return claims, nil
+}
+
+// Principal возвращает пользователя, описанного claims
+func (c *Claims) Principal() *Principal {
+	p := &Principal{
+		UserID:    c.UserID,
+		Role:      c.Role,
+		Scopes:    strings.Fields(c.Scope),
+		TokenID:   c.ID,
+		ClientID:  c.ClientID,
+		SessionID: c.SessionID,
+	}
+	if c.ExpiresAt != nil {
+		p.ExpiresAt = c.ExpiresAt.Time
+	}
+	if c.Act != nil {
+		p.ImpersonatorID, _ = strconv.Atoi(c.Act.Subject)
+	}
+	return p
+}
+
+// keyFunc выбирает ключ проверки по kid и сверяет алгоритм токена с алгоритмом ключа
+func (m *TokenManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	k := m.legacy
+	if kid, ok := token.Header["kid"].(string); ok {
+		k = m.keys[kid]
+	}
+	if k == nil {
+		return nil, errors.New("unknown signing key")
+	}
+	// Проверка метода подписи
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return k.verify, nil
+}
+
+// JWKS возвращает открытые ключи проверки; HS256-секрет не публикуется
+func (m *TokenManager) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, k := range m.keys {
+		if j, ok := k.jwk(); ok {
+			set.Keys = append(set.Keys, j)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// VerifyToken возвращает middleware-обёртку для проверки JWT (и API-ключей, см. WithAPIKeys).
+// При успешной проверке пользователь из claims токена
+// сохраняется в контексте запроса (см. PrincipalFromContext).
+// Ошибки возвращаются в формате application/problem+json.
+func VerifyToken(tm *TokenManager, opts ...Option) func(http.Handler) http.Handler {
+	o := &verifyOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Достаём заголовок Authorization
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				http.Error(w, "missing Authorization header", http.StatusUnauthorized)
+			principal, err := o.authenticate(tm, r)
+			if err != nil {
+				var authErr unauthorizedError
+				if errors.As(err, &authErr) {
+					problem.Write(w, r, http.StatusUnauthorized, string(authErr))
+					return
+				}
+				problem.Write(w, r, http.StatusInternalServerError, "internal error")
 				return
 			}
 
-			// Ожидаем формат "Bearer <token>"
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) != 2 || parts[0] != "Bearer" {
-				http.Error(w, "invalid Authorization header", http.StatusUnauthorized)
-				return
+			// Клиент должен видеть, что действует от имени другого пользователя
+			if principal.ImpersonatorID != 0 {
+				w.Header().Set(ImpersonationHeader, strconv.Itoa(principal.ImpersonatorID))
 			}
-			tokenString := parts[1]
 
-			// Парсим и валидируем токен
-			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-				// Проверка метода подписи
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, errors.New("unexpected signing method")
-				}
-				return []byte(secret), nil
-			})
-			if err != nil || !token.Valid {
-				http.Error(w, "invalid token", http.StatusUnauthorized)
-				return
-			}
-
-			// Всё ок — передаём управление дальше
-			next.ServeHTTP(w, r)
+			// Всё ок — передаём управление дальше вместе с пользователем
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
 		})
 	}
 }
+
+// unauthorizedError — ошибка аутентификации, которую можно показать клиенту (401)
+type unauthorizedError string
+
+func (e unauthorizedError) Error() string { return string(e) }
+
+// authenticate определяет пользователя по заголовкам запроса.
+// Ошибки unauthorizedError означают неверные учётные данные,
+// остальные — сбой хранилища.
+func (o *verifyOptions) authenticate(tm *TokenManager, r *http.Request) (*Principal, error) {
+	// API-ключ в отдельном заголовке
+	if key := r.Header.Get("X-API-Key"); key != "" && o.apiKeys != nil {
+		return o.authenticateAPIKey(key)
+	}
+
+	// Достаём заголовок Authorization
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return nil, unauthorizedError("missing Authorization header")
+	}
+
+	// Ожидаем формат "Bearer <token>"
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, unauthorizedError("invalid Authorization header")
+	}
+	tokenString := parts[1]
+
+	// API-ключ вместо JWT
+	if o.apiKeys != nil && strings.HasPrefix(tokenString, o.apiKeyPrefix) {
+		return o.authenticateAPIKey(tokenString)
+	}
+
+	// Парсим и валидируем токен
+	claims, err := tm.ParseToken(tokenString)
+	if err != nil {
+		return nil, unauthorizedError("invalid token")
+	}
+	principal := claims.Principal()
+
+	// Токен мог быть отозван до истечения срока (logout)
+	if o.denylist != nil && principal.TokenID != "" {
+		revoked, err := o.denylist.IsRevoked(principal.TokenID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, unauthorizedError("token revoked")
+		}
+	}
+
+	// Сессию могли завершить с другого устройства
+	if o.sessions != nil && principal.SessionID != 0 {
+		active, err := o.sessions.SessionActive(principal.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, unauthorizedError("session revoked")
+		}
+	}
+
+	// Клиента, получившего токен, могли отозвать (в том числе вместе с его владельцем)
+	if o.clients != nil && principal.ClientID != "" {
+		active, err := o.clients.ClientActive(principal.ClientID)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, unauthorizedError("client revoked")
+		}
+	}
+
+	return principal, nil
+}
+
+// authenticateAPIKey проверяет API-ключ; пустой результат без ошибки считается неверным ключом
+func (o *verifyOptions) authenticateAPIKey(key string) (*Principal, error) {
+	principal, err := o.apiKeys(key)
+	if err != nil {
+		return nil, err
+	}
+	if principal == nil {
+		return nil, unauthorizedError("invalid api key")
+	}
+	return principal, nil
+}
+
+// newTokenID генерирует случайный идентификатор токена (jti)
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
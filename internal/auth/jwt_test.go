@@ -0,0 +1,347 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKeyPair сохраняет закрытый (PKCS#8) и открытый (PKIX) ключи в PEM-файлы
+func writeKeyPair(t *testing.T, name string, priv crypto.Signer) (privPath, pubPath string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(priv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privPath = filepath.Join(dir, name+".pem")
+	pubPath = filepath.Join(dir, name+".pub.pem")
+	if err := os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return privPath, pubPath
+}
+
+// testKeys генерирует по ключу на каждый поддерживаемый тип
+func testKeys(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]crypto.Signer{"RS256": rsaKey, "ES256": ecKey, "EdDSA": edKey}
+}
+
+// -----------------------------
+// Подпись и проверка каждым алгоритмом
+// -----------------------------
+func TestTokenManager_AsymmetricAlgorithms(t *testing.T) {
+	for alg, priv := range testKeys(t) {
+		t.Run(alg, func(t *testing.T) {
+			privPath, _ := writeKeyPair(t, alg, priv)
+			tm, err := NewTokenManager(config.JwtConfig{
+				SigningKey: "k1",
+				Keys:       []config.JwtKeyConfig{{Kid: "k1", Algorithm: alg, PrivateKey: privPath}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := tm.GenerateToken(Principal{UserID: 7})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.Header["kid"] != "k1" || parsed.Method.Alg() != alg {
+				t.Errorf("unexpected header: %v", parsed.Header)
+			}
+
+			claims, err := tm.ParseToken(token)
+			if err != nil {
+				t.Fatalf("expected valid token, got %v", err)
+			}
+			if claims.UserID != 7 || claims.Subject != "7" {
+				t.Errorf("unexpected claims: %v", claims)
+			}
+
+			jwks := tm.JWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != "k1" || jwks.Keys[0].Alg != alg {
+				t.Errorf("unexpected JWKS: %+v", jwks)
+			}
+		})
+	}
+}
+
+// -----------------------------
+// Ротация ключей
+// -----------------------------
+func TestTokenManager_Rotation(t *testing.T) {
+	keys := testKeys(t)
+	oldPriv, oldPub := writeKeyPair(t, "old", keys["RS256"])
+	newPriv, _ := writeKeyPair(t, "new", keys["ES256"])
+
+	// До ротации: подписываем старым ключом, плюс есть общий HS256-секрет
+	before, err := NewTokenManager(config.JwtConfig{
+		JwtSecretKey: "legacy-secret",
+		SigningKey:   "old",
+		Keys:         []config.JwtKeyConfig{{Kid: "old", Algorithm: "RS256", PrivateKey: oldPriv}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, _ := before.GenerateToken(Principal{UserID: 1})
+
+	// Токен, выданный ещё до перехода на асимметричные ключи
+	legacy, _ := NewTokenManager(config.JwtConfig{JwtSecretKey: "legacy-secret"})
+	legacyToken, _ := legacy.GenerateToken(Principal{UserID: 1})
+
+	// После ротации: подписываем новым ключом, старый оставлен только для проверки
+	after, err := NewTokenManager(config.JwtConfig{
+		JwtSecretKey: "legacy-secret",
+		SigningKey:   "new",
+		Keys: []config.JwtKeyConfig{
+			{Kid: "new", Algorithm: "ES256", PrivateKey: newPriv},
+			{Kid: "old", Algorithm: "RS256", PublicKey: oldPub},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"old key": oldToken, "legacy HS256": legacyToken} {
+		if _, err := after.ParseToken(token); err != nil {
+			t.Errorf("%s: token must stay valid after rotation, got %v", name, err)
+		}
+	}
+
+	newToken, _ := after.GenerateToken(Principal{UserID: 1})
+	if _, err := after.ParseToken(newToken); err != nil {
+		t.Errorf("new token must be valid, got %v", err)
+	}
+	// Токен, подписанный новым ключом, не проходит у того, кто его не знает
+	if _, err := before.ParseToken(newToken); err == nil {
+		t.Errorf("token with unknown kid must be rejected")
+	}
+
+	// В JWKS публикуются оба открытых ключа, но не HS256-секрет
+	if jwks := after.JWKS(); len(jwks.Keys) != 2 || jwks.Keys[0].Kid != "new" || jwks.Keys[1].Kty != "RSA" {
+		t.Errorf("unexpected JWKS: %+v", jwks)
+	}
+}
+
+// -----------------------------
+// Подмена алгоритма
+// -----------------------------
+func TestTokenManager_AlgorithmConfusion(t *testing.T) {
+	rsaKey := testKeys(t)["RS256"]
+	privPath, pubPath := writeKeyPair(t, "rsa", rsaKey)
+
+	tm, err := NewTokenManager(config.JwtConfig{
+		JwtSecretKey: "legacy-secret",
+		SigningKey:   "rsa",
+		Keys:         []config.JwtKeyConfig{{Kid: "rsa", Algorithm: "RS256", PrivateKey: privPath}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// HS256-токен, подписанный открытым RSA-ключом как HMAC-секретом
+	pubPEM, _ := os.ReadFile(pubPath)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": 1})
+	forged.Header["kid"] = "rsa"
+	forgedString, _ := forged.SignedString(pubPEM)
+	if _, err := tm.ParseToken(forgedString); err == nil {
+		t.Errorf("token with mismatched algorithm must be rejected")
+	}
+
+	// Тип ключа не соответствует алгоритму
+	_, err = NewTokenManager(config.JwtConfig{
+		SigningKey: "rsa",
+		Keys:       []config.JwtKeyConfig{{Kid: "rsa", Algorithm: "ES256", PrivateKey: privPath}},
+	})
+	if err == nil {
+		t.Errorf("RSA key configured as ES256 must be rejected")
+	}
+}
+
+// -----------------------------
+// Стандартные claims, issuer/audience и допуск по времени
+// -----------------------------
+func TestTokenManager_StandardClaims(t *testing.T) {
+	cfg := config.JwtConfig{
+		JwtSecretKey: "secret",
+		Issuer:       "rest-api",
+		Audience:     "rest-api-clients",
+		AccessTTL:    15 * time.Minute,
+		Leeway:       time.Minute,
+	}
+	tm, err := NewTokenManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := tm.GenerateToken(Principal{UserID: 3, Role: "member", Scopes: []string{"tasks:read", "tasks:write"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := tm.ParseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.Issuer != "rest-api" || claims.Subject != "3" || len(claims.Audience) != 1 || claims.Audience[0] != "rest-api-clients" {
+		t.Errorf("unexpected registered claims: %+v", claims.RegisteredClaims)
+	}
+	if claims.ID == "" || claims.IssuedAt == nil || claims.NotBefore == nil {
+		t.Errorf("jti, iat and nbf must be set: %+v", claims.RegisteredClaims)
+	}
+	if ttl := claims.ExpiresAt.Sub(claims.IssuedAt.Time); ttl != 15*time.Minute {
+		t.Errorf("expected 15m lifetime, got %v", ttl)
+	}
+	p := claims.Principal()
+	if p.Role != "member" || len(p.Scopes) != 2 || p.Scopes[1] != "tasks:write" {
+		t.Errorf("unexpected principal: %+v", p)
+	}
+
+	// Токены другого издателя или для другой аудитории отклоняются
+	for name, other := range map[string]config.JwtConfig{
+		"issuer":   {JwtSecretKey: "secret", Issuer: "someone-else", Audience: cfg.Audience},
+		"audience": {JwtSecretKey: "secret", Issuer: cfg.Issuer, Audience: "another-api"},
+	} {
+		foreign, _ := NewTokenManager(other)
+		foreignToken, _ := foreign.GenerateToken(Principal{UserID: 3})
+		if _, err := tm.ParseToken(foreignToken); err == nil {
+			t.Errorf("%s: token must be rejected", name)
+		}
+	}
+
+	// Истёкший в пределах leeway токен принимается, за пределами — нет
+	expiredAt := func(ago time.Duration) string {
+		now := time.Now()
+		s, err := tm.sign(Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    cfg.Issuer,
+				Audience:  jwt.ClaimStrings{cfg.Audience},
+				Subject:   "3",
+				IssuedAt:  jwt.NewNumericDate(now.Add(-time.Hour)),
+				ExpiresAt: jwt.NewNumericDate(now.Add(-ago)),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	if _, err := tm.ParseToken(expiredAt(30 * time.Second)); err != nil {
+		t.Errorf("token expired within leeway must be accepted, got %v", err)
+	}
+	if _, err := tm.ParseToken(expiredAt(2 * time.Minute)); err == nil {
+		t.Errorf("token expired beyond leeway must be rejected")
+	}
+}
+
+// -----------------------------
+// Challenge-токен второго шага входа не даёт доступа к API
+// -----------------------------
+func TestTokenManager_ChallengeToken(t *testing.T) {
+	tm, err := NewTokenManager(config.JwtConfig{JwtSecretKey: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, err := tm.GenerateChallengeToken(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := tm.ParseChallengeToken(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != 7 || claims.ExpiresAt.Sub(claims.IssuedAt.Time) != ChallengeTokenTTL {
+		t.Errorf("unexpected challenge claims: %+v", claims)
+	}
+	if _, err := tm.ParseToken(challenge); err == nil {
+		t.Error("challenge token must not be accepted as access token")
+	}
+
+	access, _ := tm.GenerateToken(Principal{UserID: 7})
+	if _, err := tm.ParseChallengeToken(access); err == nil {
+		t.Error("access token must not be accepted as challenge token")
+	}
+}
+
+// -----------------------------
+// Токен имперсонации: claim act и жёсткий срок жизни
+// -----------------------------
+func TestTokenManager_Impersonation(t *testing.T) {
+	tm, err := NewTokenManager(config.JwtConfig{JwtSecretKey: "secret", ImpersonationTTL: 10 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := tm.GenerateToken(Principal{UserID: 7, Role: RoleMember, ImpersonatorID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := tm.ParseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Act == nil || claims.Act.Subject != "1" || claims.ExpiresAt.Sub(claims.IssuedAt.Time) != 10*time.Minute {
+		t.Fatalf("unexpected impersonation claims: %+v", claims)
+	}
+	if p := claims.Principal(); p.ImpersonatorID != 1 || !p.Delegated() {
+		t.Errorf("unexpected principal: %+v", p)
+	}
+
+	// Токен с act, живущий дольше impersonation_ttl, отклоняется даже с верной подписью
+	now := time.Now()
+	long, _ := tm.sign(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "7",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		Act: &ActorClaim{Subject: "1"},
+	})
+	if _, err := tm.ParseToken(long); err == nil {
+		t.Error("impersonation token beyond impersonation_ttl must be rejected")
+	}
+
+	if _, err := NewTokenManager(config.JwtConfig{JwtSecretKey: "secret", ImpersonationTTL: 2 * MaxImpersonationTTL}); err == nil {
+		t.Error("impersonation_ttl above the maximum must be rejected")
+	}
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// key — ключ подписи или проверки JWT
+type key struct {
+	id     string            // kid; пустой у HS256-ключа из jwtkey
+	method jwt.SigningMethod // алгоритм, которым разрешено пользоваться ключу
+	sign   interface{}       // ключ подписи (nil у ключей только для проверки)
+	verify interface{}       // ключ проверки
+}
+
+// loadKey загружает ключ из PEM-файлов и проверяет, что он подходит алгоритму
+func loadKey(kc config.JwtKeyConfig) (*key, error) {
+	if kc.Kid == "" {
+		return nil, errors.New("jwt key: kid is required")
+	}
+
+	method := jwt.GetSigningMethod(kc.Algorithm)
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA, *jwt.SigningMethodEd25519:
+	default:
+		return nil, fmt.Errorf("jwt key %s: unsupported algorithm %q", kc.Kid, kc.Algorithm)
+	}
+
+	k := &key{id: kc.Kid, method: method}
+	switch {
+	case kc.PrivateKey != "":
+		priv, err := readPrivateKey(kc.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", kc.Kid, err)
+		}
+		k.sign, k.verify = priv, priv.Public()
+	case kc.PublicKey != "":
+		pub, err := readPublicKey(kc.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", kc.Kid, err)
+		}
+		k.verify = pub
+	default:
+		return nil, fmt.Errorf("jwt key %s: private_key or public_key is required", kc.Kid)
+	}
+
+	if !KeyMatchesMethod(k.verify, method) {
+		return nil, fmt.Errorf("jwt key %s: key type does not match algorithm %s", kc.Kid, kc.Algorithm)
+	}
+	return k, nil
+}
+
+// KeyMatchesMethod защищает от подмены алгоритма: RSA-ключ не может
+// использоваться для ES256 и т.п., а ECDSA-ключ должен быть на нужной кривой
+func KeyMatchesMethod(pub crypto.PublicKey, method jwt.SigningMethod) bool {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodRSA)
+		return ok
+	case *ecdsa.PublicKey:
+		m, ok := method.(*jwt.SigningMethodECDSA)
+		return ok && pub.Curve.Params().BitSize == m.CurveBits
+	case ed25519.PublicKey:
+		_, ok := method.(*jwt.SigningMethodEd25519)
+		return ok
+	}
+	return false
+}
+
+// readPrivateKey читает закрытый ключ в формате PKCS#8, PKCS#1 (RSA) или SEC 1 (EC)
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := k.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, errors.New("unsupported private key type")
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	if k, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	return nil, errors.New("cannot parse private key " + path)
+}
+
+// readPublicKey читает открытый ключ в формате PKIX
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse public key %s: %w", path, err)
+	}
+	return pub, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data in " + path)
+	}
+	return block, nil
+}
+
+// -----------------------------
+// JWKS
+// -----------------------------
+
+// JWK — открытый ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC и OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS — набор открытых ключей для /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwk кодирует открытый ключ в JWK; для HS256-ключа возвращает false
+func (k *key) jwk() (JWK, bool) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	j := JWK{Kid: k.id, Alg: k.method.Alg(), Use: "sig"}
+
+	switch pub := k.verify.(type) {
+	case *rsa.PublicKey:
+		j.Kty = "RSA"
+		j.N = b64(pub.N.Bytes())
+		j.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdhKey, err := pub.ECDH()
+		if err != nil {
+			return JWK{}, false
+		}
+		// Несжатая точка: 0x04 || X || Y, координаты уже дополнены до размера кривой
+		point := ecdhKey.Bytes()[1:]
+		size := len(point) / 2
+		j.Kty = "EC"
+		j.Crv = curveName(pub.Curve)
+		j.X = b64(point[:size])
+		j.Y = b64(point[size:])
+	case ed25519.PublicKey:
+		j.Kty = "OKP"
+		j.Crv = "Ed25519"
+		j.X = b64(pub)
+	default:
+		return JWK{}, false
+	}
+	return j, true
+}
+
+// PublicKey декодирует открытый ключ из JWK (RSA, EC или Ed25519)
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding.DecodeString
+	switch j.Kty {
+	case "RSA":
+		n, err := b64(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: invalid n", j.Kid)
+		}
+		e, err := b64(j.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("jwk %s: invalid e", j.Kid)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("jwk %s: unsupported curve %q", j.Kid, j.Crv)
+		}
+		x, errX := b64(j.X)
+		y, errY := b64(j.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("jwk %s: invalid point", j.Kid)
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		// Проверяем, что точка лежит на кривой
+		if _, err := pub.ECDH(); err != nil {
+			return nil, fmt.Errorf("jwk %s: invalid point", j.Kid)
+		}
+		return pub, nil
+	case "OKP":
+		x, err := b64(j.X)
+		if j.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwk %s: invalid Ed25519 key", j.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("jwk %s: unsupported key type %q", j.Kid, j.Kty)
+}
+
+func curveName(c elliptic.Curve) string {
+	switch c {
+	case elliptic.P256():
+		return "P-256"
+	case elliptic.P384():
+		return "P-384"
+	case elliptic.P521():
+		return "P-521"
+	}
+	return c.Params().Name
+}
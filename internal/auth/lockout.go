@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+)
+
+// Значения по умолчанию для защиты от подбора пароля
+const (
+	defaultMaxFailuresPerUser = 5
+	defaultMaxFailuresPerIP   = 20
+	defaultBaseDelay          = time.Second
+	defaultMaxDelay           = time.Minute
+	defaultLockout            = 15 * time.Minute
+	defaultFailureWindow      = 15 * time.Minute
+
+	// maxTrackedKeys — при превышении из памяти удаляются устаревшие записи
+	maxTrackedKeys = 10000
+)
+
+// LockoutScope — по чему сработала блокировка
+type LockoutScope string
+
+const (
+	ScopeUser LockoutScope = "user"
+	ScopeIP   LockoutScope = "ip"
+)
+
+// -----------------------------
+// LoginLimiter
+// -----------------------------
+// Отслеживает неудачные попытки входа отдельно по имени пользователя и по IP.
+// После каждой неудачи следующая попытка для этого имени разрешена не раньше,
+// чем через base_delay * 2^(n-1) (но не больше max_delay); после N неудач
+// имя пользователя или IP блокируется на время lockout. Счётчик сбрасывается после успешного входа
+// (только по имени пользователя) или после window без неудач.
+// Хранит состояние в памяти процесса; nil-значение ничего не ограничивает.
+type LoginLimiter struct {
+	cfg     config.LoginLimitConfig
+	mu      sync.Mutex
+	entries map[string]*loginAttempts
+	now     func() time.Time
+}
+
+type loginAttempts struct {
+	failures    int
+	lastFailure time.Time
+	blockedTill time.Time
+}
+
+// NewLoginLimiter создаёт LoginLimiter; незаданные параметры получают значения по умолчанию
+func NewLoginLimiter(cfg config.LoginLimitConfig) *LoginLimiter {
+	if cfg.MaxFailuresPerUser <= 0 {
+		cfg.MaxFailuresPerUser = defaultMaxFailuresPerUser
+	}
+	if cfg.MaxFailuresPerIP <= 0 {
+		cfg.MaxFailuresPerIP = defaultMaxFailuresPerIP
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = defaultBaseDelay
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = defaultMaxDelay
+	}
+	if cfg.Lockout <= 0 {
+		cfg.Lockout = defaultLockout
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultFailureWindow
+	}
+	return &LoginLimiter{cfg: cfg, entries: map[string]*loginAttempts{}, now: time.Now}
+}
+
+// Check возвращает, сколько ещё ждать до следующей попытки входа (0 — можно входить)
+func (l *LoginLimiter) Check(username, ip string) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	wait := time.Duration(0)
+	for _, key := range l.keys(username, ip) {
+		if a, ok := l.entries[key]; ok && a.blockedTill.After(now) {
+			wait = maxDuration(wait, a.blockedTill.Sub(now))
+		}
+	}
+	return wait
+}
+
+// Failure учитывает неудачную попытку. Возвращает задержку до следующей попытки
+// и список областей, для которых эта попытка включила блокировку.
+func (l *LoginLimiter) Failure(username, ip string) (time.Duration, []LockoutScope) {
+	if l == nil {
+		return 0, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if len(l.entries) > maxTrackedKeys {
+		l.prune(now)
+	}
+
+	var (
+		wait   time.Duration
+		locked []LockoutScope
+	)
+	for scope, key := range map[LockoutScope]string{ScopeUser: userKey(username), ScopeIP: ipKey(ip)} {
+		if key == "" {
+			continue
+		}
+		a, ok := l.entries[key]
+		if !ok || now.Sub(a.lastFailure) > l.cfg.Window {
+			a = &loginAttempts{}
+			l.entries[key] = a
+		}
+		a.failures++
+		a.lastFailure = now
+
+		limit := l.cfg.MaxFailuresPerUser
+		if scope == ScopeIP {
+			limit = l.cfg.MaxFailuresPerIP
+		}
+
+		// Задержка после каждой неудачи применяется только к имени пользователя:
+		// за одним IP (NAT, прокси) может быть много честных клиентов
+		var delay time.Duration
+		if scope == ScopeUser {
+			delay = l.backoff(a.failures)
+		}
+		if a.failures >= limit {
+			delay = l.cfg.Lockout
+			if a.failures == limit {
+				locked = append(locked, scope)
+			}
+		}
+		a.blockedTill = now.Add(delay)
+		wait = maxDuration(wait, delay)
+	}
+	return wait, locked
+}
+
+// Success сбрасывает счётчик пользователя. Счётчик IP не сбрасывается,
+// чтобы успешный вход в свою учётную запись не обнулял подбор чужих паролей.
+func (l *LoginLimiter) Success(username string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, userKey(username))
+}
+
+// ClientIP определяет IP клиента. X-Forwarded-For учитывается только при
+// включённом trust_forwarded_for, иначе заголовок легко подделать.
+func (l *LoginLimiter) ClientIP(r *http.Request) string {
+	if l != nil && l.cfg.TrustForwardedFor {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			first, _, _ := strings.Cut(xff, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// backoff — экспоненциальная задержка после n-й неудачи
+func (l *LoginLimiter) backoff(n int) time.Duration {
+	factor := math.Pow(2, float64(n-1))
+	delay := time.Duration(float64(l.cfg.BaseDelay) * factor)
+	if delay <= 0 || delay > l.cfg.MaxDelay {
+		return l.cfg.MaxDelay
+	}
+	return delay
+}
+
+// prune удаляет записи, которые уже не блокируют и вышли за окно подсчёта
+func (l *LoginLimiter) prune(now time.Time) {
+	for key, a := range l.entries {
+		if !a.blockedTill.After(now) && now.Sub(a.lastFailure) > l.cfg.Window {
+			delete(l.entries, key)
+		}
+	}
+}
+
+func (l *LoginLimiter) keys(username, ip string) []string {
+	var keys []string
+	if k := userKey(username); k != "" {
+		keys = append(keys, k)
+	}
+	if k := ipKey(ip); k != "" {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func userKey(username string) string {
+	username = strings.ToLower(strings.TrimSpace(username))
+	if username == "" {
+		return ""
+	}
+	return "user:" + username
+}
+
+func ipKey(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	return "ip:" + ip
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+)
+
+// testLimiter создаёт LoginLimiter с управляемыми часами
+func testLimiter(cfg config.LoginLimitConfig) (*LoginLimiter, *time.Time) {
+	l := NewLoginLimiter(cfg)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+// -----------------------------
+// Экспоненциальная задержка и блокировка по имени пользователя
+// -----------------------------
+func TestLoginLimiter_BackoffAndLockout(t *testing.T) {
+	l, now := testLimiter(config.LoginLimitConfig{
+		MaxFailuresPerUser: 4,
+		BaseDelay:          time.Second,
+		MaxDelay:           time.Minute,
+		Lockout:            10 * time.Minute,
+	})
+
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		wait, locked := l.Failure("alex", "10.0.0.1")
+		if wait != want || len(locked) != 0 {
+			t.Fatalf("failure %d: expected %v without lockout, got %v %v", i+1, want, wait, locked)
+		}
+		if got := l.Check("alex", "10.0.0.2"); got != want {
+			t.Fatalf("failure %d: expected check %v, got %v", i+1, want, got)
+		}
+		*now = now.Add(want)
+		if got := l.Check("alex", "10.0.0.2"); got != 0 {
+			t.Fatalf("failure %d: expected attempt allowed after delay, got %v", i+1, got)
+		}
+	}
+
+	wait, locked := l.Failure("Alex", "10.0.0.1")
+	if wait != 10*time.Minute || len(locked) != 1 || locked[0] != ScopeUser {
+		t.Fatalf("expected user lockout for 10m, got %v %v", wait, locked)
+	}
+	// Имя пользователя сравнивается без учёта регистра, IP не важен
+	*now = now.Add(9 * time.Minute)
+	if got := l.Check("ALEX", "192.168.1.1"); got != time.Minute {
+		t.Errorf("expected 1m left, got %v", got)
+	}
+	*now = now.Add(time.Minute)
+	if got := l.Check("alex", "192.168.1.1"); got != 0 {
+		t.Errorf("expected lockout to expire, got %v", got)
+	}
+}
+
+// -----------------------------
+// Блокировка по IP при переборе разных имён
+// -----------------------------
+func TestLoginLimiter_IPLockout(t *testing.T) {
+	l, _ := testLimiter(config.LoginLimitConfig{MaxFailuresPerIP: 3, Lockout: time.Hour})
+
+	l.Failure("a", "10.0.0.1")
+	l.Failure("b", "10.0.0.1")
+	_, locked := l.Failure("c", "10.0.0.1")
+	if len(locked) != 1 || locked[0] != ScopeIP {
+		t.Fatalf("expected ip lockout, got %v", locked)
+	}
+	if got := l.Check("d", "10.0.0.1"); got != time.Hour {
+		t.Errorf("expected new username blocked from the same ip, got %v", got)
+	}
+	if got := l.Check("d", "10.0.0.2"); got != 0 {
+		t.Errorf("expected other ip allowed, got %v", got)
+	}
+}
+
+// -----------------------------
+// Сброс счётчика: успешный вход и окно без неудач
+// -----------------------------
+func TestLoginLimiter_Reset(t *testing.T) {
+	l, now := testLimiter(config.LoginLimitConfig{MaxFailuresPerUser: 2, Window: time.Hour})
+
+	l.Failure("alex", "10.0.0.1")
+	l.Success("alex")
+	*now = now.Add(time.Minute)
+	if _, locked := l.Failure("alex", "10.0.0.1"); len(locked) != 0 {
+		t.Errorf("expected counter reset after success, got lockout %v", locked)
+	}
+
+	*now = now.Add(2 * time.Hour)
+	if _, locked := l.Failure("alex", "10.0.0.1"); len(locked) != 0 {
+		t.Errorf("expected counter reset after window, got lockout %v", locked)
+	}
+}
+
+// -----------------------------
+// Определение IP клиента
+// -----------------------------
+func TestLoginLimiter_ClientIP(t *testing.T) {
+	req := httptest.NewRequest("POST", "/login", nil)
+	req.RemoteAddr = "10.0.0.1:5555"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+
+	if ip := NewLoginLimiter(config.LoginLimitConfig{}).ClientIP(req); ip != "10.0.0.1" {
+		t.Errorf("expected RemoteAddr when proxy is not trusted, got %s", ip)
+	}
+	trusted := NewLoginLimiter(config.LoginLimitConfig{TrustForwardedFor: true})
+	if ip := trusted.ClientIP(req); ip != "203.0.113.7" {
+		t.Errorf("expected X-Forwarded-For client, got %s", ip)
+	}
+}
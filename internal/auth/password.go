@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Алгоритмы хэширования паролей
+const (
+	PasswordArgon2id = "argon2id"
+	PasswordBcrypt   = "bcrypt"
+)
+
+// Параметры хэширования по умолчанию (рекомендации OWASP для argon2id)
+const (
+	defaultArgon2Memory      = 19 * 1024 // КиБ
+	defaultArgon2Iterations  = 2
+	defaultArgon2Parallelism = 1
+	defaultArgon2SaltLength  = 16
+	defaultArgon2KeyLength   = 32
+	defaultBcryptCost        = bcrypt.DefaultCost
+
+	minArgon2SaltLength = 8
+	minArgon2KeyLength  = 16
+
+	// bcryptMaxPasswordBytes — bcrypt не принимает пароли длиннее 72 байт
+	bcryptMaxPasswordBytes = 72
+)
+
+var (
+	// ErrPasswordMismatch — пароль не совпадает с хэшем
+	ErrPasswordMismatch = errors.New("password does not match")
+	// ErrUnknownPasswordHash — хэш в неизвестном формате (например, у пользователей
+	// внешнего провайдера, которым вход по паролю недоступен)
+	ErrUnknownPasswordHash = errors.New("unknown password hash format")
+)
+
+// -----------------------------
+// PasswordHasher
+// -----------------------------
+// Создаёт хэши паролей выбранным алгоритмом и проверяет хэши любого поддерживаемого
+// алгоритма. argon2id хранится в формате PHC:
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<соль base64>$<хэш base64>
+//
+// bcrypt — в своём стандартном формате $2a$<cost>$... (совместим с хэшами,
+// созданными до появления PasswordHasher). Verify сообщает, что хэш создан другим
+// алгоритмом или с устаревшими параметрами и его стоит пересчитать.
+// nil-значение использует параметры по умолчанию.
+type PasswordHasher struct {
+	algorithm  string
+	argon      argon2Params
+	bcryptCost int
+}
+
+// argon2Params — параметры argon2id, записываемые в хэш
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+// defaultPasswordHasher используется nil-значением PasswordHasher
+var defaultPasswordHasher, _ = NewPasswordHasher(config.PasswordConfig{})
+
+// NewPasswordHasher создаёт PasswordHasher; незаданные параметры получают значения по умолчанию
+func NewPasswordHasher(cfg config.PasswordConfig) (*PasswordHasher, error) {
+	h := &PasswordHasher{
+		algorithm:  cfg.Algorithm,
+		bcryptCost: cfg.BcryptCost,
+		argon: argon2Params{
+			memory:      cfg.Argon2.Memory,
+			iterations:  cfg.Argon2.Iterations,
+			parallelism: cfg.Argon2.Parallelism,
+			saltLength:  cfg.Argon2.SaltLength,
+			keyLength:   cfg.Argon2.KeyLength,
+		},
+	}
+	if h.algorithm == "" {
+		h.algorithm = PasswordArgon2id
+	}
+	if h.algorithm != PasswordArgon2id && h.algorithm != PasswordBcrypt {
+		return nil, fmt.Errorf("password: unknown algorithm %q", cfg.Algorithm)
+	}
+
+	if h.bcryptCost == 0 {
+		h.bcryptCost = defaultBcryptCost
+	}
+	if h.bcryptCost < bcrypt.MinCost || h.bcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("password: bcrypt_cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	if h.argon.memory == 0 {
+		h.argon.memory = defaultArgon2Memory
+	}
+	if h.argon.iterations == 0 {
+		h.argon.iterations = defaultArgon2Iterations
+	}
+	if h.argon.parallelism == 0 {
+		h.argon.parallelism = defaultArgon2Parallelism
+	}
+	if h.argon.saltLength == 0 {
+		h.argon.saltLength = defaultArgon2SaltLength
+	}
+	if h.argon.keyLength == 0 {
+		h.argon.keyLength = defaultArgon2KeyLength
+	}
+	if h.argon.saltLength < minArgon2SaltLength {
+		return nil, fmt.Errorf("password: argon2.salt_length must be at least %d", minArgon2SaltLength)
+	}
+	if h.argon.keyLength < minArgon2KeyLength {
+		return nil, fmt.Errorf("password: argon2.key_length must be at least %d", minArgon2KeyLength)
+	}
+	if h.argon.memory < 8*uint32(h.argon.parallelism) {
+		return nil, errors.New("password: argon2.memory must be at least 8 KiB per thread")
+	}
+	return h, nil
+}
+
+// Algorithm возвращает алгоритм, которым создаются новые хэши
+func (h *PasswordHasher) Algorithm() string {
+	if h == nil {
+		h = defaultPasswordHasher
+	}
+	return h.algorithm
+}
+
+// MaxPasswordBytes возвращает максимальную длину пароля в байтах,
+// которую принимает текущий алгоритм; 0 — без ограничения
+func (h *PasswordHasher) MaxPasswordBytes() int {
+	if h.Algorithm() == PasswordBcrypt {
+		return bcryptMaxPasswordBytes
+	}
+	return 0
+}
+
+// Hash хэширует пароль текущим алгоритмом
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	if h == nil {
+		h = defaultPasswordHasher
+	}
+	if h.algorithm == PasswordBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+
+	salt := make([]byte, h.argon.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.argon.iterations, h.argon.memory, h.argon.parallelism, h.argon.keyLength)
+	return encodeArgon2(h.argon, salt, key), nil
+}
+
+// Verify проверяет пароль по хэшу. needsRehash = true, если пароль верен, но хэш
+// создан другим алгоритмом или с другими параметрами. Неверный пароль — ErrPasswordMismatch.
+func (h *PasswordHasher) Verify(encoded, password string) (needsRehash bool, err error) {
+	if h == nil {
+		h = defaultPasswordHasher
+	}
+
+	switch {
+	case strings.HasPrefix(encoded, "$"+PasswordArgon2id+"$"):
+		params, salt, key, err := decodeArgon2(encoded)
+		if err != nil {
+			return false, err
+		}
+		actual := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, params.keyLength)
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return false, ErrPasswordMismatch
+		}
+		return h.algorithm != PasswordArgon2id || params != h.argon, nil
+
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, ErrPasswordMismatch
+			}
+			return false, err
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return false, err
+		}
+		return h.algorithm != PasswordBcrypt || cost != h.bcryptCost, nil
+	}
+	return false, ErrUnknownPasswordHash
+}
+
+// encodeArgon2 записывает хэш argon2id в формате PHC
+func encodeArgon2(p argon2Params, salt, key []byte) string {
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		PasswordArgon2id, argon2.Version, p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))
+}
+
+// decodeArgon2 разбирает хэш argon2id в формате PHC
+func decodeArgon2(encoded string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", соль, хэш
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("password: unsupported argon2 version %d", version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	if p.memory == 0 || p.iterations == 0 || p.parallelism == 0 {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	p.saltLength = uint32(len(salt))
+	p.keyLength = uint32(len(key))
+	return p, salt, key, nil
+}
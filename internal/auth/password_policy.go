@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+)
+
+// Значения политики паролей по умолчанию
+const (
+	defaultPasswordMinLength = 6
+	defaultPasswordMaxLength = 64
+
+	// minUsernameCheckLength — более короткие логины не проверяются на вхождение в пароль
+	minUsernameCheckLength = 3
+	// hashPrefixLength — длина префикса SHA-1 в списке утёкших паролей (как в range API HIBP)
+	hashPrefixLength = 5
+)
+
+// Правила политики паролей; используются как коды ошибок поля
+const (
+	RuleMinLength        = "min"
+	RuleMaxLength        = "max"
+	RuleRequireLower     = "require_lower"
+	RuleRequireUpper     = "require_upper"
+	RuleRequireDigit     = "require_digit"
+	RuleRequireSymbol    = "require_symbol"
+	RuleContainsUsername = "contains_username"
+	RuleBreached         = "breached"
+)
+
+// PolicyViolation — пароль нарушает правило Rule
+type PolicyViolation struct {
+	Rule string
+}
+
+func (v *PolicyViolation) Error() string {
+	return "password policy violation: " + v.Rule
+}
+
+// -----------------------------
+// PasswordPolicy
+// -----------------------------
+// Требования к новому паролю: длина (в символах), обязательные классы символов,
+// запрет логина внутри пароля и проверка по списку утёкших паролей.
+// Пароли при входе не проверяются — политика применяется только при установке пароля.
+// nil-значение проверяет только длину по умолчанию.
+type PasswordPolicy struct {
+	cfg      config.PasswordPolicyConfig
+	breached *BreachedPasswords
+	// maxBytes — ограничение длины в байтах со стороны алгоритма хэширования; 0 — нет
+	maxBytes int
+}
+
+// NewPasswordPolicy создаёт PasswordPolicy и загружает список утёкших паролей, если он задан
+func NewPasswordPolicy(cfg config.PasswordPolicyConfig) (*PasswordPolicy, error) {
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = defaultPasswordMinLength
+	}
+	if cfg.MaxLength <= 0 {
+		cfg.MaxLength = defaultPasswordMaxLength
+	}
+	if cfg.MaxLength < cfg.MinLength {
+		return nil, fmt.Errorf("password policy: max_length %d is less than min_length %d", cfg.MaxLength, cfg.MinLength)
+	}
+
+	p := &PasswordPolicy{cfg: cfg}
+	if cfg.BreachedList != "" {
+		list, err := LoadBreachedPasswords(cfg.BreachedList)
+		if err != nil {
+			return nil, err
+		}
+		p.breached = list
+	}
+	return p, nil
+}
+
+// LimitBytes дополнительно ограничивает длину пароля n байтами (0 — без ограничения).
+// Нужен для bcrypt, который не хэширует пароли длиннее 72 байт: max_length
+// считается в символах, и пароль из многобайтовых символов иначе превысил бы лимит.
+func (p *PasswordPolicy) LimitBytes(n int) {
+	p.maxBytes = n
+}
+
+// Check проверяет новый пароль пользователя username.
+// Возвращает *PolicyViolation с первым нарушенным правилом или nil.
+func (p *PasswordPolicy) Check(password, username string) error {
+	cfg := config.PasswordPolicyConfig{MinLength: defaultPasswordMinLength, MaxLength: defaultPasswordMaxLength}
+	if p != nil {
+		cfg = p.cfg
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < cfg.MinLength {
+		return &PolicyViolation{Rule: RuleMinLength}
+	}
+	if length > cfg.MaxLength || (p != nil && p.maxBytes > 0 && len(password) > p.maxBytes) {
+		return &PolicyViolation{Rule: RuleMaxLength}
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+	switch {
+	case cfg.RequireLower && !lower:
+		return &PolicyViolation{Rule: RuleRequireLower}
+	case cfg.RequireUpper && !upper:
+		return &PolicyViolation{Rule: RuleRequireUpper}
+	case cfg.RequireDigit && !digit:
+		return &PolicyViolation{Rule: RuleRequireDigit}
+	case cfg.RequireSymbol && !symbol:
+		return &PolicyViolation{Rule: RuleRequireSymbol}
+	}
+
+	if cfg.DisallowUsername && utf8.RuneCountInString(username) >= minUsernameCheckLength &&
+		strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return &PolicyViolation{Rule: RuleContainsUsername}
+	}
+
+	if p != nil && p.breached.Contains(password) {
+		return &PolicyViolation{Rule: RuleBreached}
+	}
+	return nil
+}
+
+// -----------------------------
+// BreachedPasswords
+// -----------------------------
+// Список утёкших паролей в виде SHA-1, сгруппированных по 5-символьному префиксу
+// (k-anonymity, как в range API Have I Been Pwned). Файл содержит по одному хэшу
+// на строку в формате HIBP: "<SHA-1 hex>[:<количество утечек>]"; пустые строки
+// и строки, начинающиеся с #, пропускаются. nil-значение не содержит ни одного пароля.
+type BreachedPasswords struct {
+	// ranges — отсортированные суффиксы хэшей по префиксу
+	ranges map[string][]string
+}
+
+// LoadBreachedPasswords загружает список утёкших паролей из файла path
+func LoadBreachedPasswords(path string) (*BreachedPasswords, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("breached passwords: %w", err)
+	}
+	defer f.Close()
+
+	b := &BreachedPasswords{ranges: make(map[string][]string)}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("breached passwords: %s:%d: expected SHA-1 hash", path, n)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("breached passwords: %s:%d: expected SHA-1 hash", path, n)
+		}
+		prefix := hash[:hashPrefixLength]
+		b.ranges[prefix] = append(b.ranges[prefix], hash[hashPrefixLength:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("breached passwords: %w", err)
+	}
+
+	for _, suffixes := range b.ranges {
+		sort.Strings(suffixes)
+	}
+	return b, nil
+}
+
+// Contains сообщает, есть ли пароль в списке
+func (b *BreachedPasswords) Contains(password string) bool {
+	if b == nil {
+		return false
+	}
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes := b.ranges[hash[:hashPrefixLength]]
+	suffix := hash[hashPrefixLength:]
+	i := sort.SearchStrings(suffixes, suffix)
+	return i < len(suffixes) && suffixes[i] == suffix
+}
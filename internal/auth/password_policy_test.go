@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+)
+
+// violation возвращает правило, нарушенное паролем, или ""
+func violation(t *testing.T, p *PasswordPolicy, password, username string) string {
+	t.Helper()
+	err := p.Check(password, username)
+	if err == nil {
+		return ""
+	}
+	var v *PolicyViolation
+	if !errors.As(err, &v) {
+		t.Fatalf("Expected PolicyViolation, got %v", err)
+	}
+	return v.Rule
+}
+
+func TestPasswordPolicy_Rules(t *testing.T) {
+	p, err := NewPasswordPolicy(config.PasswordPolicyConfig{
+		MinLength:     8,
+		MaxLength:     12,
+		RequireLower:  true,
+		RequireSymbol: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for password, want := range map[string]string{
+		"пароль-1":       "", // длина считается в символах, а не в байтах
+		"abc-1":          RuleMinLength,
+		"abcdefgh-12345": RuleMaxLength,
+		"ABCDEFGH-1":     RuleRequireLower,
+		"abcdefgh1":      RuleRequireSymbol,
+		"alexalex-1":     "", // DisallowUsername выключен
+	} {
+		if got := violation(t, p, password, "alex"); got != want {
+			t.Errorf("%q: expected %q, got %q", password, want, got)
+		}
+	}
+
+	// nil-политика проверяет только длину по умолчанию
+	var empty *PasswordPolicy
+	if got := violation(t, empty, "12345", "alex"); got != RuleMinLength {
+		t.Errorf("Expected default min length, got %q", got)
+	}
+	if got := violation(t, empty, "alex123", "alex"); got != "" {
+		t.Errorf("Expected nil policy to accept password, got %q", got)
+	}
+
+	if _, err := NewPasswordPolicy(config.PasswordPolicyConfig{MinLength: 10, MaxLength: 8}); err == nil {
+		t.Error("Expected error for max_length < min_length")
+	}
+}
+
+func TestPasswordPolicy_LimitBytes(t *testing.T) {
+	p, err := NewPasswordPolicy(config.PasswordPolicyConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 64 символа по 2 байта укладываются в max_length, но не в 72 байта bcrypt
+	long := strings.Repeat("я", defaultPasswordMaxLength)
+	if got := violation(t, p, long, "alex"); got != "" {
+		t.Fatalf("Expected password to pass without byte limit, got %q", got)
+	}
+
+	bcryptHasher := testHasher(t, config.PasswordConfig{Algorithm: PasswordBcrypt})
+	p.LimitBytes(bcryptHasher.MaxPasswordBytes())
+	if got := violation(t, p, long, "alex"); got != RuleMaxLength {
+		t.Errorf("Expected %q for password over 72 bytes, got %q", RuleMaxLength, got)
+	}
+	fits := strings.Repeat("я", bcryptMaxPasswordBytes/2)
+	if got := violation(t, p, fits, "alex"); got != "" {
+		t.Errorf("Expected 72-byte password to pass, got %q", got)
+	}
+	if _, err := bcryptHasher.Hash(fits); err != nil {
+		t.Errorf("Expected bcrypt to hash a policy-valid password, got %v", err)
+	}
+
+	if n := testHasher(t, config.PasswordConfig{}).MaxPasswordBytes(); n != 0 {
+		t.Errorf("Expected no byte limit for argon2id, got %d", n)
+	}
+}
+
+func TestBreachedPasswords(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "breached.txt")
+	// SHA-1("password123") в нижнем регистре и без счётчика, SHA-1("qwerty") с ним
+	os.WriteFile(list, []byte("cbfdac6008f9cab4083784cbd1874f76618d2a97\n\nB1B3773A05C0ED0176787A4F1574FF0075F7521E:3912816\n"), 0o600)
+
+	b, err := LoadBreachedPasswords(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for password, want := range map[string]bool{"password123": true, "qwerty": true, "Correct7Horse": false} {
+		if got := b.Contains(password); got != want {
+			t.Errorf("%q: expected %v, got %v", password, want, got)
+		}
+	}
+
+	broken := filepath.Join(dir, "broken.txt")
+	os.WriteFile(broken, []byte("not-a-hash:1\n"), 0o600)
+	if _, err := LoadBreachedPasswords(broken); err == nil {
+		t.Error("Expected error for malformed line")
+	}
+	if _, err := NewPasswordPolicy(config.PasswordPolicyConfig{BreachedList: filepath.Join(dir, "missing.txt")}); err == nil {
+		t.Error("Expected error for missing list")
+	}
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testHasher создаёт PasswordHasher с лёгкими параметрами, чтобы тесты шли быстро
+func testHasher(t *testing.T, cfg config.PasswordConfig) *PasswordHasher {
+	t.Helper()
+	if cfg.Argon2.Memory == 0 {
+		cfg.Argon2.Memory = 1024
+	}
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = bcrypt.MinCost
+	}
+	h, err := NewPasswordHasher(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// -----------------------------
+// argon2id в формате PHC
+// -----------------------------
+func TestPasswordHasher_Argon2id(t *testing.T) {
+	h := testHasher(t, config.PasswordConfig{})
+
+	hash, err := h.Hash("password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=2,p=1$") || strings.Count(hash, "$") != 5 {
+		t.Fatalf("Unexpected PHC string: %s", hash)
+	}
+	if other, _ := h.Hash("password123"); other == hash {
+		t.Error("Expected random salt per hash")
+	}
+
+	if rehash, err := h.Verify(hash, "password123"); err != nil || rehash {
+		t.Fatalf("Expected match without rehash, got %v %v", rehash, err)
+	}
+	if _, err := h.Verify(hash, "wrong"); !errors.Is(err, ErrPasswordMismatch) {
+		t.Fatalf("Expected ErrPasswordMismatch, got %v", err)
+	}
+
+	// Изменение параметров требует пересчёта, но старый хэш остаётся рабочим
+	stronger := testHasher(t, config.PasswordConfig{Argon2: config.Argon2Config{Memory: 2048, Iterations: 3}})
+	if rehash, err := stronger.Verify(hash, "password123"); err != nil || !rehash {
+		t.Fatalf("Expected rehash after parameter change, got %v %v", rehash, err)
+	}
+}
+
+// -----------------------------
+// bcrypt и переход между алгоритмами
+// -----------------------------
+func TestPasswordHasher_Bcrypt(t *testing.T) {
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+
+	bh := testHasher(t, config.PasswordConfig{Algorithm: PasswordBcrypt})
+	if rehash, err := bh.Verify(string(legacy), "password123"); err != nil || rehash {
+		t.Fatalf("Expected match without rehash, got %v %v", rehash, err)
+	}
+	if _, err := bh.Verify(string(legacy), "wrong"); !errors.Is(err, ErrPasswordMismatch) {
+		t.Fatalf("Expected ErrPasswordMismatch, got %v", err)
+	}
+	higher := testHasher(t, config.PasswordConfig{Algorithm: PasswordBcrypt, BcryptCost: bcrypt.MinCost + 1})
+	if rehash, _ := higher.Verify(string(legacy), "password123"); !rehash {
+		t.Error("Expected rehash after cost change")
+	}
+
+	// Хэши bcrypt пересчитываются в argon2id, если он выбран алгоритмом
+	ah := testHasher(t, config.PasswordConfig{})
+	if rehash, err := ah.Verify(string(legacy), "password123"); err != nil || !rehash {
+		t.Fatalf("Expected bcrypt hash to need rehash, got %v %v", rehash, err)
+	}
+	// ... и наоборот
+	hash, _ := ah.Hash("password123")
+	if rehash, err := bh.Verify(hash, "password123"); err != nil || !rehash {
+		t.Fatalf("Expected argon2id hash to need rehash, got %v %v", rehash, err)
+	}
+}
+
+func TestPasswordHasher_InvalidInput(t *testing.T) {
+	h := testHasher(t, config.PasswordConfig{})
+	for _, hash := range []string{"", "!external", "$argon2id$v=19$m=0,t=1,p=1$c2FsdA$a2V5", "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5"} {
+		if _, err := h.Verify(hash, "password123"); err == nil {
+			t.Errorf("Expected error for hash %q", hash)
+		}
+	}
+
+	for _, cfg := range []config.PasswordConfig{
+		{Algorithm: "md5"},
+		{BcryptCost: 100},
+		{Argon2: config.Argon2Config{SaltLength: 4}},
+	} {
+		if _, err := NewPasswordHasher(cfg); err == nil {
+			t.Errorf("Expected config error for %+v", cfg)
+		}
+	}
+}
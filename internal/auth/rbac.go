@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/go-portfolio/rest-api/internal/problem"
+)
+
+// Роли пользователей
+const (
+	RoleAdmin  = "admin"  // управляет всеми задачами и пользователями
+	RoleMember = "member" // работает со своими задачами
+	RoleViewer = "viewer" // только чтение своих задач
+)
+
+// Permission — разрешение на действие; значения совпадают со scope в токене
+type Permission string
+
+// Разрешения API
+const (
+	PermTasksRead   Permission = "tasks:read"  // чтение задач
+	PermTasksWrite  Permission = "tasks:write" // создание, изменение и удаление задач
+	PermTasksManage Permission = "tasks:admin" // доступ к задачам других пользователей
+	PermUsersManage Permission = "users:admin" // управление пользователями
+)
+
+// rolePermissions — разрешения, которые даёт каждая роль
+var rolePermissions = map[string][]Permission{
+	RoleAdmin:  {PermTasksRead, PermTasksWrite, PermTasksManage, PermUsersManage},
+	RoleMember: {PermTasksRead, PermTasksWrite},
+	RoleViewer: {PermTasksRead},
+}
+
+// ValidRole сообщает, известна ли роль
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// ValidScope сообщает, является ли scope известным разрешением
+func ValidScope(scope string) bool {
+	for _, perm := range rolePermissions[RoleAdmin] {
+		if string(perm) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleScopes возвращает разрешения роли в виде scope для токена
+func RoleScopes(role string) []string {
+	perms := rolePermissions[role]
+	scopes := make([]string, len(perms))
+	for i, p := range perms {
+		scopes[i] = string(p)
+	}
+	return scopes
+}
+
+// Can сообщает, есть ли у пользователя разрешение perm.
+// Разрешение должно давать роль пользователя; если токен ограничен
+// списком scope, разрешение должно быть ещё и в этом списке.
+// Токены без роли (выпущенные до появления ролей) считаются токенами участника.
+func (p *Principal) Can(perm Permission) bool {
+	role := p.Role
+	if role == "" {
+		role = RoleMember
+	}
+
+	granted := false
+	for _, rp := range rolePermissions[role] {
+		if rp == perm {
+			granted = true
+			break
+		}
+	}
+	if !granted || len(p.Scopes) == 0 {
+		return granted
+	}
+
+	for _, s := range p.Scopes {
+		if s == string(perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission возвращает middleware, пропускающий только пользователей с разрешением perm.
+// Ставится после VerifyToken: VerifyToken(tm)(RequirePermission(perm)(handler)).
+func RequirePermission(perm Permission) func(http.Handler) http.Handler {
+	return RequireAccess(perm, perm)
+}
+
+// RequireAccess возвращает middleware, требующий разрешение read для безопасных
+// методов (GET, HEAD, OPTIONS) и разрешение write для всех остальных.
+// Ставится после VerifyToken.
+func RequireAccess(read, write Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				problem.Write(w, r, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+
+			perm := write
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				perm = read
+			}
+			if !principal.Can(perm) {
+				problem.Write(w, r, http.StatusForbidden, "missing permission "+string(perm))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
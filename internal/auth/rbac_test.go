@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+	"github.com/go-portfolio/rest-api/internal/problem"
+)
+
+// -----------------------------
+// Разрешения ролей и ограничение scope
+// -----------------------------
+func TestPrincipal_Can(t *testing.T) {
+	cases := []struct {
+		name      string
+		principal Principal
+		perm      Permission
+		want      bool
+	}{
+		{"viewer reads", Principal{Role: RoleViewer}, PermTasksRead, true},
+		{"viewer cannot write", Principal{Role: RoleViewer}, PermTasksWrite, false},
+		{"member writes", Principal{Role: RoleMember}, PermTasksWrite, true},
+		{"member cannot manage", Principal{Role: RoleMember}, PermTasksManage, false},
+		{"admin manages", Principal{Role: RoleAdmin}, PermUsersManage, true},
+		{"legacy token is member", Principal{}, PermTasksWrite, true},
+		{"unknown role", Principal{Role: "root"}, PermTasksRead, false},
+		// scope сужает права роли, но не расширяет их
+		{"scope narrows admin", Principal{Role: RoleAdmin, Scopes: []string{"tasks:read"}}, PermTasksWrite, false},
+		{"scope cannot widen viewer", Principal{Role: RoleViewer, Scopes: []string{"tasks:write"}}, PermTasksWrite, false},
+	}
+
+	for _, c := range cases {
+		if got := c.principal.Can(c.perm); got != c.want {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, got)
+		}
+	}
+}
+
+// -----------------------------
+// Middleware RequireAccess
+// -----------------------------
+func TestRequireAccess(t *testing.T) {
+	handler := RequireAccess(PermTasksRead, PermTasksWrite)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }),
+	)
+
+	call := func(method string, p *Principal) int {
+		req := httptest.NewRequest(method, "/tasks", nil)
+		if p != nil {
+			req = req.WithContext(WithPrincipal(req.Context(), p))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	viewer := &Principal{UserID: 1, Role: RoleViewer}
+	if code := call(http.MethodGet, viewer); code != http.StatusOK {
+		t.Errorf("viewer GET: expected 200, got %d", code)
+	}
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		if code := call(method, viewer); code != http.StatusForbidden {
+			t.Errorf("viewer %s: expected 403, got %d", method, code)
+		}
+	}
+	if code := call(http.MethodPost, &Principal{UserID: 2, Role: RoleMember}); code != http.StatusOK {
+		t.Errorf("member POST: expected 200, got %d", code)
+	}
+	if code := call(http.MethodGet, nil); code != http.StatusUnauthorized {
+		t.Errorf("anonymous: expected 401, got %d", code)
+	}
+}
+
+// TestMiddlewareProblems проверяет, что ошибки middleware возвращаются в формате problem+json
+func TestMiddlewareProblems(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	tm, err := NewTokenManager(config.JwtConfig{JwtSecretKey: "test-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, c := range map[string]struct {
+		handler http.Handler
+		req     *http.Request
+		status  int
+	}{
+		"missing token": {VerifyToken(tm)(ok), httptest.NewRequest(http.MethodGet, "/tasks", nil), http.StatusUnauthorized},
+		"forbidden": {
+			RequireAccess(PermTasksRead, PermTasksWrite)(ok),
+			httptest.NewRequest(http.MethodPost, "/tasks", nil).WithContext(
+				WithPrincipal(context.Background(), &Principal{UserID: 1, Role: RoleViewer})),
+			http.StatusForbidden,
+		},
+	} {
+		w := httptest.NewRecorder()
+		problem.RequestID(c.handler).ServeHTTP(w, c.req)
+
+		var p problem.Problem
+		json.NewDecoder(w.Body).Decode(&p)
+		if w.Code != c.status || w.Header().Get("Content-Type") != problem.ContentType {
+			t.Errorf("%s: expected problem+json %d, got %d %q", name, c.status, w.Code, w.Header().Get("Content-Type"))
+		}
+		if p.Status != c.status || p.Instance != "/tasks" || p.RequestID == "" || p.Detail == "" {
+			t.Errorf("%s: unexpected problem %+v", name, p)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// -----------------------------
+// Тестовые векторы RFC 6238 (приложение B, SHA1)
+// -----------------------------
+func TestHOTP_RFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, c := range cases {
+		if got := hotp(key, uint64(TOTPStep(time.Unix(c.unix, 0))), 8); got != c.want {
+			t.Errorf("T=%d: expected %s, got %s", c.unix, c.want, got)
+		}
+	}
+}
+
+// -----------------------------
+// Проверка кода с допуском по времени
+// -----------------------------
+func TestValidateTOTP(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+
+	code, err := TOTPCode(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "050471" {
+		t.Fatalf("expected 050471, got %s", code)
+	}
+
+	// Секрет принимается в нижнем регистре и с выравниванием
+	if step, ok := ValidateTOTP(strings.ToLower(secret), code, now.Add(TOTPPeriod)); !ok || step != TOTPStep(now) {
+		t.Errorf("expected code valid in the next period with step %d, got %d %v", TOTPStep(now), step, ok)
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(3*TOTPPeriod)); ok {
+		t.Error("expected code expired after skew")
+	}
+	if _, ok := ValidateTOTP(secret, "000000", now); ok {
+		t.Error("expected wrong code rejected")
+	}
+	if _, ok := ValidateTOTP("not base32!", code, now); ok {
+		t.Error("expected invalid secret rejected")
+	}
+}
+
+// -----------------------------
+// otpauth:// URI
+// -----------------------------
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("rest-api", "alex", "JBSWY3DPEHPK3PXP")
+	for _, want := range []string{"otpauth://totp/rest-api:alex?", "secret=JBSWY3DPEHPK3PXP", "issuer=rest-api", "digits=6", "period=30"} {
+		if !strings.Contains(uri, want) {
+			t.Errorf("expected %q in %s", want, uri)
+		}
+	}
+}
@@ -2,9 +2,11 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
-	"log"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v3"
@@ -18,14 +20,153 @@ type Config struct {
 		User     string `yaml:"user"`
 		Password string `yaml:"password"`
 		Name     string `yaml:"name"`
-		SslMode  string `yaml:"sslmode"`		
+		SslMode  string `yaml:"sslmode"`
 	} `yaml:"database"`
 	Migrations struct {
 		Path string `yaml:"path"`
 	} `yaml:"migrations"`
-	Jwt struct {
-		JwtSecretKey string `yaml:"jwtkey"`
-	} `yaml:"jwt"`
+	Jwt      JwtConfig        `yaml:"jwt"`
+	Login    LoginLimitConfig `yaml:"login"`
+	Mail     MailConfig       `yaml:"mail"`
+	Account  AccountConfig    `yaml:"account"`
+	OIDC     OIDCConfig       `yaml:"oidc"`
+	Password PasswordConfig   `yaml:"password"`
+	Tasks    TasksConfig      `yaml:"tasks"`
+}
+
+// TasksConfig — настройки API задач.
+// RequireIfMatch требует заголовок If-Match с ETag задачи в PUT, PATCH и DELETE:
+// без него запрос отклоняется с 428 Precondition Required. Если выключено,
+// If-Match проверяется только когда клиент его передал.
+type TasksConfig struct {
+	RequireIfMatch bool `yaml:"require_if_match"`
+}
+
+// PasswordConfig задаёт хэширование паролей.
+// Algorithm — argon2id (по умолчанию) или bcrypt: им хэшируются новые пароли.
+// Хэш, созданный другим алгоритмом или с другими параметрами, пересчитывается
+// при следующем успешном входе пользователя. Нулевые значения заменяются значениями по умолчанию.
+type PasswordConfig struct {
+	Algorithm  string               `yaml:"algorithm"`
+	BcryptCost int                  `yaml:"bcrypt_cost"`
+	Argon2     Argon2Config         `yaml:"argon2"`
+	Policy     PasswordPolicyConfig `yaml:"policy"`
+}
+
+// PasswordPolicyConfig — требования к паролю при регистрации, смене и сбросе.
+// Длина считается в символах. BreachedList — файл со списком SHA-1 утёкших
+// паролей в формате Have I Been Pwned ("<hash>:<count>" на строку); пустое значение
+// отключает проверку.
+type PasswordPolicyConfig struct {
+	MinLength        int    `yaml:"min_length"`        // по умолчанию 6
+	MaxLength        int    `yaml:"max_length"`        // по умолчанию 64
+	RequireLower     bool   `yaml:"require_lower"`     // строчная буква
+	RequireUpper     bool   `yaml:"require_upper"`     // заглавная буква
+	RequireDigit     bool   `yaml:"require_digit"`     // цифра
+	RequireSymbol    bool   `yaml:"require_symbol"`    // символ, не являющийся буквой или цифрой
+	DisallowUsername bool   `yaml:"disallow_username"` // пароль не может содержать логин
+	BreachedList     string `yaml:"breached_list"`
+}
+
+// Argon2Config — параметры argon2id
+type Argon2Config struct {
+	Memory      uint32 `yaml:"memory"`      // память в КиБ
+	Iterations  uint32 `yaml:"iterations"`  // число проходов
+	Parallelism uint8  `yaml:"parallelism"` // число потоков
+	SaltLength  uint32 `yaml:"salt_length"` // длина соли в байтах
+	KeyLength   uint32 `yaml:"key_length"`  // длина хэша в байтах
+}
+
+// OIDCConfig — вход через внешних провайдеров OpenID Connect
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig `yaml:"providers"`
+}
+
+// OIDCProviderConfig — один провайдер OpenID Connect.
+// Name используется в URL: /auth/oidc/{name}/login и /auth/oidc/{name}/callback.
+// Issuer — адрес, по которому доступен /.well-known/openid-configuration.
+// ClientSecret можно задать в переменной окружения OIDC_<NAME>_CLIENT_SECRET.
+type OIDCProviderConfig struct {
+	Name         string   `yaml:"name"`
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"`
+	Scopes       []string `yaml:"scopes"` // по умолчанию openid, email, profile
+}
+
+// MailConfig задаёт отправку писем.
+// Driver: smtp — реальный SMTP-сервер, file — письма сохраняются в Dir
+// (для локальной разработки), memory — письма только хранятся в памяти.
+type MailConfig struct {
+	Driver string `yaml:"driver"`
+	From   string `yaml:"from"`
+	Dir    string `yaml:"dir"`
+	SMTP   struct {
+		Host     string `yaml:"host"`
+		Port     string `yaml:"port"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+	} `yaml:"smtp"`
+}
+
+// AccountConfig — параметры восстановления доступа и подтверждения email.
+// PublicURL — адрес, с которого строятся ссылки в письмах.
+// RequireVerifiedEmail: "" — не требовать подтверждения, "login" — не пускать
+// на вход, "tasks" — запретить создание задач, пока email не подтверждён.
+type AccountConfig struct {
+	PublicURL            string        `yaml:"public_url"`
+	ResetTTL             time.Duration `yaml:"reset_ttl"`  // срок жизни токена сброса пароля
+	VerifyTTL            time.Duration `yaml:"verify_ttl"` // срок жизни ссылки подтверждения email
+	RequireVerifiedEmail string        `yaml:"require_verified_email"`
+}
+
+// Значения AccountConfig.RequireVerifiedEmail
+const (
+	RequireVerifiedForLogin = "login"
+	RequireVerifiedForTasks = "tasks"
+)
+
+// LoginLimitConfig задаёт защиту POST /login от подбора пароля.
+// Нулевые значения заменяются значениями по умолчанию.
+type LoginLimitConfig struct {
+	MaxFailuresPerUser int           `yaml:"max_failures_per_user"` // неудач до блокировки имени пользователя
+	MaxFailuresPerIP   int           `yaml:"max_failures_per_ip"`   // неудач до блокировки IP
+	BaseDelay          time.Duration `yaml:"base_delay"`            // задержка после первой неудачи, далее удваивается
+	MaxDelay           time.Duration `yaml:"max_delay"`             // верхняя граница задержки
+	Lockout            time.Duration `yaml:"lockout"`               // длительность блокировки
+	Window             time.Duration `yaml:"window"`                // через сколько без неудач счётчик сбрасывается
+	TrustForwardedFor  bool          `yaml:"trust_forwarded_for"`   // брать IP клиента из X-Forwarded-For (только за доверенным прокси)
+}
+
+// JwtConfig описывает ключи подписи JWT.
+// JwtSecretKey — общий секрет HS256: используется для подписи, если не заданы
+// асимметричные ключи, и для проверки старых токенов без kid.
+// Keys — асимметричные ключи (RS256/ES256/EdDSA); SigningKey — kid ключа,
+// которым подписываются новые токены. Остальные ключи используются только
+// для проверки, что позволяет ротировать ключи без разлогинивания пользователей.
+// Issuer и Audience записываются в токены и проверяются при входящих запросах;
+// Leeway — допуск на расхождение часов при проверке exp/nbf/iat.
+type JwtConfig struct {
+	JwtSecretKey string         `yaml:"jwtkey"`
+	SigningKey   string         `yaml:"signing_key"`
+	Keys         []JwtKeyConfig `yaml:"keys"`
+	Issuer       string         `yaml:"issuer"`
+	Audience     string         `yaml:"audience"`
+	AccessTTL    time.Duration  `yaml:"access_ttl"`
+	RefreshTTL   time.Duration  `yaml:"refresh_ttl"`
+	Leeway       time.Duration  `yaml:"leeway"`
+	// ImpersonationTTL — срок жизни токена администратора, действующего от имени пользователя
+	ImpersonationTTL time.Duration `yaml:"impersonation_ttl"`
+}
+
+// JwtKeyConfig — один ключ подписи или проверки в формате PEM.
+// Для ключа подписи нужен PrivateKey; для ключа только проверки достаточно PublicKey.
+type JwtKeyConfig struct {
+	Kid        string `yaml:"kid"`
+	Algorithm  string `yaml:"algorithm"`
+	PrivateKey string `yaml:"private_key"`
+	PublicKey  string `yaml:"public_key"`
 }
 
 // LoadConfig загружает конфигурацию из YAML и .env
@@ -68,6 +209,23 @@ func LoadConfig() (*Config, error) {
 	if v := os.Getenv("JWT_SECRET_KEY"); v != "" {
 		cfg.Jwt.JwtSecretKey = v
 	}
+	if v := os.Getenv("SMTP_PASSWORD"); v != "" {
+		cfg.Mail.SMTP.Password = v
+	}
+
+	for i := range cfg.OIDC.Providers {
+		p := &cfg.OIDC.Providers[i]
+		env := "OIDC_" + strings.ToUpper(strings.ReplaceAll(p.Name, "-", "_")) + "_CLIENT_SECRET"
+		if v := os.Getenv(env); v != "" {
+			p.ClientSecret = v
+		}
+	}
+
+	switch cfg.Account.RequireVerifiedEmail {
+	case "", RequireVerifiedForLogin, RequireVerifiedForTasks:
+	default:
+		return nil, fmt.Errorf("account.require_verified_email: unknown value %q", cfg.Account.RequireVerifiedEmail)
+	}
 
 	return cfg, nil
 }
@@ -85,8 +243,6 @@ func (c *Config) DSN() string {
 	)
 }
 
-
-
 // ConfigPath автоматически ищет configs/config.yaml
 func сonfigPath() string {
 	// 1. Сначала смотрим переменную окружения CONFIG_PATH
@@ -118,4 +274,4 @@ func сonfigPath() string {
 
 	log.Fatal("Cannot find configs/config.yaml in any parent directory")
 	return ""
-}
\ No newline at end of file
+}
@@ -0,0 +1,94 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// assertJSON сравнивает JSON-документы без учёта порядка полей
+func assertJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid result %s: %v", got, err)
+	}
+	json.Unmarshal([]byte(want), &w)
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// Примеры из приложения A RFC 7396
+	for _, c := range []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+	} {
+		got, err := MergePatch([]byte(c.doc), []byte(c.patch))
+		if err != nil {
+			t.Fatalf("%s + %s: %v", c.doc, c.patch, err)
+		}
+		assertJSON(t, got, c.want)
+	}
+
+	if _, err := MergePatch([]byte(`{}`), []byte(`{`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("Expected ErrInvalidPatch, got %v", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	doc := `{"title":"Old","status":"todo","tags":["a","b"],"meta":{"x~y":1}}`
+	got, err := Apply([]byte(doc), []byte(`[
+		{"op":"test","path":"/status","value":"todo"},
+		{"op":"replace","path":"/status","value":"done"},
+		{"op":"add","path":"/tags/1","value":"c"},
+		{"op":"add","path":"/tags/-","value":"d"},
+		{"op":"remove","path":"/tags/0"},
+		{"op":"copy","from":"/title","path":"/meta/title"},
+		{"op":"move","from":"/meta/x~0y","path":"/count"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, got, `{"title":"Old","status":"done","tags":["c","b","d"],"meta":{"title":"Old"},"count":1}`)
+
+	for patch, want := range map[string]error{
+		`[{"op":"test","path":"/status","value":"done"}]`:     ErrNotApplicable,
+		`[{"op":"replace","path":"/missing","value":1}]`:      ErrNotApplicable,
+		`[{"op":"remove","path":"/tags/5"}]`:                  ErrNotApplicable,
+		`[{"op":"add","path":"/missing/x","value":1}]`:        ErrNotApplicable,
+		`[{"op":"rename","path":"/title"}]`:                   ErrInvalidPatch,
+		`[{"op":"add","path":"title","value":1}]`:             ErrInvalidPatch,
+		`[{"op":"replace","path":"/title"}]`:                  ErrInvalidPatch,
+		`[{"op":"move","from":"/meta","path":"/meta/inner"}]`: ErrInvalidPatch,
+		`{"op":"remove","path":"/title"}`:                     ErrInvalidPatch,
+	} {
+		if _, err := Apply([]byte(doc), []byte(patch)); !errors.Is(err, want) {
+			t.Errorf("%s: expected %v, got %v", patch, want, err)
+		}
+	}
+}
+
+func TestApply_TestComparesNumbersByValue(t *testing.T) {
+	doc := `{"count":1,"items":[{"n":100}]}`
+	for _, patch := range []string{
+		`[{"op":"test","path":"/count","value":1.0}]`,
+		`[{"op":"test","path":"/count","value":1e0}]`,
+		`[{"op":"test","path":"/items","value":[{"n":1E2}]}]`,
+	} {
+		if _, err := Apply([]byte(doc), []byte(patch)); err != nil {
+			t.Errorf("%s: %v", patch, err)
+		}
+	}
+	if _, err := Apply([]byte(doc), []byte(`[{"op":"test","path":"/count","value":"1"}]`)); !errors.Is(err, ErrNotApplicable) {
+		t.Errorf("Expected a string not to equal a number, got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+)
+
+// Message — текстовое письмо одному получателю
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// -----------------------------
+// Интерфейс Mailer
+// -----------------------------
+// Отправка писем (сброс пароля, подтверждение email).
+// Обработчики зависят только от интерфейса, поэтому в тестах и при
+// локальной разработке используются FileMailer и MemoryMailer.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// New создаёт Mailer по cfg.Driver: smtp, file или memory (по умолчанию)
+func New(cfg config.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		if cfg.SMTP.Host == "" {
+			return nil, fmt.Errorf("mail: smtp host is required")
+		}
+		return NewSMTPMailer(cfg), nil
+	case "file":
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("mail: dir is required for file driver")
+		}
+		return &FileMailer{Dir: cfg.Dir, From: cfg.From}, nil
+	case "", "memory":
+		return &MemoryMailer{}, nil
+	default:
+		return nil, fmt.Errorf("mail: unknown driver %q", cfg.Driver)
+	}
+}
+
+// -----------------------------
+// SMTPMailer
+// -----------------------------
+// Отправляет письма через SMTP-сервер. Если задан логин, используется
+// PLAIN-аутентификация (net/smtp разрешает её только поверх TLS или на localhost).
+type SMTPMailer struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+// NewSMTPMailer создаёт SMTPMailer из конфигурации
+func NewSMTPMailer(cfg config.MailConfig) *SMTPMailer {
+	port := cfg.SMTP.Port
+	if port == "" {
+		port = "587"
+	}
+	m := &SMTPMailer{Addr: net.JoinHostPort(cfg.SMTP.Host, port), From: cfg.From}
+	if cfg.SMTP.Username != "" {
+		m.Auth = smtp.PlainAuth("", cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.Host)
+	}
+	return m
+}
+
+// Send отправляет письмо
+func (m *SMTPMailer) Send(msg Message) error {
+	data, err := render(m.From, msg)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(m.Addr, m.Auth, m.From, []string{msg.To}, data)
+}
+
+// -----------------------------
+// FileMailer
+// -----------------------------
+// Сохраняет каждое письмо в отдельный .eml файл в каталоге Dir.
+// Удобно для локальной разработки: ссылки из писем видны без почтового сервера.
+type FileMailer struct {
+	Dir  string
+	From string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// Send записывает письмо в файл
+func (m *FileMailer) Send(msg Message) error {
+	data, err := render(m.From, msg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0o700); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.seq++
+	name := fmt.Sprintf("%s-%03d.eml", time.Now().UTC().Format("20060102T150405"), m.seq)
+	m.mu.Unlock()
+
+	return os.WriteFile(filepath.Join(m.Dir, name), data, 0o600)
+}
+
+// -----------------------------
+// MemoryMailer
+// -----------------------------
+// Хранит отправленные письма в памяти; используется в тестах.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	Messages []Message
+}
+
+// Send сохраняет письмо
+func (m *MemoryMailer) Send(msg Message) error {
+	if err := validateHeaders(msg); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Messages = append(m.Messages, msg)
+	return nil
+}
+
+// Last возвращает последнее письмо на адрес to
+func (m *MemoryMailer) Last(to string) (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.Messages) - 1; i >= 0; i-- {
+		if m.Messages[i].To == to {
+			return m.Messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// render собирает письмо в формате RFC 5322
+func render(from string, msg Message) ([]byte, error) {
+	if err := validateHeaders(msg); err != nil {
+		return nil, err
+	}
+	if strings.ContainsAny(from, "\r\n") {
+		return nil, fmt.Errorf("mail: invalid sender")
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return b.Bytes(), nil
+}
+
+// validateHeaders защищает от подстановки заголовков через адрес или тему
+func validateHeaders(msg Message) error {
+	if msg.To == "" || strings.ContainsAny(msg.To, "\r\n") {
+		return fmt.Errorf("mail: invalid recipient")
+	}
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("mail: invalid subject")
+	}
+	return nil
+}
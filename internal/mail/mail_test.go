@@ -0,0 +1,65 @@
+package mail
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+)
+
+// -----------------------------
+// FileMailer пишет письмо в .eml файл
+// -----------------------------
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m, err := New(config.MailConfig{Driver: "file", Dir: dir, From: "noreply@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Send(Message{To: "alex@example.com", Subject: "Hello", Body: "line1\nline2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 || !strings.HasSuffix(files[0].Name(), ".eml") {
+		t.Fatalf("expected one .eml file, got %v", files)
+	}
+	data, _ := os.ReadFile(dir + "/" + files[0].Name())
+	for _, want := range []string{"From: noreply@example.com\r\n", "To: alex@example.com\r\n", "Subject: Hello\r\n", "line1\r\nline2"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected message to contain %q:\n%s", want, data)
+		}
+	}
+}
+
+// -----------------------------
+// Подстановка заголовков отклоняется
+// -----------------------------
+func TestMailer_HeaderInjection(t *testing.T) {
+	m := &MemoryMailer{}
+	if err := m.Send(Message{To: "a@example.com\r\nBcc: victim@example.com", Subject: "x"}); err == nil {
+		t.Error("expected error for CRLF in recipient")
+	}
+	if err := m.Send(Message{To: "a@example.com", Subject: "x\nBcc: victim@example.com"}); err == nil {
+		t.Error("expected error for CRLF in subject")
+	}
+	if len(m.Messages) != 0 {
+		t.Errorf("expected no messages stored, got %d", len(m.Messages))
+	}
+}
+
+// -----------------------------
+// Выбор реализации по драйверу
+// -----------------------------
+func TestNew(t *testing.T) {
+	if _, err := New(config.MailConfig{}); err != nil {
+		t.Errorf("expected memory mailer by default, got %v", err)
+	}
+	if _, err := New(config.MailConfig{Driver: "smtp"}); err == nil {
+		t.Error("expected error for smtp without host")
+	}
+	if _, err := New(config.MailConfig{Driver: "pigeon"}); err == nil {
+		t.Error("expected error for unknown driver")
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// APIKey описывает API-ключ пользователя (без самого секрета)
+// swagger:model APIKey
+type APIKey struct {
+	// ID ключа
+	// example: 1
+	ID int `json:"id"`
+
+	// ID владельца ключа
+	// example: 42
+	UserID int `json:"user_id"`
+
+	// Название ключа
+	// example: "ci-deploy"
+	Name string `json:"name"`
+
+	// Открытая часть ключа для опознания
+	// example: "rak_1a2b3c4d"
+	Prefix string `json:"prefix"`
+
+	// Разрешения ключа
+	// example: ["tasks:read"]
+	Scopes []string `json:"scopes"`
+
+	// Дата создания в формате RFC3339
+	CreatedAt time.Time `json:"created_at"`
+
+	// Время последнего использования
+	LastUsedAt *time.Time `json:"last_used_at"`
+
+	// Срок действия; null — бессрочный ключ
+	ExpiresAt *time.Time `json:"expires_at"`
+
+	// Время отзыва
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Действия в журнале имперсонации
+const (
+	// ImpersonationStart — администратор получил токен от имени пользователя
+	ImpersonationStart = "start"
+	// ImpersonationWrite — изменяющий запрос, выполненный от имени пользователя
+	ImpersonationWrite = "write"
+)
+
+// ImpersonationEvent — запись журнала действий администратора от имени пользователя
+// swagger:model ImpersonationEvent
+type ImpersonationEvent struct {
+	// ID записи
+	ID int `json:"id"`
+
+	// Администратор, фактически выполнивший действие
+	// example: 1
+	AdminID int `json:"admin_id"`
+
+	// Пользователь, от имени которого выполнено действие
+	// example: 42
+	UserID int `json:"user_id"`
+
+	// jti токена имперсонации
+	TokenID string `json:"token_id"`
+
+	// Действие: start или write
+	// example: write
+	Action string `json:"action"`
+
+	// HTTP-метод и путь запроса
+	// example: DELETE
+	Method string `json:"method"`
+	// example: /tasks/7
+	Path string `json:"path"`
+
+	// IP клиента
+	// example: "203.0.113.7"
+	IP string `json:"ip"`
+
+	// HTTP-статус ответа; 0, если запрос не завершился
+	// example: 204
+	Status int `json:"status"`
+
+	// Время записи в формате RFC3339
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// OAuthClient описывает клиента OAuth 2.0 (без секрета)
+// swagger:model OAuthClient
+type OAuthClient struct {
+	// Внутренний ID
+	// example: 1
+	ID int `json:"id"`
+
+	// Идентификатор клиента для /oauth/token
+	// example: "cl_1a2b3c4d5e6f7a8b"
+	ClientID string `json:"client_id"`
+
+	// Название клиента
+	// example: "reporting"
+	Name string `json:"name"`
+
+	// ID пользователя, от имени которого выдаются токены client_credentials
+	// example: 1
+	OwnerID int `json:"owner_id"`
+
+	// Разрешённые redirect_uri для authorization_code
+	// example: ["https://app.example.com/callback"]
+	RedirectURIs []string `json:"redirect_uris"`
+
+	// Разрешённые grant_type
+	// example: ["authorization_code","client_credentials"]
+	GrantTypes []string `json:"grant_types"`
+
+	// Максимальный набор разрешений клиента
+	// example: ["tasks:read"]
+	Scopes []string `json:"scopes"`
+
+	// Публичный клиент — без секрета, authorization_code только с PKCE
+	Public bool `json:"public"`
+
+	// Дата регистрации в формате RFC3339
+	CreatedAt time.Time `json:"created_at"`
+
+	// Время отзыва
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasGrantType сообщает, разрешён ли клиенту grant_type
+func (c *OAuthClient) HasGrantType(grantType string) bool {
+	for _, g := range c.GrantTypes {
+		if g == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRedirectURI сообщает, зарегистрирован ли redirect_uri (точное совпадение)
+func (c *OAuthClient) HasRedirectURI(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Session описывает сессию входа пользователя
+// swagger:model Session
+type Session struct {
+	// ID сессии
+	// example: 12
+	ID int `json:"id"`
+
+	// ID пользователя
+	// example: 42
+	UserID int `json:"user_id"`
+
+	// Устройство: заголовок User-Agent при входе
+	// example: "Mozilla/5.0 (X11; Linux x86_64)"
+	UserAgent string `json:"user_agent"`
+
+	// IP клиента при входе
+	// example: "203.0.113.7"
+	IP string `json:"ip"`
+
+	// Время входа в формате RFC3339
+	CreatedAt time.Time `json:"created_at"`
+
+	// Время последнего запроса с токеном сессии
+	LastSeenAt time.Time `json:"last_seen_at"`
+
+	// Сессия, которой выполнен текущий запрос
+	Current bool `json:"current"`
+}
@@ -34,4 +34,8 @@ type Task struct {
     // ID пользователя, которому принадлежит задача
     // example: 42
     UserID int `json:"user_id" db:"user_id" validate:"required"`
+
+    // Версия задачи; увеличивается при каждом изменении и передаётся в ETag
+    // example: 3
+    Version int `json:"version"`
 }
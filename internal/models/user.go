@@ -24,9 +24,25 @@ type User struct {
     // Хэш пароля пользователя
     // example: "$2a$10$E0NRl..."
     // min length: 6
-    Password string `json:"password_hash" validate:"required,min=6"`
+    Password string `json:"password_hash,omitempty" validate:"required,min=6"`
+
+    // Отображаемое имя
+    // example: "Алексей"
+    DisplayName string `json:"display_name,omitempty"`
+
+    // Роль пользователя
+    // example: "member"
+    // допустимые значения: admin, member, viewer
+    Role string `json:"role"`
+
+    // Время подтверждения email; отсутствует, пока адрес не подтверждён
+    // example: "2025-08-22T17:05:00Z"
+    EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 
     // Дата создания пользователя в формате RFC3339
     // example: "2025-08-22T17:00:00Z"
     CreatedAt time.Time `json:"created_at"`
+
+    // Время soft-удаления учётной записи
+    DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
@@ -0,0 +1,336 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Значения по умолчанию
+var defaultScopes = []string{"openid", "email", "profile"}
+
+const (
+	// jwksMinRefresh — не чаще этого интервала перезапрашиваем JWKS при неизвестном kid
+	jwksMinRefresh = time.Minute
+	// idTokenLeeway — допуск на расхождение часов с провайдером
+	idTokenLeeway = time.Minute
+	// maxResponseSize — ограничение на размер ответов провайдера
+	maxResponseSize = 1 << 20
+)
+
+// ErrInvalidIDToken — ID-токен не прошёл проверку
+var ErrInvalidIDToken = errors.New("oidc: invalid id token")
+
+// Claims — данные пользователя из ID-токена
+type Claims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// metadata — нужная часть /.well-known/openid-configuration
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// -----------------------------
+// Provider
+// -----------------------------
+// Клиент одного провайдера OpenID Connect: authorization code flow с PKCE (S256).
+// Метаданные провайдера загружаются при первом обращении, поэтому недоступный
+// провайдер не мешает запуску приложения. Ключи проверки ID-токенов кэшируются
+// и перезапрашиваются, когда встречается неизвестный kid (ротация у провайдера).
+type Provider struct {
+	cfg    config.OIDCProviderConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	meta        *metadata
+	keys        map[string]auth.JWK
+	keysFetched time.Time
+}
+
+// NewProvider создаёт провайдер по конфигурации; client == nil — http.DefaultClient
+func NewProvider(cfg config.OIDCProviderConfig, client *http.Client) (*Provider, error) {
+	if cfg.Name == "" || cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc provider %q: name, issuer, client_id and redirect_url are required", cfg.Name)
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = defaultScopes
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{cfg: cfg, client: client}, nil
+}
+
+// Name возвращает имя провайдера из конфигурации
+func (p *Provider) Name() string { return p.cfg.Name }
+
+// AuthCodeURL возвращает адрес страницы входа провайдера
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange обменивает код авторизации на ID-токен и возвращает его проверенные claims
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		// client_secret_basic (RFC 6749, раздел 2.3.1)
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var resp struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("oidc token request: %w", err)
+	}
+	if status != http.StatusOK || resp.IDToken == "" {
+		if resp.Error != "" {
+			return nil, fmt.Errorf("oidc token request: %s: %s", resp.Error, resp.ErrorDescription)
+		}
+		return nil, fmt.Errorf("oidc token request: unexpected status %d", status)
+	}
+
+	return p.verifyIDToken(ctx, meta, resp.IDToken, nonce)
+}
+
+// verifyIDToken проверяет подпись, iss, aud, exp и nonce ID-токена (OIDC Core, 3.1.3.7)
+func (p *Provider) verifyIDToken(ctx context.Context, meta *metadata, raw, nonce string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(raw, claims,
+		func(t *jwt.Token) (interface{}, error) { return p.verificationKey(ctx, meta, t) },
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(idTokenLeeway),
+	)
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// verificationKey ищет ключ по kid; при неизвестном kid один раз перезагружает JWKS
+func (p *Provider) verificationKey(ctx context.Context, meta *metadata, t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	p.mu.Lock()
+	jwk, ok := p.findKey(kid)
+	stale := time.Since(p.keysFetched) > jwksMinRefresh
+	p.mu.Unlock()
+
+	if !ok && stale {
+		if err := p.fetchKeys(ctx, meta); err != nil {
+			return nil, err
+		}
+		p.mu.Lock()
+		jwk, ok = p.findKey(kid)
+		p.mu.Unlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	pub, err := jwk.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	if !auth.KeyMatchesMethod(pub, t.Method) {
+		return nil, errors.New("key does not match algorithm")
+	}
+	return pub, nil
+}
+
+// findKey возвращает ключ по kid; без kid подходит единственный ключ набора. Вызывается под p.mu
+func (p *Provider) findKey(kid string) (auth.JWK, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, true
+		}
+	}
+	k, ok := p.keys[kid]
+	return k, ok
+}
+
+// fetchKeys загружает JWKS провайдера
+func (p *Provider) fetchKeys(ctx context.Context, meta *metadata) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.JWKSURI, nil)
+	if err != nil {
+		return err
+	}
+	var set auth.JWKS
+	status, err := p.doJSON(req, &set)
+	if err != nil {
+		return fmt.Errorf("oidc jwks: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("oidc jwks: unexpected status %d", status)
+	}
+
+	keys := make(map[string]auth.JWK, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use == "" || k.Use == "sig" {
+			keys[k.Kid] = k
+		}
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.keysFetched = time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+// discover загружает метаданные провайдера и проверяет, что issuer совпадает с настроенным
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	meta := p.meta
+	p.mu.Unlock()
+	if meta != nil {
+		return meta, nil
+	}
+
+	wellKnown := strings.TrimRight(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+	meta = &metadata{}
+	status, err := p.doJSON(req, meta)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery: unexpected status %d", status)
+	}
+	if strings.TrimRight(meta.Issuer, "/") != strings.TrimRight(p.cfg.Issuer, "/") {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match configured %q", meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: incomplete provider metadata")
+	}
+
+	p.mu.Lock()
+	p.meta = meta
+	p.mu.Unlock()
+	return meta, nil
+}
+
+// doJSON выполняет запрос и декодирует JSON-ответ; возвращает HTTP-статус
+func (p *Provider) doJSON(req *http.Request, v interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v); err != nil {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
+
+// -----------------------------
+// PKCE и случайные значения
+// -----------------------------
+
+// NewCodeVerifier возвращает случайный code_verifier (RFC 7636)
+func NewCodeVerifier() (string, error) {
+	return randomString(32)
+}
+
+// CodeChallenge вычисляет code_challenge по методу S256
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomString возвращает n случайных байт в base64url
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NewProviders создаёт провайдеры из конфигурации, индексируя их по имени
+func NewProviders(cfgs []config.OIDCProviderConfig) (map[string]*Provider, error) {
+	providers := make(map[string]*Provider, len(cfgs))
+	for _, c := range cfgs {
+		if _, dup := providers[c.Name]; dup {
+			return nil, fmt.Errorf("oidc provider %q: duplicate name", c.Name)
+		}
+		p, err := NewProvider(c, nil)
+		if err != nil {
+			return nil, err
+		}
+		providers[c.Name] = p
+	}
+	return providers, nil
+}
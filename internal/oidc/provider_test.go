@@ -0,0 +1,140 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+	"github.com/go-portfolio/rest-api/internal/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// authorize проходит страницу входа заглушки и возвращает код из редиректа
+func authorize(t *testing.T, authURL string) url.Values {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected redirect from authorize, got %d", resp.StatusCode)
+	}
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc.Query()
+}
+
+func testProvider(t *testing.T) (*Provider, *oidctest.IdP) {
+	t.Helper()
+	idp := oidctest.NewIdP("rest-api", "s3cret")
+	t.Cleanup(idp.Close)
+	idp.User = oidctest.User{Subject: "u-1", Email: "alex@example.com", EmailVerified: true, PreferredUsername: "alex"}
+
+	p, err := NewProvider(config.OIDCProviderConfig{
+		Name:         "corp",
+		Issuer:       idp.Issuer(),
+		ClientID:     "rest-api",
+		ClientSecret: "s3cret",
+		RedirectURL:  "http://localhost:8080/auth/oidc/corp/callback",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, idp
+}
+
+// -----------------------------
+// Полный цикл: authorize → код → ID-токен
+// -----------------------------
+func TestProvider_CodeFlowWithPKCE(t *testing.T) {
+	p, _ := testProvider(t)
+	ctx := context.Background()
+	store := NewStateStore(0)
+
+	state, req, err := store.Begin("corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := p.AuthCodeURL(ctx, state, req.Nonce, CodeChallenge(req.CodeVerifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := authorize(t, authURL)
+	if q.Get("state") != state {
+		t.Fatalf("expected state %s, got %s", state, q.Get("state"))
+	}
+
+	back, ok := store.Take(state)
+	if !ok {
+		t.Fatal("state not found")
+	}
+	if _, ok := store.Take(state); ok {
+		t.Error("state must be single-use")
+	}
+
+	// Неверный code_verifier отклоняется провайдером
+	if _, err := p.Exchange(ctx, q.Get("code"), "wrong-verifier", back.Nonce); err == nil {
+		t.Error("expected error for wrong code_verifier")
+	}
+
+	q = authorize(t, authURL)
+	claims, err := p.Exchange(ctx, q.Get("code"), back.CodeVerifier, back.Nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "u-1" || claims.Email != "alex@example.com" || !claims.EmailVerified || claims.PreferredUsername != "alex" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+}
+
+// -----------------------------
+// Проверка ID-токена: nonce, audience, issuer
+// -----------------------------
+func TestProvider_RejectsInvalidIDToken(t *testing.T) {
+	p, idp := testProvider(t)
+	ctx := context.Background()
+	verifier, _ := NewCodeVerifier()
+
+	cases := map[string]func(jwt.MapClaims){
+		"nonce":    func(c jwt.MapClaims) { c["nonce"] = "other" },
+		"audience": func(c jwt.MapClaims) { c["aud"] = "another-client" },
+		"issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"expired":  func(c jwt.MapClaims) { c["exp"] = 1 },
+	}
+	for name, tamper := range cases {
+		idp.Tamper = tamper
+		authURL, err := p.AuthCodeURL(ctx, "state", "nonce", CodeChallenge(verifier))
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := authorize(t, authURL)
+		if _, err := p.Exchange(ctx, q.Get("code"), verifier, "nonce"); !errors.Is(err, ErrInvalidIDToken) {
+			t.Errorf("%s: expected ErrInvalidIDToken, got %v", name, err)
+		}
+	}
+}
+
+// -----------------------------
+// Issuer из discovery должен совпадать с настроенным
+// -----------------------------
+func TestProvider_IssuerMismatch(t *testing.T) {
+	idp := oidctest.NewIdP("rest-api", "")
+	defer idp.Close()
+
+	p, _ := NewProvider(config.OIDCProviderConfig{
+		Name:        "corp",
+		Issuer:      idp.Issuer() + "/other",
+		ClientID:    "rest-api",
+		RedirectURL: "http://localhost/cb",
+	}, nil)
+	if _, err := p.AuthCodeURL(context.Background(), "s", "n", "c"); err == nil {
+		t.Error("expected discovery error")
+	}
+}
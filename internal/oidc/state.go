@@ -0,0 +1,119 @@
+package oidc
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultStateTTL — сколько ждём возврата пользователя от провайдера
+const DefaultStateTTL = 10 * time.Minute
+
+// DefaultMaxStates — сколько незавершённых входов хранится одновременно
+const DefaultMaxStates = 10000
+
+// ErrTooManyStates — незавершённых входов слишком много, новый вход не начат
+var ErrTooManyStates = errors.New("oidc: too many pending logins")
+
+// AuthRequest — параметры начатого входа, нужные на шаге callback
+type AuthRequest struct {
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	expiresAt    time.Time
+}
+
+// -----------------------------
+// StateStore
+// -----------------------------
+// Хранит начатые входы по параметру state до возврата от провайдера.
+// Каждый state можно использовать один раз. Число незавершённых входов
+// ограничено Max: вход начинается без аутентификации, и иначе память можно
+// было бы исчерпать запросами /login. Срок жизни у всех входов одинаковый,
+// поэтому они лежат в списке в порядке истечения, и просроченные удаляются
+// с его начала без обхода всего хранилища. Состояние хранится в памяти
+// процесса: при нескольких экземплярах API нужна привязка сессий к экземпляру.
+type StateStore struct {
+	// Max — предельное число незавершённых входов; задаётся до начала работы
+	Max int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // state → элемент order
+	order   *list.List               // *pendingState по возрастанию expiresAt
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+type pendingState struct {
+	state string
+	req   AuthRequest
+}
+
+// NewStateStore создаёт хранилище на DefaultMaxStates входов; ttl <= 0 — DefaultStateTTL
+func NewStateStore(ttl time.Duration) *StateStore {
+	if ttl <= 0 {
+		ttl = DefaultStateTTL
+	}
+	return &StateStore{
+		Max:     DefaultMaxStates,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// Begin создаёт вход для провайдера: state, nonce и code_verifier.
+// Если незавершённых входов уже Max, возвращает ErrTooManyStates.
+func (s *StateStore) Begin(provider string) (state string, req AuthRequest, err error) {
+	if state, err = randomString(24); err != nil {
+		return "", AuthRequest{}, err
+	}
+	if req.Nonce, err = randomString(24); err != nil {
+		return "", AuthRequest{}, err
+	}
+	if req.CodeVerifier, err = NewCodeVerifier(); err != nil {
+		return "", AuthRequest{}, err
+	}
+	req.Provider = provider
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.expire(now)
+	if s.Max > 0 && len(s.entries) >= s.Max {
+		return "", AuthRequest{}, ErrTooManyStates
+	}
+	req.expiresAt = now.Add(s.ttl)
+	s.entries[state] = s.order.PushBack(&pendingState{state: state, req: req})
+	return state, req, nil
+}
+
+// Take возвращает и удаляет вход по state; просроченный или неизвестный state — false
+func (s *StateStore) Take(state string) (AuthRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[state]
+	if !ok {
+		return AuthRequest{}, false
+	}
+	delete(s.entries, state)
+	req := s.order.Remove(e).(*pendingState).req
+	if s.now().After(req.expiresAt) {
+		return AuthRequest{}, false
+	}
+	return req, true
+}
+
+// expire удаляет просроченные входы с начала списка
+func (s *StateStore) expire(now time.Time) {
+	for e := s.order.Front(); e != nil; e = s.order.Front() {
+		p := e.Value.(*pendingState)
+		if !now.After(p.req.expiresAt) {
+			return
+		}
+		s.order.Remove(e)
+		delete(s.entries, p.state)
+	}
+}
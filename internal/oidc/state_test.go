@@ -0,0 +1,52 @@
+package oidc
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStateStore(t *testing.T) {
+	now := time.Now()
+	store := NewStateStore(time.Minute)
+	store.Max = 2
+	store.now = func() time.Time { return now }
+
+	first, _, err := store.Begin("corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(30 * time.Second)
+	second, _, err := store.Begin("corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Хранилище заполнено — новые входы не начинаются
+	if _, _, err := store.Begin("corp"); !errors.Is(err, ErrTooManyStates) {
+		t.Fatalf("Expected ErrTooManyStates, got %v", err)
+	}
+
+	// Завершённый вход освобождает место; state одноразовый
+	if req, ok := store.Take(second); !ok || req.Provider != "corp" {
+		t.Fatalf("Expected pending login, got %+v %v", req, ok)
+	}
+	if _, ok := store.Take(second); ok {
+		t.Fatal("Expected state to be single-use")
+	}
+	if _, _, err := store.Begin("corp"); err != nil {
+		t.Fatalf("Expected free slot after Take, got %v", err)
+	}
+
+	// Просроченные входы удаляются при следующем Begin
+	now = now.Add(45 * time.Second)
+	if _, _, err := store.Begin("corp"); err != nil {
+		t.Fatalf("Expected expired login to be evicted, got %v", err)
+	}
+	if _, ok := store.entries[first]; ok {
+		t.Error("Expected expired state to be removed")
+	}
+	if len(store.entries) != store.order.Len() || len(store.entries) != 2 {
+		t.Errorf("Expected 2 pending logins, got %d in map and %d in list", len(store.entries), store.order.Len())
+	}
+}
@@ -0,0 +1,105 @@
+// Package problem формирует ответы об ошибках в формате
+// application/problem+json (RFC 7807) и присваивает запросам ID.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// ContentType — тип содержимого ответов об ошибках
+const ContentType = "application/problem+json"
+
+// Типы проблем. Для ошибок без отдельного типа используется about:blank,
+// и title совпадает с текстом HTTP-статуса (RFC 7807, раздел 4.2).
+const (
+	TypeBlank      = "about:blank"
+	TypeValidation = "/problems/validation-error"
+)
+
+// -----------------------------
+// Problem
+// -----------------------------
+// Тело ответа об ошибке (RFC 7807). Errors перечисляет ошибки отдельных
+// полей запроса; поля называются так же, как в JSON.
+// swagger:model Problem
+type Problem struct {
+	// URI типа проблемы
+	// example: /problems/validation-error
+	Type string `json:"type"`
+	// Краткое описание типа проблемы
+	// example: Validation Failed
+	Title string `json:"title"`
+	// HTTP-статус
+	// example: 400
+	Status int `json:"status"`
+	// Описание конкретного случая
+	// example: request body contains invalid fields
+	Detail string `json:"detail,omitempty"`
+	// Путь запроса, в котором возникла ошибка
+	// example: /tasks/7
+	Instance string `json:"instance,omitempty"`
+	// ID запроса (заголовок X-Request-ID)
+	// example: 5f2b8c1e9a4d7e3f
+	RequestID string `json:"request_id,omitempty"`
+	// Ошибки отдельных полей
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError — ошибка одного поля запроса
+// swagger:model FieldError
+type FieldError struct {
+	// JSON-имя поля
+	// example: status
+	Field string `json:"field"`
+	// Нарушенное правило
+	// example: oneof
+	Code string `json:"code"`
+	// Описание ошибки для человека
+	// example: must be one of: pending in_progress done
+	Message string `json:"message"`
+}
+
+// New создаёт проблему типа about:blank со статусом status
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   TypeBlank,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Validation создаёт проблему 400 с ошибками полей errs
+func Validation(errs []FieldError) *Problem {
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return &Problem{
+		Type:   TypeValidation,
+		Title:  "Validation Failed",
+		Status: http.StatusBadRequest,
+		Detail: "request contains invalid fields",
+		Errors: errs,
+	}
+}
+
+// Write отправляет проблему клиенту, дополнив её путём и ID запроса r
+func (p *Problem) Write(w http.ResponseWriter, r *http.Request) {
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = RequestIDFromContext(r.Context())
+	}
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", ContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Write — сокращение для New(status, detail).Write(w, r)
+func Write(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	New(status, detail).Write(w, r)
+}
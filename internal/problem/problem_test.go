@@ -0,0 +1,75 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	var got Problem
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, http.StatusNotFound, "task not found")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks/7?fields=all", nil)
+	req.Header.Set(RequestIDHeader, "lb-1234")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("Unexpected response %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := Problem{Type: TypeBlank, Title: "Not Found", Status: 404, Detail: "task not found", Instance: "/tasks/7", RequestID: "lb-1234"}
+	if got.Type != want.Type || got.Title != want.Title || got.Status != want.Status ||
+		got.Detail != want.Detail || got.Instance != want.Instance || got.RequestID != want.RequestID {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if w.Header().Get(RequestIDHeader) != "lb-1234" {
+		t.Errorf("Expected request ID to be echoed, got %q", w.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	for _, incoming := range []string{"", "with space", strings.Repeat("a", maxRequestIDLength+1)} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, incoming)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if seen == "" || seen == incoming || w.Header().Get(RequestIDHeader) != seen {
+			t.Errorf("%q: expected a generated request ID, got %q", incoming, seen)
+		}
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	type request struct {
+		Title  string `json:"title" validate:"required"`
+		Status string `json:"status,omitempty" validate:"oneof=todo done"`
+	}
+	errs := FieldErrors(NewValidator().Struct(request{Status: "later"}))
+	p := Validation(errs)
+	if p.Status != http.StatusBadRequest || p.Type != TypeValidation || len(p.Errors) != 2 {
+		t.Fatalf("Unexpected problem: %+v", p)
+	}
+	// Ошибки отсортированы по JSON-имени поля
+	if e := p.Errors[0]; e.Field != "status" || e.Code != "oneof" || e.Message != "must be one of: todo done" {
+		t.Errorf("Unexpected status error: %+v", e)
+	}
+	if e := p.Errors[1]; e.Field != "title" || e.Code != "required" || e.Message != "is required" {
+		t.Errorf("Unexpected title error: %+v", e)
+	}
+	if FieldErrors(nil) != nil {
+		t.Error("Expected no field errors for nil")
+	}
+}
@@ -0,0 +1,55 @@
+package problem
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader — заголовок с ID запроса
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength — более длинные ID от клиента заменяются своими
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestID — middleware, присваивающий запросу ID. ID из заголовка X-Request-ID
+// (например, от балансировщика) сохраняется, если он короткий и печатный,
+// иначе генерируется новый. ID возвращается в заголовке ответа и попадает
+// в ответы об ошибках.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFromContext возвращает ID запроса или "", если middleware RequestID не применялся
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,64 @@
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// NewValidator создаёт валидатор, который называет поля по JSON-тегам,
+// чтобы ошибки полей совпадали с именами в теле запроса
+func NewValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+	return v
+}
+
+// FieldErrors переводит ошибки валидатора в ошибки полей.
+// Для ошибок другого типа возвращает nil.
+func FieldErrors(err error) []FieldError {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+	errs := make([]FieldError, 0, len(verrs))
+	for _, e := range verrs {
+		errs = append(errs, FieldError{
+			Field:   e.Field(),
+			Code:    e.Tag(),
+			Message: Message(e.Tag(), e.Param()),
+		})
+	}
+	return errs
+}
+
+// Message возвращает описание нарушенного правила code с параметром param
+func Message(code, param string) string {
+	switch code {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters long", param)
+	case "max":
+		return fmt.Sprintf("must be at most %s characters long", param)
+	case "oneof":
+		return "must be one of: " + param
+	case "email":
+		return "must be a valid email address"
+	case "readonly":
+		return "is read-only and cannot be changed"
+	}
+	return fmt.Sprintf("failed the %q rule", code)
+}
@@ -4,31 +4,33 @@ import (
     "database/sql"
     "log"
 
-    "golang.org/x/crypto/bcrypt"
+    "github.com/go-portfolio/rest-api/internal/auth"
 )
 
-// SeedUsers добавляет тестовых пользователей
-func SeedUsers(db *sql.DB) {
+// SeedUsers добавляет тестовых пользователей; пароли хэшируются hasher.
+// Пароли известны всем, поэтому вызывается только по флагу --seed для локальной разработки.
+func SeedUsers(db *sql.DB, hasher *auth.PasswordHasher) {
     users := []struct {
         Username string
         Password string
         Email    string
+        Role     string
     }{
-        {"alex", "password123", "alex@example.com"},
-        {"maria", "secret456", "maria@example.com"},
+        {"alex", "password123", "alex@example.com", "admin"},
+        {"maria", "secret456", "maria@example.com", "member"},
     }
 
     for _, u := range users {
-        hash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+        hash, err := hasher.Hash(u.Password)
         if err != nil {
             log.Fatalf("failed to hash password: %v", err)
         }
 
         _, err = db.Exec(`
-            INSERT INTO users (username, password_hash, email) 
-            VALUES ($1, $2, $3)
+            INSERT INTO users (username, password_hash, email, role, email_verified_at) 
+            VALUES ($1, $2, $3, $4, NOW())
             ON CONFLICT (username) DO NOTHING
-        `, u.Username, string(hash), u.Email)
+        `, u.Username, hash, u.Email, u.Role)
         if err != nil {
             log.Fatalf("failed to insert user %s: %v", u.Username, err)
         }
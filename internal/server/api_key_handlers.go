@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// APIKeysHandler godoc
+// @Summary      API-ключи пользователя
+// @Description  Создание, просмотр и отзыв долгоживущих API-ключей для автоматизации.
+// @Description  Ключ показывается только один раз — в ответе на создание.
+// @Description  Ключ передаётся в заголовке X-API-Key или как Authorization: Bearer rak_...
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Param        id       path  int                  false  "ID ключа"
+// @Param        request  body  CreateAPIKeyRequest  false  "Параметры нового ключа"
+// @Success      200  {array}   models.APIKey          "Список ключей"
+// @Success      201  {object}  CreateAPIKeyResponse   "Созданный ключ"
+// @Success      204  {string}  string                 "Ключ отозван"
+// @Failure      400  {string}  string                 "Некорректный запрос"
+// @Failure      401  {string}  string                 "Неавторизован"
+// @Failure      403  {string}  string                 "Ключами нельзя управлять с помощью API-ключа"
+// @Failure      404  {string}  string                 "Ключ не найден"
+// @Security     BearerAuth
+// @Router       /me/api-keys [get]
+// @Router       /me/api-keys [post]
+// @Router       /me/api-keys/{id} [delete]
+func APIKeysHandler(svc services.APIKeyService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		// Утечка одного ключа не должна позволять выпускать новые
+		if principal.Delegated() {
+			http.Error(w, "api keys cannot be managed with delegated credentials", http.StatusForbidden)
+			return
+		}
+
+		idStr := strings.Trim(strings.TrimPrefix(r.URL.Path, "/me/api-keys"), "/")
+
+		switch {
+		// -----------------------------
+		// GET /me/api-keys
+		// -----------------------------
+		case r.Method == http.MethodGet && idStr == "":
+			keys, err := svc.ListAPIKeys(principal.UserID)
+			if err != nil {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(keys)
+
+		// -----------------------------
+		// POST /me/api-keys
+		// -----------------------------
+		case r.Method == http.MethodPost && idStr == "":
+			var req CreateAPIKeyRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid JSON", http.StatusBadRequest)
+				return
+			}
+			if err := authValidate.Struct(req); err != nil {
+				errors := make(map[string]string)
+				for _, e := range err.(validator.ValidationErrors) {
+					errors[e.Field()] = e.Tag()
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(errors)
+				return
+			}
+
+			// Без явного списка ключ получает все разрешения роли владельца
+			scopes := req.Scopes
+			if len(scopes) == 0 {
+				scopes = auth.RoleScopes(principal.Role)
+			}
+			for _, scope := range scopes {
+				if !principal.Can(auth.Permission(scope)) {
+					http.Error(w, fmt.Sprintf("scope %q is not allowed", scope), http.StatusBadRequest)
+					return
+				}
+			}
+			if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+				http.Error(w, "expires_at must be in the future", http.StatusBadRequest)
+				return
+			}
+
+			key, apiKey, err := svc.CreateAPIKey(principal.UserID, req.Name, scopes, req.ExpiresAt)
+			if err != nil {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(CreateAPIKeyResponse{Key: key, APIKey: *apiKey})
+
+		// -----------------------------
+		// DELETE /me/api-keys/{id}
+		// -----------------------------
+		case r.Method == http.MethodDelete && idStr != "":
+			id, err := strconv.Atoi(idStr)
+			if err != nil || id <= 0 {
+				http.Error(w, "invalid api key ID", http.StatusBadRequest)
+				return
+			}
+			if err := svc.RevokeAPIKey(principal.UserID, id); err != nil {
+				if errors.Is(err, services.ErrAPIKeyNotFound) {
+					http.Error(w, "api key not found", http.StatusNotFound)
+					return
+				}
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// apiKeyPrincipal подключает APIKeyService к middleware auth.VerifyToken
+func apiKeyPrincipal(svc services.APIKeyService) auth.APIKeyFunc {
+	return func(key string) (*auth.Principal, error) {
+		k, role, err := svc.AuthenticateAPIKey(key)
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Пустой список scope снял бы ограничения ключа — такой ключ не принимаем
+		if len(k.Scopes) == 0 {
+			return nil, nil
+		}
+		return &auth.Principal{UserID: k.UserID, Role: role, Scopes: k.Scopes, APIKeyID: k.ID}, nil
+	}
+}
+
+// CreateAPIKeyRequest модель запроса создания API-ключа
+// swagger:model CreateAPIKeyRequest
+type CreateAPIKeyRequest struct {
+	// Название ключа
+	// example: ci-deploy
+	Name string `json:"name" validate:"required,max=100"`
+	// Разрешения ключа; по умолчанию — все разрешения роли
+	// example: ["tasks:read"]
+	Scopes []string `json:"scopes"`
+	// Срок действия в формате RFC3339; по умолчанию ключ бессрочный
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse модель ответа с новым API-ключом
+// swagger:model CreateAPIKeyResponse
+type CreateAPIKeyResponse struct {
+	// Сам ключ — показывается только один раз
+	// example: rak_1a2b3c4d_Qm9zb24...
+	Key string `json:"key"`
+	// Описание ключа
+	APIKey models.APIKey `json:"api_key"`
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// TestAPIKeysHandler проверяет полный цикл API-ключа: создание, использование, отзыв
+func TestAPIKeysHandler(t *testing.T) {
+	tm := testTokenManager(t)
+	keySvc := &services.MockAPIKeyService{Roles: map[int]string{1: auth.RoleMember}}
+	member := &auth.Principal{UserID: 1, Role: auth.RoleMember}
+
+	requireAuth := auth.VerifyToken(tm, auth.WithAPIKeys(services.APIKeyPrefix, apiKeyPrincipal(keySvc)))
+	tasks := requireAuth(auth.RequireAccess(auth.PermTasksRead, auth.PermTasksWrite)(TasksHandler(&services.MockTaskService{}, false)))
+
+	// create создаёт ключ от имени пользователя p
+	create := func(p *auth.Principal, body CreateAPIKeyRequest) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := withPrincipal(httptest.NewRequest(http.MethodPost, "/me/api-keys", bytes.NewReader(data)), p)
+		w := httptest.NewRecorder()
+		APIKeysHandler(keySvc)(w, req)
+		return w
+	}
+
+	// -----------------------------
+	// Создание ключа только на чтение
+	// -----------------------------
+	w := create(member, CreateAPIKeyRequest{Name: "ci", Scopes: []string{"tasks:read"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created CreateAPIKeyResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if len(created.Key) < 40 || created.APIKey.Prefix == "" || created.Key[:len(created.APIKey.Prefix)] != created.APIKey.Prefix {
+		t.Fatalf("Unexpected key: %+v", created)
+	}
+
+	// -----------------------------
+	// Список ключей не содержит секрета
+	// -----------------------------
+	req := withPrincipal(httptest.NewRequest(http.MethodGet, "/me/api-keys", nil), member)
+	w = httptest.NewRecorder()
+	APIKeysHandler(keySvc)(w, req)
+	if bytes.Contains(w.Body.Bytes(), []byte(created.Key)) {
+		t.Errorf("Secret must not be listed")
+	}
+	var listed []models.APIKey
+	json.NewDecoder(w.Body).Decode(&listed)
+	if len(listed) != 1 || listed[0].Name != "ci" {
+		t.Errorf("Unexpected list: %+v", listed)
+	}
+
+	// -----------------------------
+	// Использование ключа и ограничение scope
+	// -----------------------------
+	call := func(method, header, value string) int {
+		req := httptest.NewRequest(method, "/tasks", bytes.NewReader([]byte(`{"title":"x","status":"todo"}`)))
+		req.Header.Set(header, value)
+		w := httptest.NewRecorder()
+		tasks.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := call(http.MethodGet, "X-API-Key", created.Key); code != http.StatusOK {
+		t.Errorf("X-API-Key GET: expected 200, got %d", code)
+	}
+	if code := call(http.MethodGet, "Authorization", "Bearer "+created.Key); code != http.StatusOK {
+		t.Errorf("Bearer GET: expected 200, got %d", code)
+	}
+	if code := call(http.MethodPost, "X-API-Key", created.Key); code != http.StatusForbidden {
+		t.Errorf("read-only key POST: expected 403, got %d", code)
+	}
+	if keySvc.Keys[0].LastUsedAt == nil {
+		t.Errorf("last_used_at must be updated")
+	}
+
+	// Ключом нельзя выпустить новый ключ
+	if w := create(&auth.Principal{UserID: 1, Role: auth.RoleMember, APIKeyID: 1}, CreateAPIKeyRequest{Name: "x"}); w.Code != http.StatusForbidden {
+		t.Errorf("key via key: expected 403, got %d", w.Code)
+	}
+	// Нельзя запросить разрешения сверх роли
+	viewer := &auth.Principal{UserID: 2, Role: auth.RoleViewer}
+	if w := create(viewer, CreateAPIKeyRequest{Name: "x", Scopes: []string{"tasks:write"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("scope escalation: expected 400, got %d", w.Code)
+	}
+
+	// -----------------------------
+	// Отзыв ключа
+	// -----------------------------
+	path := "/me/api-keys/" + strconv.Itoa(created.APIKey.ID)
+	// Чужой ключ отозвать нельзя
+	req = withPrincipal(httptest.NewRequest(http.MethodDelete, path, nil), viewer)
+	w = httptest.NewRecorder()
+	APIKeysHandler(keySvc)(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("foreign revoke: expected 404, got %d", w.Code)
+	}
+
+	req = withPrincipal(httptest.NewRequest(http.MethodDelete, path, nil), member)
+	w = httptest.NewRecorder()
+	APIKeysHandler(keySvc)(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("revoke: expected 204, got %d", w.Code)
+	}
+	if code := call(http.MethodGet, "X-API-Key", created.Key); code != http.StatusUnauthorized {
+		t.Errorf("revoked key: expected 401, got %d", code)
+	}
+}
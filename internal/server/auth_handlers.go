@@ -2,48 +2,286 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/mail"
 	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/problem"
 	"github.com/go-portfolio/rest-api/internal/services"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+var authValidate = validator.New()
+
+// loginValidate называет поля по JSON-тегам для ошибок problem+json
+var loginValidate = problem.NewValidator()
+
+var (
+	loginAttempts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "login_attempts_total",
+			Help: "Login attempts by result (success, failure, blocked)",
+		},
+		[]string{"result"},
+	)
+	loginLockouts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "login_lockouts_total",
+			Help: "Temporary login lockouts by scope (user, ip)",
+		},
+		[]string{"scope"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(loginAttempts, loginLockouts)
+}
+
 // LoginHandler godoc
 // @Summary      Авторизация пользователя
-// @Description  Аутентификация пользователя и получение JWT токена
+// @Description  Аутентификация пользователя и получение JWT токена и refresh-токена
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        credentials  body  LoginRequest  true  "Данные для входа"
-// @Success      200  {object}  LoginResponse  "JWT токен и данные пользователя"
-// @Failure      400  {object}  map[string]string  "Некорректный JSON"
-// @Failure      401  {object}  map[string]string  "Неверные учетные данные"
+// @Success      200  {object}  LoginResponse  "JWT токен, refresh-токен и данные пользователя"
+// @Success      202  {object}  TwoFactorChallengeResponse  "Включена 2FA: нужен второй шаг POST /login/2fa"
+// @Failure      400  {object}  problem.Problem  "Некорректный JSON или не заполнены username/password"
+// @Failure      401  {object}  problem.Problem  "Неверные учетные данные"
+// @Failure      403  {object}  problem.Problem  "Email не подтверждён (если вход требует подтверждения)"
+// @Failure      429  {object}  problem.Problem  "Слишком много неудачных попыток, см. заголовок Retry-After"
 // @Router       /login [post]
-func LoginHandler(userSvc services.UserService, jwtSecret string) http.HandlerFunc {
+func LoginHandler(userSvc services.UserService, tokenSvc services.TokenService, tm *auth.TokenManager,
+	opts LoginOptions) http.HandlerFunc {
+	limiter := opts.Limiter
 	return func(w http.ResponseWriter, r *http.Request) {
 		var creds LoginRequest
 
 		if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			writeDecodeError(w, r, err, "invalid JSON")
+			return
+		}
+		if err := loginValidate.Struct(creds); err != nil {
+			problem.Validation(problem.FieldErrors(err)).Write(w, r)
+			return
+		}
+
+		// Пока действует задержка или блокировка, пароль даже не проверяем
+		ip := limiter.ClientIP(r)
+		if wait := limiter.Check(creds.Username, ip); wait > 0 {
+			loginAttempts.WithLabelValues("blocked").Inc()
+			tooManyAttempts(w, r, wait)
 			return
 		}
 
 		user, err := userSvc.Authenticate(creds.Username, creds.Password)
 		if err != nil {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			loginAttempts.WithLabelValues("failure").Inc()
+			_, locked := limiter.Failure(creds.Username, ip)
+			for _, scope := range locked {
+				loginLockouts.WithLabelValues(string(scope)).Inc()
+			}
+			problem.Write(w, r, http.StatusUnauthorized, "invalid username or password")
+			return
+		}
+		loginAttempts.WithLabelValues("success").Inc()
+		limiter.Success(creds.Username)
+
+		if opts.RequireVerifiedEmail && user.EmailVerifiedAt == nil {
+			problem.Write(w, r, http.StatusForbidden, "email not verified")
+			return
+		}
+
+		// При включённой 2FA вместо токенов выдаём challenge для второго шага
+		if requireSecondFactor(w, r, tm, opts.TwoFactor, user.ID) {
+			return
+		}
+
+		tokens, err := issueTokens(tokenSvc, tm, opts.Sessions, r, user)
+		if err != nil {
+			problem.Write(w, r, http.StatusInternalServerError, "internal error")
 			return
 		}
 
-		token, _ := auth.GenerateToken(user.ID, jwtSecret)
+		// Authenticate возвращает пользователя с хэшем пароля — клиенту он не нужен
+		user.Password = ""
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(LoginResponse{
-			Token: token,
-			User:  *user,
+			Token:        tokens.Token,
+			RefreshToken: tokens.RefreshToken,
+			User:         *user,
 		})
 	}
 }
 
+// LoginOptions — дополнительные проверки при входе; нулевое значение их отключает
+type LoginOptions struct {
+	Limiter              *auth.LoginLimiter        // защита от подбора пароля
+	RequireVerifiedEmail bool                      // не пускать с неподтверждённым email
+	TwoFactor            services.TwoFactorService // второй шаг входа для пользователей с 2FA
+	Sessions             *SessionRecorder          // сохранение сессии входа
+}
+
+// tooManyAttempts отвечает 429 с Retry-After в целых секундах (с округлением вверх)
+func tooManyAttempts(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	problem.Write(w, r, http.StatusTooManyRequests, "too many login attempts")
+}
+
+// requireSecondFactor отвечает 202 с challenge-токеном, если у пользователя
+// включена 2FA, и сообщает, что ответ уже записан. svc = nil — 2FA не проверяется.
+func requireSecondFactor(w http.ResponseWriter, r *http.Request, tm *auth.TokenManager,
+	svc services.TwoFactorService, userID int) bool {
+	if svc == nil {
+		return false
+	}
+	enabled, err := svc.Enabled(userID)
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "internal error")
+		return true
+	}
+	if !enabled {
+		return false
+	}
+	challenge, err := tm.GenerateChallengeToken(userID)
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, "internal error")
+		return true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    challenge,
+	})
+	return true
+}
+
+// RegisterHandler godoc
+// @Summary      Регистрация пользователя
+// @Description  Создание нового пользователя и получение JWT токена. На email отправляется ссылка подтверждения.
+// @Description  Если вход требует подтверждённого email (account.require_verified_email: login),
+// @Description  токены не выдаются: ответ содержит только пользователя и email_verification_required.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        user  body  RegisterRequest  true  "Данные нового пользователя"
+// @Success      201  {object}  LoginResponse  "JWT токен и данные пользователя"
+// @Success      201  {object}  RegistrationPendingResponse  "Нужно подтвердить email, токены не выданы"
+// @Failure      400  {object}  map[string]string  "Некорректный JSON или ошибки валидации"
+// @Failure      409  {string}  string  "Логин или email уже заняты"
+// @Router       /register [post]
+func RegisterHandler(userSvc services.UserService, tokenSvc services.TokenService, tm *auth.TokenManager,
+	verifySvc services.EmailVerificationService, mailer mail.Mailer, publicURL string, sessions *SessionRecorder,
+	hasher *auth.PasswordHasher, policy *auth.PasswordPolicy, requireVerified bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req RegisterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		errs := fieldErrors(authValidate.Struct(req))
+		checkPasswordPolicy(errs, policy, "Password", req.Password, req.Username)
+		if len(errs) > 0 {
+			writeFieldErrors(w, errs)
+			return
+		}
+
+		hash, err := hasher.Hash(req.Password)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		user, err := userSvc.CreateUser(req.Username, req.Email, hash)
+		switch {
+		case errors.Is(err, services.ErrUsernameTaken):
+			http.Error(w, "username already taken", http.StatusConflict)
+			return
+		case errors.Is(err, services.ErrEmailTaken):
+			http.Error(w, "email already taken", http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		// Ошибка отправки письма не отменяет регистрацию: письмо можно запросить
+		// повторно через POST /verify-email/resend
+		if verifySvc != nil {
+			if err := sendVerificationEmail(verifySvc, mailer, publicURL, user); err != nil {
+				log.Printf("email verification: %v", err)
+			}
+		}
+
+		// Хэш пароля клиенту не отдаём
+		user.Password = ""
+
+		// Вход без подтверждённого email запрещён — токены выдаст POST /login
+		// после перехода по ссылке из письма
+		if requireVerified {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(RegistrationPendingResponse{
+				EmailVerificationRequired: true,
+				User:                      *user,
+			})
+			return
+		}
+
+		tokens, err := issueTokens(tokenSvc, tm, sessions, r, user)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(LoginResponse{
+			Token:        tokens.Token,
+			RefreshToken: tokens.RefreshToken,
+			User:         *user,
+		})
+	}
+}
+
+// RegisterRequest модель запроса регистрации для Swagger.
+// Правила валидации совпадают с models.User.
+// swagger:model RegisterRequest
+type RegisterRequest struct {
+	// Логин пользователя
+	// example: user123
+	Username string `json:"username" validate:"required,min=3,max=20"`
+	// Электронная почта пользователя
+	// example: user@example.com
+	Email string `json:"email" validate:"required,email"`
+	// Пароль пользователя; требования задаются политикой паролей (password.policy)
+	// example: pass1234
+	Password string `json:"password" validate:"required"`
+}
+
+// RegistrationPendingResponse — ответ POST /register, если вход требует подтверждённого email
+// swagger:model RegistrationPendingResponse
+type RegistrationPendingResponse struct {
+	EmailVerificationRequired bool `json:"email_verification_required"`
+	// Данные пользователя
+	User models.User `json:"user"`
+}
+
 // LoginRequest модель запроса для Swagger
 // swagger:model LoginRequest
 type LoginRequest struct {
@@ -61,6 +299,9 @@ type LoginResponse struct {
 	// JWT токен
 	// example: eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
 	Token string `json:"token"`
+	// Refresh-токен для POST /token/refresh
+	// example: 3q2-7wEAAAA...
+	RefreshToken string `json:"refresh_token,omitempty"`
 	// Данные пользователя
 	User models.User `json:"user"`
 }
@@ -0,0 +1,281 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/config"
+	"github.com/go-portfolio/rest-api/internal/mail"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/problem"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// TestRegisterHandler тестирует обработчик /register с мок-сервисом пользователей
+func TestRegisterHandler(t *testing.T) {
+	userSvc := &services.MockUserService{
+		Users: []models.User{
+			{ID: 1, Username: "alex", Email: "alex@example.com", Password: "password123"},
+		},
+	}
+	mailer := &mail.MemoryMailer{}
+	verifySvc := &services.MockEmailVerificationService{Users: userSvc}
+	handler := RegisterHandler(userSvc, &services.MockTokenService{}, testTokenManager(t), verifySvc, mailer, "http://localhost:8080", nil, nil, nil, false)
+
+	// register отправляет POST /register с переданным телом
+	register := func(body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewReader(data))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	// -----------------------------
+	// Успешная регистрация
+	// -----------------------------
+	t.Run("success", func(t *testing.T) {
+		w := register(RegisterRequest{Username: "newbie", Email: "newbie@example.com", Password: "secret1"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var resp LoginResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Token == "" || resp.RefreshToken == "" || resp.User.Username != "newbie" || resp.User.ID == 0 {
+			t.Errorf("Unexpected response: %+v", resp)
+		}
+		// Хэш пароля не должен утекать в ответ
+		if resp.User.Password != "" {
+			t.Errorf("Password hash leaked in response")
+		}
+		// Пароль сохраняется только в виде хэша
+		stored := userSvc.Users[len(userSvc.Users)-1]
+		if stored.Password == "secret1" {
+			t.Errorf("Password stored in plain text")
+		}
+		// На email отправлена ссылка подтверждения
+		if msg, ok := mailer.Last("newbie@example.com"); !ok || !strings.Contains(msg.Body, "/verify-email?token=") {
+			t.Errorf("Expected verification email, got %+v", msg)
+		}
+		if resp.User.EmailVerifiedAt != nil {
+			t.Errorf("New user must not be verified")
+		}
+	})
+
+	// -----------------------------
+	// Ошибки валидации
+	// -----------------------------
+	t.Run("validation", func(t *testing.T) {
+		w := register(RegisterRequest{Username: "ab", Email: "not-an-email", Password: "123"})
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", w.Code)
+		}
+
+		var errs map[string]string
+		if err := json.NewDecoder(w.Body).Decode(&errs); err != nil {
+			t.Fatal(err)
+		}
+		if errs["Username"] != "min" || errs["Email"] != "email" || errs["Password"] != "min" {
+			t.Errorf("Unexpected validation errors: %v", errs)
+		}
+	})
+
+	// -----------------------------
+	// Занятые логин и email
+	// -----------------------------
+	t.Run("conflicts", func(t *testing.T) {
+		if w := register(RegisterRequest{Username: "alex", Email: "other@example.com", Password: "secret1"}); w.Code != http.StatusConflict {
+			t.Errorf("username: expected status 409, got %d", w.Code)
+		}
+		if w := register(RegisterRequest{Username: "other", Email: "alex@example.com", Password: "secret1"}); w.Code != http.StatusConflict {
+			t.Errorf("email: expected status 409, got %d", w.Code)
+		}
+	})
+}
+
+// TestRegisterHandler_RequireVerifiedEmail проверяет, что в режиме login регистрация не выдаёт токены
+func TestRegisterHandler_RequireVerifiedEmail(t *testing.T) {
+	userSvc := &services.MockUserService{}
+	tokenSvc := &services.MockTokenService{}
+	mailer := &mail.MemoryMailer{}
+	verifySvc := &services.MockEmailVerificationService{Users: userSvc}
+	handler := RegisterHandler(userSvc, tokenSvc, testTokenManager(t), verifySvc, mailer, "http://localhost:8080", nil, nil, nil, true)
+
+	w := postJSON(handler, "/register", RegisterRequest{Username: "newbie", Email: "newbie@example.com", Password: "secret1"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]json.RawMessage
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp["token"]; ok {
+		t.Errorf("Expected no access token, got %s", resp["token"])
+	}
+	if string(resp["email_verification_required"]) != "true" || resp["user"] == nil {
+		t.Errorf("Unexpected response: %v", resp)
+	}
+	if len(tokenSvc.RefreshTokens) != 0 {
+		t.Errorf("Expected no refresh tokens, got %d", len(tokenSvc.RefreshTokens))
+	}
+	if _, ok := mailer.Last("newbie@example.com"); !ok {
+		t.Error("Expected verification email")
+	}
+}
+
+// TestRegisterHandler_PasswordPolicy проверяет ошибки политики паролей в формате ошибок валидации
+func TestRegisterHandler_PasswordPolicy(t *testing.T) {
+	// Список утёкших паролей: SHA-1("Password1!") в формате HIBP
+	list := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(list, []byte("# test list\n32CA9FC1A0F5B6330E3F4C8C1BBECDE9BEDB9573:1\n"+
+		"EF7A2D9C3C8E12BD6F3C2D6E4C2F0E5C7E2B6E9B:3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := auth.NewPasswordPolicy(config.PasswordPolicyConfig{
+		MinLength:        8,
+		RequireUpper:     true,
+		RequireDigit:     true,
+		DisallowUsername: true,
+		BreachedList:     list,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	userSvc := &services.MockUserService{}
+	handler := RegisterHandler(userSvc, &services.MockTokenService{}, testTokenManager(t), nil, nil, "", nil, nil, policy, false)
+
+	for _, tc := range []struct {
+		password string
+		rule     string
+	}{
+		{"Ab1", "min"},
+		{"password1", "require_upper"},
+		{"Password", "require_digit"},
+		{"Newbie2025", "contains_username"},
+		{"Password1!", "breached"},
+	} {
+		w := postJSON(handler, "/register", RegisterRequest{Username: "newbie", Email: "newbie@example.com", Password: tc.password})
+		var errs map[string]string
+		json.NewDecoder(w.Body).Decode(&errs)
+		if w.Code != http.StatusBadRequest || errs["Password"] != tc.rule {
+			t.Errorf("%q: expected %s violation, got %d %v", tc.password, tc.rule, w.Code, errs)
+		}
+	}
+
+	// Ошибки политики возвращаются вместе с остальными ошибками валидации
+	w := postJSON(handler, "/register", RegisterRequest{Username: "ab", Email: "newbie@example.com", Password: "short"})
+	var errs map[string]string
+	json.NewDecoder(w.Body).Decode(&errs)
+	if errs["Username"] != "min" || errs["Password"] != "min" {
+		t.Errorf("Unexpected validation errors: %v", errs)
+	}
+
+	if w := postJSON(handler, "/register", RegisterRequest{Username: "newbie", Email: "newbie@example.com", Password: "Correct7Horse"}); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestLoginHandler_Lockout проверяет задержку после неудачного входа и ответ 429
+// TestLoginHandler проверяет успешный вход: токены выдаются, хэш пароля не возвращается
+func TestLoginHandler(t *testing.T) {
+	userSvc := testUsers()
+	handler := LoginHandler(userSvc, &services.MockTokenService{}, testTokenManager(t), LoginOptions{})
+
+	data, _ := json.Marshal(LoginRequest{Username: "alex", Password: "password123"})
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(data))
+	w := httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "password_hash") {
+		t.Errorf("Expected no password hash in response, got %s", w.Body.String())
+	}
+
+	var resp LoginResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" || resp.User.Username != "alex" {
+		t.Errorf("Unexpected login response: %+v", resp)
+	}
+	if resp.User.Password != "" {
+		t.Errorf("Expected empty password, got %q", resp.User.Password)
+	}
+}
+
+func TestLoginHandler_Lockout(t *testing.T) {
+	limiter := auth.NewLoginLimiter(config.LoginLimitConfig{
+		MaxFailuresPerUser: 2,
+		BaseDelay:          time.Minute,
+		Lockout:            time.Hour,
+	})
+	handler := LoginHandler(testUsers(), &services.MockTokenService{}, testTokenManager(t), LoginOptions{Limiter: limiter})
+
+	login := func(username, password string) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(LoginRequest{Username: username, Password: password})
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(data))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	if w := login("alex", "wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, got %d", w.Code)
+	}
+	// Сразу после неудачи действует задержка — даже верный пароль не проверяется
+	w := login("alex", "password123")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", w.Code)
+	}
+	if ra := w.Header().Get("Retry-After"); ra != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", ra)
+	}
+	// Другой пользователь с того же IP не затронут
+	if w := login("boss", "admin123"); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for another user, got %d", w.Code)
+	}
+}
+
+// TestLoginHandler_Problems проверяет ответы об ошибках входа в формате problem+json
+func TestLoginHandler_Problems(t *testing.T) {
+	handler := problem.RequestID(LoginHandler(testUsers(), &services.MockTokenService{}, testTokenManager(t), LoginOptions{}))
+	login := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Пустые поля — ошибки с JSON-именами полей
+	w := login(`{"username":""}`)
+	p := decodeProblem(t, w)
+	if w.Code != http.StatusBadRequest || p.Type != problem.TypeValidation || len(p.Errors) != 2 ||
+		p.Errors[0].Field != "password" || p.Errors[1].Field != "username" || p.Errors[0].Message == "" {
+		t.Fatalf("Unexpected validation problem: %d %+v", w.Code, p)
+	}
+	if p.Instance != "/login" || p.RequestID == "" || p.RequestID != w.Header().Get(problem.RequestIDHeader) {
+		t.Errorf("Expected instance and request ID, got %+v", p)
+	}
+
+	w = login(`{"username":5,"password":"x"}`)
+	if p := decodeProblem(t, w); w.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "username" {
+		t.Errorf("Expected type error for username, got %d %+v", w.Code, p)
+	}
+
+	w = login(`{"username":"alex","password":"wrong"}`)
+	if p := decodeProblem(t, w); w.Code != http.StatusUnauthorized || p.Title != "Unauthorized" || p.Detail == "" {
+		t.Errorf("Unexpected 401 problem: %d %+v", w.Code, p)
+	}
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/mail"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/problem"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// VerifyEmailHandler godoc
+// @Summary      Подтверждение email
+// @Description  Подтверждает email по токену из письма, отправленного при регистрации
+// @Tags         auth
+// @Produce      json
+// @Param        token  query  string  true  "Токен из письма"
+// @Success      200  {object}  map[string]string  "Email подтверждён"
+// @Failure      400  {string}  string  "Недействительный или истёкший токен"
+// @Router       /verify-email [get]
+func VerifyEmailHandler(verifySvc services.EmailVerificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			http.Error(w, "token is required", http.StatusBadRequest)
+			return
+		}
+		if _, err := verifySvc.VerifyEmail(token); err != nil {
+			if errors.Is(err, services.ErrInvalidVerifyToken) {
+				http.Error(w, "invalid or expired token", http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "verified"})
+	}
+}
+
+// ResendVerificationHandler godoc
+// @Summary      Повторная отправка письма подтверждения
+// @Description  Отправляет новое письмо со ссылкой подтверждения, если email зарегистрирован и ещё не подтверждён.
+// @Description  Токен не нужен: при account.require_verified_email: login без подтверждения нельзя войти.
+// @Description  Ответ не зависит от того, зарегистрирован ли email. Запросы ограничиваются по email и по IP.
+// @Tags         auth
+// @Accept       json
+// @Param        request  body  ResendVerificationRequest  true  "Email учётной записи"
+// @Success      202  {string}  string  "Если email ждёт подтверждения, письмо отправлено"
+// @Failure      400  {object}  map[string]string  "Некорректный JSON или ошибки валидации"
+// @Failure      429  {object}  problem.Problem  "Слишком много запросов, см. Retry-After"
+// @Router       /verify-email/resend [post]
+func ResendVerificationHandler(userSvc services.UserService, verifySvc services.EmailVerificationService,
+	mailer mail.Mailer, publicURL string, limiter *auth.LoginLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req ResendVerificationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if err := authValidate.Struct(req); err != nil {
+			writeValidationErrors(w, err)
+			return
+		}
+
+		// Ограничение и фоновая отправка — как у POST /password/forgot
+		key := mailLimitKey("verify-email", req.Email)
+		ip := limiter.ClientIP(r)
+		if wait := limiter.Check(key, ip); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			problem.Write(w, r, http.StatusTooManyRequests, "too many verification requests")
+			return
+		}
+		limiter.Failure(key, ip)
+
+		email := req.Email
+		sendInBackground(func() {
+			if err := resendVerification(userSvc, verifySvc, mailer, publicURL, email); err != nil {
+				log.Printf("email verification: %v", err)
+			}
+		})
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// resendVerification отправляет письмо подтверждения, если email зарегистрирован и не подтверждён
+func resendVerification(userSvc services.UserService, verifySvc services.EmailVerificationService,
+	mailer mail.Mailer, publicURL, email string) error {
+	user, err := userSvc.FindUserByEmail(email)
+	if errors.Is(err, services.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("find user: %w", err)
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	return sendVerificationEmail(verifySvc, mailer, publicURL, &user)
+}
+
+// ResendVerificationRequest — запрос повторной отправки письма подтверждения
+// swagger:model ResendVerificationRequest
+type ResendVerificationRequest struct {
+	// Email учётной записи
+	// example: user@example.com
+	Email string `json:"email" validate:"required,email"`
+}
+
+// RequireVerifiedEmail запрещает создание задач (POST) пользователям с неподтверждённым email.
+// Состояние берётся из базы, а не из токена, чтобы подтверждение действовало сразу.
+func RequireVerifiedEmail(userSvc services.UserService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+			principal, ok := auth.PrincipalFromContext(r.Context())
+			if !ok {
+				problem.Write(w, r, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			user, err := userSvc.GetUserByID(principal.UserID)
+			if err != nil {
+				problem.Write(w, r, http.StatusInternalServerError, "internal error")
+				return
+			}
+			if user.EmailVerifiedAt == nil {
+				problem.Write(w, r, http.StatusForbidden, "email not verified")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// sendVerificationEmail выдаёт токен подтверждения и отправляет письмо со ссылкой
+func sendVerificationEmail(verifySvc services.EmailVerificationService, mailer mail.Mailer,
+	publicURL string, user *models.User) error {
+	token, err := verifySvc.CreateVerificationToken(user.ID, user.Email)
+	if err != nil {
+		return fmt.Errorf("create token: %w", err)
+	}
+	link := strings.TrimRight(publicURL, "/") + "/verify-email?token=" + url.QueryEscape(token)
+	err = mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hello, %s!\n\n"+
+			"Please confirm your email address by opening the link below:\n\n"+
+			"%s\n\n"+
+			"If you did not create an account, ignore this email.\n",
+			user.Username, link),
+	})
+	if err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/config"
+	"github.com/go-portfolio/rest-api/internal/mail"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// verifyLink извлекает токен из ссылки подтверждения в письме
+func verifyLink(t *testing.T, msg mail.Message) string {
+	t.Helper()
+	for _, line := range strings.Split(msg.Body, "\n") {
+		if i := strings.Index(line, "/verify-email?"); i >= 0 {
+			q, err := url.ParseQuery(line[i+len("/verify-email?"):])
+			if err == nil && q.Get("token") != "" {
+				return q.Get("token")
+			}
+		}
+	}
+	t.Fatalf("No verification link in mail: %s", msg.Body)
+	return ""
+}
+
+// TestEmailVerification проверяет повторную отправку письма и подтверждение по ссылке
+func TestEmailVerification(t *testing.T) {
+	syncSend(t)
+	userSvc := &services.MockUserService{
+		Users: []models.User{
+			{ID: 1, Username: "alex", Email: "alex@example.com", Password: "password123"},
+		},
+	}
+	verifySvc := &services.MockEmailVerificationService{Users: userSvc}
+	mailer := &mail.MemoryMailer{}
+	resend := ResendVerificationHandler(userSvc, verifySvc, mailer, "http://localhost:8080", nil)
+	verify := VerifyEmailHandler(verifySvc)
+
+	// Повторная отправка не требует токена: без подтверждения войти нельзя
+	w := postJSON(resend, "/verify-email/resend", ResendVerificationRequest{Email: "alex@example.com"})
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", w.Code)
+	}
+	msg, ok := mailer.Last("alex@example.com")
+	if !ok {
+		t.Fatal("Expected verification email")
+	}
+	token := verifyLink(t, msg)
+
+	// -----------------------------
+	// Недействительный токен
+	// -----------------------------
+	w = httptest.NewRecorder()
+	verify(w, httptest.NewRequest(http.MethodGet, "/verify-email?token=bogus", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for bogus token, got %d", w.Code)
+	}
+
+	// -----------------------------
+	// Подтверждение и повторное использование ссылки
+	// -----------------------------
+	w = httptest.NewRecorder()
+	verify(w, httptest.NewRequest(http.MethodGet, "/verify-email?token="+url.QueryEscape(token), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if userSvc.Users[0].EmailVerifiedAt == nil {
+		t.Error("Expected email to be marked verified")
+	}
+
+	w = httptest.NewRecorder()
+	verify(w, httptest.NewRequest(http.MethodGet, "/verify-email?token="+url.QueryEscape(token), nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for reused token, got %d", w.Code)
+	}
+
+	// Подтверждённому и незарегистрированному адресу письмо не отправляется,
+	// но ответ тот же, чтобы по нему нельзя было перебирать адреса
+	sent := len(mailer.Messages)
+	for _, email := range []string{"alex@example.com", "nobody@example.com"} {
+		if w := postJSON(resend, "/verify-email/resend", ResendVerificationRequest{Email: email}); w.Code != http.StatusAccepted {
+			t.Errorf("%s: expected status 202, got %d", email, w.Code)
+		}
+	}
+	if len(mailer.Messages) != sent {
+		t.Errorf("Expected no new emails, got %d", len(mailer.Messages)-sent)
+	}
+}
+
+// TestResendVerification_RateLimit проверяет ограничение повторной отправки по email
+func TestResendVerification_RateLimit(t *testing.T) {
+	syncSend(t)
+	userSvc := &services.MockUserService{
+		Users: []models.User{{ID: 1, Username: "alex", Email: "alex@example.com", Password: "password123"}},
+	}
+	mailer := &mail.MemoryMailer{}
+	limiter := auth.NewLoginLimiter(config.LoginLimitConfig{
+		MaxFailuresPerUser: 3,
+		MaxFailuresPerIP:   3,
+		BaseDelay:          time.Minute,
+		Lockout:            time.Hour,
+	})
+	resend := ResendVerificationHandler(userSvc, &services.MockEmailVerificationService{Users: userSvc}, mailer, "", limiter)
+
+	if w := postJSON(resend, "/verify-email/resend", ResendVerificationRequest{Email: "alex@example.com"}); w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", w.Code)
+	}
+	w := postJSON(resend, "/verify-email/resend", ResendVerificationRequest{Email: "Alex@example.com"})
+	if p := decodeProblem(t, w); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("Expected 429 with Retry-After 60, got %d %q %+v", w.Code, w.Header().Get("Retry-After"), p)
+	}
+	if len(mailer.Messages) != 1 {
+		t.Errorf("Expected a single verification email, got %d", len(mailer.Messages))
+	}
+}
+
+// TestRequireVerifiedEmail проверяет запрет входа, refresh и создания задач без подтверждённого email
+func TestRequireVerifiedEmail(t *testing.T) {
+	verifiedAt := time.Now()
+	userSvc := &services.MockUserService{
+		Users: []models.User{
+			{ID: 1, Username: "alex", Email: "alex@example.com", Password: "password123"},
+			{ID: 2, Username: "maria", Email: "maria@example.com", Password: "secret456", EmailVerifiedAt: &verifiedAt},
+		},
+	}
+
+	t.Run("login", func(t *testing.T) {
+		login := LoginHandler(userSvc, &services.MockTokenService{}, testTokenManager(t), LoginOptions{RequireVerifiedEmail: true})
+		for _, c := range []struct {
+			username, password string
+			want               int
+		}{
+			{"alex", "password123", http.StatusForbidden},
+			{"maria", "secret456", http.StatusOK},
+		} {
+			data, _ := json.Marshal(LoginRequest{Username: c.username, Password: c.password})
+			w := httptest.NewRecorder()
+			login(w, httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(data)))
+			if w.Code != c.want {
+				t.Errorf("%s: expected status %d, got %d", c.username, c.want, w.Code)
+			}
+		}
+	})
+
+	t.Run("refresh", func(t *testing.T) {
+		tokenSvc := &services.MockTokenService{}
+		handler := RefreshHandler(userSvc, tokenSvc, testTokenManager(t), true)
+		for _, c := range []struct {
+			userID int
+			want   int
+		}{
+			{1, http.StatusForbidden},
+			{2, http.StatusOK},
+		} {
+			token, _ := tokenSvc.IssueRefreshToken(c.userID, 0)
+			if w := postJSON(handler, "/token/refresh", RefreshRequest{RefreshToken: token}); w.Code != c.want {
+				t.Errorf("user %d: expected status %d, got %d", c.userID, c.want, w.Code)
+			}
+		}
+	})
+
+	t.Run("tasks", func(t *testing.T) {
+		handler := RequireVerifiedEmail(userSvc)(TasksHandler(&services.MockTaskService{}, false))
+		call := func(method string, userID int) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, "/tasks", strings.NewReader(`{"title":"Task","status":"new"}`))
+			req = withPrincipal(req, &auth.Principal{UserID: userID})
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		w := call(http.MethodPost, 1)
+		if p := decodeProblem(t, w); w.Code != http.StatusForbidden || p.Detail != "email not verified" {
+			t.Errorf("Unverified POST: expected 403 problem, got %d %+v", w.Code, p)
+		}
+		if w := call(http.MethodGet, 1); w.Code != http.StatusOK {
+			t.Errorf("Unverified GET: expected 200, got %d", w.Code)
+		}
+		if w := call(http.MethodPost, 2); w.Code != http.StatusOK {
+			t.Errorf("Verified POST: expected 200, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-portfolio/rest-api/internal/problem"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// serviceErrorStatus возвращает HTTP-статус для категории доменной ошибки
+func serviceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrValidation):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// writeServiceError отвечает на ошибку сервисного слоя в формате problem+json.
+// Текст доменных ошибок передаётся клиенту в detail, остальные ошибки
+// только пишутся в лог вместе с ID запроса, а клиент получает "internal error".
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	status := serviceErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("internal error: %s %s request_id=%q: %v", r.Method, r.URL.Path, problem.RequestIDFromContext(r.Context()), err)
+		problem.Write(w, r, status, "internal error")
+		return
+	}
+	problem.Write(w, r, status, err.Error())
+}
+
+// writeDecodeError отвечает на ошибку разбора JSON-тела. Поле неверного типа
+// или неизвестное поле попадает в errors, для остальных ошибок отправляется detail.
+func writeDecodeError(w http.ResponseWriter, r *http.Request, err error, detail string) {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		problem.Validation([]problem.FieldError{{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: "must be of type " + jsonTypeName(typeErr.Type.Kind()),
+		}}).Write(w, r)
+		return
+	}
+	// encoding/json не экспортирует тип ошибки неизвестного поля
+	if _, field, ok := strings.Cut(err.Error(), "json: unknown field "); ok {
+		problem.Validation([]problem.FieldError{{
+			Field:   strings.Trim(field, `"`),
+			Code:    "unknown",
+			Message: "is not a known field",
+		}}).Write(w, r)
+		return
+	}
+	problem.Write(w, r, http.StatusBadRequest, detail)
+}
+
+// jsonTypeName называет тип Go так, как он выглядит в JSON
+func jsonTypeName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/problem"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// ImpersonationAudit записывает в журнал действия администраторов от имени пользователей.
+// nil или пустой Events отключает имперсонацию: выдача токенов и изменяющие
+// запросы с такими токенами отклоняются.
+type ImpersonationAudit struct {
+	Events  services.ImpersonationAuditService
+	Limiter *auth.LoginLimiter // определяет IP клиента с учётом trust_forwarded_for
+}
+
+// record сохраняет действие principal в журнале и возвращает ID записи
+func (a *ImpersonationAudit) record(r *http.Request, p *auth.Principal, action string, status int) (int, error) {
+	if a == nil || a.Events == nil {
+		return 0, errors.New("impersonation audit is not configured")
+	}
+	return a.Events.RecordImpersonation(models.ImpersonationEvent{
+		AdminID: p.ImpersonatorID,
+		UserID:  p.UserID,
+		TokenID: p.TokenID,
+		Action:  action,
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		IP:      a.Limiter.ClientIP(r),
+		Status:  status,
+	})
+}
+
+// Middleware записывает в журнал каждый изменяющий запрос, выполненный токеном
+// имперсонации. Запись создаётся до выполнения запроса: если журнал недоступен,
+// запрос отклоняется. Ставится после auth.VerifyToken.
+func (a *ImpersonationAudit) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok || principal.ImpersonatorID == 0 || isSafeMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		id, err := a.record(r, principal, models.ImpersonationWrite, 0)
+		if err != nil {
+			log.Printf("impersonation audit: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		if err := a.Events.CompleteImpersonation(id, sw.Status()); err != nil {
+			log.Printf("impersonation audit: %v", err)
+		}
+	})
+}
+
+// isSafeMethod сообщает, что метод не изменяет данные
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// statusWriter запоминает код ответа
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Status возвращает отправленный код ответа (200, если обработчик ничего не записал)
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// ImpersonateHandler godoc
+// @Summary      Вход от имени пользователя
+// @Description  Выдаёт администратору короткоживущий access-токен пользователя для воспроизведения
+// @Description  проблем. Токен содержит claim act с ID администратора, живёт jwt.impersonation_ttl
+// @Description  (по умолчанию 15 минут, не более часа) и не продлевается: refresh-токен не выдаётся.
+// @Description  Ответы на запросы с таким токеном содержат заголовок X-Impersonated-By,
+// @Description  а все изменяющие запросы записываются в журнал. Управлять учётной записью
+// @Description  (пароль, ключи, сессии, 2FA) с таким токеном нельзя. Администраторов имперсонировать нельзя.
+// @Tags         users
+// @Produce      json
+// @Param        id  path  int  true  "ID пользователя"
+// @Success      201  {object}  ImpersonationResponse  "Токен от имени пользователя"
+// @Failure      400  {string}  string  "Некорректный ID или попытка имперсонировать себя"
+// @Failure      401  {string}  string  "Неавторизован"
+// @Failure      403  {string}  string  "Нет прав users:admin, делегированные учётные данные или пользователь — администратор"
+// @Failure      404  {string}  string  "Пользователь не найден"
+// @Security     BearerAuth
+// @Router       /users/{id}/impersonate [post]
+func ImpersonateHandler(userSvc services.UserService, tm *auth.TokenManager, audit *ImpersonationAudit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/users/"), "/")
+		idStr, action, _ := strings.Cut(rest, "/")
+		if action != "impersonate" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		admin, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		// Только администратор, вошедший сам: не ключом, не OAuth-клиентом и не из-под другого пользователя
+		if admin.Delegated() {
+			http.Error(w, "impersonation requires an admin session", http.StatusForbidden)
+			return
+		}
+
+		id, err := strconv.Atoi(idStr)
+		if err != nil || id <= 0 {
+			http.Error(w, "invalid user ID", http.StatusBadRequest)
+			return
+		}
+		if id == admin.UserID {
+			http.Error(w, "cannot impersonate yourself", http.StatusBadRequest)
+			return
+		}
+		user, err := userSvc.GetUserByID(id)
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		if user.Role == auth.RoleAdmin {
+			http.Error(w, "administrators cannot be impersonated", http.StatusForbidden)
+			return
+		}
+
+		principal := principalFor(user)
+		principal.ImpersonatorID = admin.UserID
+		token, err := tm.GenerateToken(principal)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		claims, err := tm.ParseToken(token)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		// Без записи в журнале токен не выдаём
+		if _, err := audit.record(r, claims.Principal(), models.ImpersonationStart, http.StatusCreated); err != nil {
+			log.Printf("impersonation audit: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		user.Password = ""
+		setNoStore(w)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(ImpersonationResponse{
+			Token:     token,
+			ExpiresIn: int(tm.ImpersonationTTL() / time.Second),
+			User:      *user,
+		})
+	}
+}
+
+// ImpersonationResponse модель ответа POST /users/{id}/impersonate
+// swagger:model ImpersonationResponse
+type ImpersonationResponse struct {
+	// Access-токен от имени пользователя (с claim act)
+	// example: eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
+	Token string `json:"token"`
+	// Срок жизни токена в секундах
+	// example: 900
+	ExpiresIn int `json:"expires_in"`
+	// Пользователь, от имени которого выдан токен
+	User models.User `json:"user"`
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// TestImpersonation проверяет выдачу токена от имени пользователя, заголовок ответа и журнал
+func TestImpersonation(t *testing.T) {
+	tm := testTokenManager(t)
+	users := testUsers()
+	events := &services.MockImpersonationAuditService{}
+	audit := &ImpersonationAudit{Events: events}
+	taskSvc := &services.MockTaskService{}
+	requireAuth := func(next http.Handler) http.Handler {
+		return auth.VerifyToken(tm)(audit.Middleware(next))
+	}
+	impersonate := ImpersonateHandler(users, tm, audit)
+	tasks := requireAuth(auth.RequireAccess(auth.PermTasksRead, auth.PermTasksWrite)(TasksHandler(taskSvc, false)))
+	me := requireAuth(MeHandler(users, &services.MockTokenService{}, nil, nil, nil))
+
+	start := func(admin *auth.Principal, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		impersonate(w, withPrincipal(httptest.NewRequest(http.MethodPost, path, nil), admin))
+		return w
+	}
+	boss := &auth.Principal{UserID: 2, Role: auth.RoleAdmin}
+
+	// Себя, других администраторов и из-под делегированных учётных данных — нельзя
+	if w := start(boss, "/users/2/impersonate"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for self, got %d", w.Code)
+	}
+	users.Users[2].Role = auth.RoleAdmin
+	if w := start(boss, "/users/3/impersonate"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for admin target, got %d", w.Code)
+	}
+	if w := start(&auth.Principal{UserID: 2, Role: auth.RoleAdmin, APIKeyID: 5}, "/users/1/impersonate"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for api key, got %d", w.Code)
+	}
+	if w := start(boss, "/users/42/impersonate"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	w := start(boss, "/users/1/impersonate")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ImpersonationResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.ExpiresIn != int(auth.DefaultImpersonationTTL/time.Second) || resp.User.ID != 1 {
+		t.Fatalf("Unexpected response: %+v", resp)
+	}
+	claims, err := tm.ParseToken(resp.Token)
+	if err != nil || claims.Act == nil || claims.Act.Subject != "2" || claims.UserID != 1 {
+		t.Fatalf("Expected act claim for admin 2, got %+v (%v)", claims, err)
+	}
+	if ttl := claims.ExpiresAt.Sub(claims.IssuedAt.Time); ttl != auth.DefaultImpersonationTTL {
+		t.Errorf("Expected impersonation TTL, got %v", ttl)
+	}
+	if len(events.Events) != 1 || events.Events[0].Action != models.ImpersonationStart || events.Events[0].AdminID != 2 {
+		t.Fatalf("Expected start event, got %+v", events.Events)
+	}
+
+	call := func(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+resp.Token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// Чтение не журналируется, но ответ помечен заголовком
+	w = call(tasks, http.MethodGet, "/tasks", "")
+	if w.Code != http.StatusOK || w.Header().Get(auth.ImpersonationHeader) != "2" {
+		t.Fatalf("Expected impersonation header, got %d %q", w.Code, w.Header().Get(auth.ImpersonationHeader))
+	}
+	if len(events.Events) != 1 {
+		t.Fatalf("Reads must not be audited: %+v", events.Events)
+	}
+
+	// Запись журналируется вместе со статусом ответа
+	w = call(tasks, http.MethodPost, "/tasks", `{"title":"Reproduce bug","status":"pending"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	last := events.Events[len(events.Events)-1]
+	if last.Action != models.ImpersonationWrite || last.Method != http.MethodPost || last.Path != "/tasks" ||
+		last.Status != http.StatusOK || last.AdminID != 2 || last.UserID != 1 || last.TokenID != claims.ID {
+		t.Fatalf("Unexpected audit event: %+v", last)
+	}
+
+	// Учётной записью пользователя управлять нельзя
+	if w := call(me, http.MethodPut, "/me/password", `{"current_password":"password123","new_password":"newpass123"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for password change, got %d", w.Code)
+	}
+
+	// Без журнала изменяющие запросы не выполняются
+	events.Err = errors.New("db down")
+	w = call(tasks, http.MethodPost, "/tasks", `{"title":"Unaudited","status":"pending"}`)
+	if p := decodeProblem(t, w); w.Code != http.StatusInternalServerError || p.Detail != "internal error" {
+		t.Errorf("Expected 500 problem when audit fails, got %d %+v", w.Code, p)
+	}
+	if w := start(boss, "/users/1/impersonate"); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected no token without audit record, got %d", w.Code)
+	}
+}
@@ -0,0 +1,618 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/oidc"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// Поддерживаемые grant_type (RFC 6749)
+const (
+	grantAuthorizationCode = "authorization_code"
+	grantClientCredentials = "client_credentials"
+)
+
+// OAuthClientsHandler godoc
+// @Summary      Клиенты OAuth 2.0
+// @Description  Регистрация, просмотр и отзыв клиентов сервера авторизации (только администратор).
+// @Description  Секрет конфиденциального клиента показывается только один раз — в ответе на создание.
+// @Tags         oauth
+// @Accept       json
+// @Produce      json
+// @Param        client_id  path  string                    false  "client_id клиента"
+// @Param        request    body  CreateOAuthClientRequest  false  "Параметры клиента"
+// @Success      200  {array}   models.OAuthClient         "Список клиентов"
+// @Success      201  {object}  CreateOAuthClientResponse  "Зарегистрированный клиент"
+// @Success      204  {string}  string                     "Клиент отозван"
+// @Failure      400  {string}  string                     "Некорректный запрос"
+// @Failure      401  {string}  string                     "Неавторизован"
+// @Failure      403  {string}  string                     "Требуется разрешение users:admin"
+// @Failure      404  {string}  string                     "Клиент не найден"
+// @Security     BearerAuth
+// @Router       /oauth/clients [get]
+// @Router       /oauth/clients [post]
+// @Router       /oauth/clients/{client_id} [delete]
+func OAuthClientsHandler(svc services.OAuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		clientID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/oauth/clients"), "/")
+
+		switch {
+		// -----------------------------
+		// GET /oauth/clients
+		// -----------------------------
+		case r.Method == http.MethodGet && clientID == "":
+			clients, err := svc.ListClients()
+			if err != nil {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(clients)
+
+		// -----------------------------
+		// POST /oauth/clients
+		// -----------------------------
+		case r.Method == http.MethodPost && clientID == "":
+			var req CreateOAuthClientRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid JSON", http.StatusBadRequest)
+				return
+			}
+			if err := authValidate.Struct(req); err != nil {
+				writeValidationErrors(w, err)
+				return
+			}
+			if err := req.check(principal); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			secret, client, err := svc.CreateClient(models.OAuthClient{
+				Name:         req.Name,
+				OwnerID:      principal.UserID,
+				RedirectURIs: req.RedirectURIs,
+				GrantTypes:   req.GrantTypes,
+				Scopes:       req.Scopes,
+				Public:       req.Public,
+			})
+			if err != nil {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(CreateOAuthClientResponse{ClientSecret: secret, Client: *client})
+
+		// -----------------------------
+		// DELETE /oauth/clients/{client_id}
+		// -----------------------------
+		case r.Method == http.MethodDelete && clientID != "":
+			if err := svc.RevokeClient(clientID); err != nil {
+				if errors.Is(err, services.ErrOAuthClientNotFound) {
+					http.Error(w, "oauth client not found", http.StatusNotFound)
+					return
+				}
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// OAuthAuthorizeHandler godoc
+// @Summary      Авторизация клиента OAuth 2.0
+// @Description  Authorization code flow (RFC 6749, раздел 4.1) с PKCE (RFC 7636, только S256).
+// @Description  Пользователь аутентифицируется своим access-токеном; согласие считается данным,
+// @Description  и браузер перенаправляется на redirect_uri с параметрами code и state.
+// @Description  Публичные клиенты обязаны передавать code_challenge.
+// @Tags         oauth
+// @Param        response_type          query  string  true   "Только code"
+// @Param        client_id              query  string  true   "client_id клиента"
+// @Param        redirect_uri           query  string  false  "Один из зарегистрированных redirect_uri"
+// @Param        scope                  query  string  false  "Разрешения через пробел; по умолчанию — все разрешения клиента"
+// @Param        state                  query  string  false  "Возвращается клиенту без изменений"
+// @Param        code_challenge         query  string  false  "PKCE code_challenge"
+// @Param        code_challenge_method  query  string  false  "Только S256"
+// @Success      302  {string}  string  "Перенаправление на redirect_uri с code или error"
+// @Failure      400  {object}  OAuthErrorResponse  "Неизвестный клиент или redirect_uri"
+// @Failure      401  {string}  string  "Неавторизован"
+// @Failure      403  {object}  OAuthErrorResponse  "Токен выдан не пользователю напрямую"
+// @Security     BearerAuth
+// @Router       /oauth/authorize [get]
+func OAuthAuthorizeHandler(svc services.OAuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		// Делегировать доступ может только сам пользователь, а не ключ, другой клиент
+		// или администратор от его имени
+		if principal.Delegated() {
+			writeOAuthError(w, http.StatusForbidden, "access_denied", "authorization requires a user session")
+			return
+		}
+
+		q := r.URL.Query()
+		client, err := svc.GetClient(q.Get("client_id"))
+		if errors.Is(err, services.ErrOAuthClientNotFound) {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_client", "unknown client_id")
+			return
+		}
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		// Пока redirect_uri не проверен, ошибки возвращаются пользователю, а не клиенту
+		redirectURI := q.Get("redirect_uri")
+		if redirectURI == "" && len(client.RedirectURIs) == 1 {
+			redirectURI = client.RedirectURIs[0]
+		}
+		if !client.HasRedirectURI(redirectURI) {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_request", "redirect_uri is not registered")
+			return
+		}
+
+		state := q.Get("state")
+		fail := func(code, description string) {
+			redirectWithParams(w, r, redirectURI, url.Values{
+				"error": {code}, "error_description": {description}, "state": {state},
+			})
+		}
+
+		if q.Get("response_type") != "code" {
+			fail("unsupported_response_type", "only response_type=code is supported")
+			return
+		}
+		if !client.HasGrantType(grantAuthorizationCode) {
+			fail("unauthorized_client", "client may not use authorization_code")
+			return
+		}
+		challenge := q.Get("code_challenge")
+		if method := q.Get("code_challenge_method"); challenge != "" && method != "S256" {
+			fail("invalid_request", "code_challenge_method must be S256")
+			return
+		}
+		if challenge == "" && client.Public {
+			fail("invalid_request", "code_challenge is required for public clients")
+			return
+		}
+		scopes, ok := grantScopes(q.Get("scope"), client, principal.Role)
+		if !ok {
+			fail("invalid_scope", "requested scope is not allowed")
+			return
+		}
+
+		code, err := svc.CreateAuthCode(services.AuthCode{
+			ClientID:        client.ClientID,
+			UserID:          principal.UserID,
+			RedirectURI:     redirectURI,
+			RedirectURISent: q.Get("redirect_uri") != "",
+			Scopes:          scopes,
+			CodeChallenge:   challenge,
+		})
+		if err != nil {
+			fail("server_error", "could not issue authorization code")
+			return
+		}
+		redirectWithParams(w, r, redirectURI, url.Values{"code": {code}, "state": {state}})
+	}
+}
+
+// OAuthTokenHandler godoc
+// @Summary      Выдача токена OAuth 2.0
+// @Description  Поддерживаются grant_type=authorization_code (с PKCE) и client_credentials.
+// @Description  Клиент аутентифицируется через HTTP Basic или параметры client_id и client_secret;
+// @Description  публичный клиент передаёт только client_id. Токены client_credentials выдаются
+// @Description  от имени администратора, зарегистрировавшего клиента. Refresh-токен не выдаётся.
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        grant_type     formData  string  true   "authorization_code или client_credentials"
+// @Param        code           formData  string  false  "Код авторизации"
+// @Param        redirect_uri   formData  string  false  "redirect_uri из запроса авторизации (обязателен, если был передан в нём)"
+// @Param        code_verifier  formData  string  false  "PKCE code_verifier"
+// @Param        scope          formData  string  false  "Разрешения через пробел (client_credentials)"
+// @Success      200  {object}  OAuthTokenResponse  "Access-токен"
+// @Failure      400  {object}  OAuthErrorResponse  "Некорректный запрос, код или scope"
+// @Failure      401  {object}  OAuthErrorResponse  "Клиент не аутентифицирован"
+// @Router       /oauth/token [post]
+func OAuthTokenHandler(svc services.OAuthService, userSvc services.UserService, tm *auth.TokenManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		client, ok := authenticateOAuthClient(w, r, svc)
+		if !ok {
+			return
+		}
+
+		grantType := r.PostForm.Get("grant_type")
+		if grantType != grantAuthorizationCode && grantType != grantClientCredentials {
+			writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "unsupported grant_type")
+			return
+		}
+		if !client.HasGrantType(grantType) {
+			writeOAuthError(w, http.StatusBadRequest, "unauthorized_client", "client may not use "+grantType)
+			return
+		}
+
+		var userID int
+		var requested string
+		switch grantType {
+		// -----------------------------
+		// grant_type=client_credentials
+		// -----------------------------
+		case grantClientCredentials:
+			userID = client.OwnerID
+			requested = r.PostForm.Get("scope")
+
+		// -----------------------------
+		// grant_type=authorization_code
+		// -----------------------------
+		case grantAuthorizationCode:
+			code, err := svc.ConsumeAuthCode(r.PostForm.Get("code"), client.ClientID)
+			if errors.Is(err, services.ErrInvalidAuthCode) {
+				writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "invalid authorization code")
+				return
+			}
+			if err != nil {
+				writeOAuthError(w, http.StatusInternalServerError, "server_error", "internal error")
+				return
+			}
+			// redirect_uri обязателен, если он был передан при авторизации
+			if uri := r.PostForm.Get("redirect_uri"); (uri != "" || code.RedirectURISent) && uri != code.RedirectURI {
+				writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "redirect_uri mismatch")
+				return
+			}
+			verifier := r.PostForm.Get("code_verifier")
+			if (code.CodeChallenge != "" || verifier != "") && oidc.CodeChallenge(verifier) != code.CodeChallenge {
+				writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "invalid code_verifier")
+				return
+			}
+			userID = code.UserID
+			requested = strings.Join(code.Scopes, " ")
+		}
+
+		// Роль берём из базы: она могла измениться с момента выдачи кода
+		user, err := userSvc.GetUserByID(userID)
+		if err != nil {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "resource owner not found")
+			return
+		}
+		scopes, ok := grantScopes(requested, client, user.Role)
+		if !ok {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_scope", "requested scope is not allowed")
+			return
+		}
+
+		token, err := tm.GenerateToken(auth.Principal{
+			UserID:   user.ID,
+			Role:     user.Role,
+			Scopes:   scopes,
+			ClientID: client.ClientID,
+		})
+		if err != nil {
+			writeOAuthError(w, http.StatusInternalServerError, "server_error", "internal error")
+			return
+		}
+
+		setNoStore(w)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(OAuthTokenResponse{
+			AccessToken: token,
+			TokenType:   "Bearer",
+			ExpiresIn:   int(tm.AccessTTL().Seconds()),
+			Scope:       strings.Join(scopes, " "),
+		})
+	}
+}
+
+// OAuthIntrospectHandler godoc
+// @Summary      Проверка токена (RFC 7662)
+// @Description  Возвращает active=false для недействительных, истёкших и отозванных токенов,
+// @Description  а также для токенов завершённых сессий и отозванных клиентов.
+// @Description  Доступно только конфиденциальным клиентам.
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        token  formData  string  true  "Access-токен"
+// @Success      200  {object}  IntrospectionResponse  "Состояние токена"
+// @Failure      401  {object}  OAuthErrorResponse     "Клиент не аутентифицирован"
+// @Router       /oauth/introspect [post]
+func OAuthIntrospectHandler(svc services.OAuthService, tokenSvc services.TokenService,
+	sessions services.SessionService, tm *auth.TokenManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		client, ok := authenticateOAuthClient(w, r, svc)
+		if !ok {
+			return
+		}
+		if client.Public {
+			writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "public clients may not introspect tokens")
+			return
+		}
+
+		resp := IntrospectionResponse{}
+		if claims, err := tm.ParseToken(r.PostForm.Get("token")); err == nil {
+			revoked, err := tokenSvc.IsRevoked(claims.ID)
+			// Токены завершённой сессии и отозванного клиента неактивны, как и в auth.VerifyToken
+			if err == nil && !revoked && claims.SessionID != 0 && sessions != nil {
+				var active bool
+				active, err = sessions.SessionActive(claims.SessionID)
+				revoked = !active
+			}
+			if err == nil && !revoked && claims.ClientID != "" {
+				var active bool
+				active, err = svc.ClientActive(claims.ClientID)
+				revoked = !active
+			}
+			if err != nil {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			if !revoked {
+				resp = IntrospectionResponse{
+					Active:    true,
+					Scope:     claims.Scope,
+					ClientID:  claims.ClientID,
+					Subject:   claims.Subject,
+					TokenType: "Bearer",
+					Issuer:    claims.Issuer,
+					ExpiresAt: claims.ExpiresAt.Unix(),
+				}
+				if claims.IssuedAt != nil {
+					resp.IssuedAt = claims.IssuedAt.Unix()
+				}
+			}
+		}
+
+		setNoStore(w)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// OAuthRevokeHandler godoc
+// @Summary      Отзыв токена (RFC 7009)
+// @Description  Отзывает access-токен, выданный этому клиенту. Ответ 200 возвращается
+// @Description  и для неизвестных токенов, чтобы клиент не мог проверять чужие токены.
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Param        token  formData  string  true  "Access-токен"
+// @Success      200  {string}  string  "Токен отозван или неизвестен"
+// @Failure      401  {object}  OAuthErrorResponse  "Клиент не аутентифицирован"
+// @Router       /oauth/revoke [post]
+func OAuthRevokeHandler(svc services.OAuthService, tokenSvc services.TokenService, tm *auth.TokenManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		client, ok := authenticateOAuthClient(w, r, svc)
+		if !ok {
+			return
+		}
+
+		claims, err := tm.ParseToken(r.PostForm.Get("token"))
+		if err == nil && claims.ClientID == client.ClientID && claims.ID != "" {
+			expiresAt := time.Now().Add(tm.AccessTTL())
+			if claims.ExpiresAt != nil {
+				expiresAt = claims.ExpiresAt.Time
+			}
+			if err := tokenSvc.RevokeAccessToken(claims.ID, expiresAt); err != nil {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// authenticateOAuthClient разбирает форму и проверяет клиента (HTTP Basic или
+// client_id/client_secret в теле). При ошибке ответ уже записан.
+func authenticateOAuthClient(w http.ResponseWriter, r *http.Request, svc services.OAuthService) (*models.OAuthClient, bool) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "invalid form body")
+		return nil, false
+	}
+
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		// RFC 6749, раздел 2.3.1: значения закодированы как application/x-www-form-urlencoded
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	client, err := svc.AuthenticateClient(clientID, secret)
+	if errors.Is(err, services.ErrInvalidClient) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return nil, false
+	}
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "internal error")
+		return nil, false
+	}
+	return client, true
+}
+
+// grantScopes вычисляет scope токена: запрошенные разрешения (по умолчанию —
+// все разрешения клиента), которые есть и у клиента, и у роли пользователя.
+// Пустой результат недопустим: токен без scope получил бы все права роли.
+func grantScopes(requested string, client *models.OAuthClient, role string) ([]string, bool) {
+	want := strings.Fields(requested)
+	explicit := len(want) > 0
+	if !explicit {
+		want = client.Scopes
+	}
+
+	owner := &auth.Principal{Role: role}
+	scopes := []string{}
+	for _, scope := range want {
+		allowed := auth.ValidScope(scope) && owner.Can(auth.Permission(scope))
+		if allowed {
+			allowed = false
+			for _, s := range client.Scopes {
+				if s == scope {
+					allowed = true
+					break
+				}
+			}
+		}
+		if !allowed {
+			// Явно запрошенное, но недоступное разрешение — ошибка
+			if explicit {
+				return nil, false
+			}
+			continue
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, len(scopes) > 0
+}
+
+// redirectWithParams перенаправляет на uri, добавляя параметры к его query
+func redirectWithParams(w http.ResponseWriter, r *http.Request, uri string, params url.Values) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	q := u.Query()
+	for k, v := range params {
+		if len(v) > 0 && v[0] != "" {
+			q.Set(k, v[0])
+		}
+	}
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// writeOAuthError отправляет ошибку в формате RFC 6749, раздел 5.2
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	setNoStore(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(OAuthErrorResponse{Error: code, ErrorDescription: description})
+}
+
+// setNoStore запрещает кэширование ответов с токенами
+func setNoStore(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+}
+
+// CreateOAuthClientRequest модель запроса регистрации OAuth-клиента
+// swagger:model CreateOAuthClientRequest
+type CreateOAuthClientRequest struct {
+	// Название клиента
+	// example: reporting
+	Name string `json:"name" validate:"required,max=100"`
+	// Адреса возврата для authorization_code (точное совпадение)
+	// example: ["https://app.example.com/callback"]
+	RedirectURIs []string `json:"redirect_uris" validate:"dive,url"`
+	// Разрешённые grant_type
+	// example: ["authorization_code"]
+	GrantTypes []string `json:"grant_types" validate:"required,min=1,dive,oneof=authorization_code client_credentials"`
+	// Максимальный набор разрешений клиента
+	// example: ["tasks:read"]
+	Scopes []string `json:"scopes" validate:"required,min=1"`
+	// Публичный клиент (SPA, мобильное приложение) — без секрета
+	Public bool `json:"public"`
+}
+
+// check проверяет правила, которые не выражаются тегами validate
+func (req *CreateOAuthClientRequest) check(admin *auth.Principal) error {
+	for _, scope := range req.Scopes {
+		if !auth.ValidScope(scope) || !admin.Can(auth.Permission(scope)) {
+			return fmt.Errorf("scope %q is not allowed", scope)
+		}
+	}
+	client := models.OAuthClient{GrantTypes: req.GrantTypes}
+	if req.Public && client.HasGrantType(grantClientCredentials) {
+		return errors.New("public clients may not use client_credentials")
+	}
+	if client.HasGrantType(grantAuthorizationCode) && len(req.RedirectURIs) == 0 {
+		return errors.New("authorization_code requires at least one redirect_uri")
+	}
+	for _, uri := range req.RedirectURIs {
+		if strings.Contains(uri, "#") {
+			return fmt.Errorf("redirect_uri %q must not contain a fragment", uri)
+		}
+	}
+	return nil
+}
+
+// CreateOAuthClientResponse модель ответа с новым OAuth-клиентом
+// swagger:model CreateOAuthClientResponse
+type CreateOAuthClientResponse struct {
+	// Секрет клиента — показывается только один раз; пустой у публичных клиентов
+	ClientSecret string `json:"client_secret,omitempty"`
+	// Описание клиента
+	Client models.OAuthClient `json:"client"`
+}
+
+// OAuthTokenResponse модель ответа /oauth/token (RFC 6749, раздел 5.1)
+// swagger:model OAuthTokenResponse
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	// example: Bearer
+	TokenType string `json:"token_type"`
+	// Срок жизни токена в секундах
+	// example: 900
+	ExpiresIn int `json:"expires_in"`
+	// example: tasks:read
+	Scope string `json:"scope"`
+}
+
+// OAuthErrorResponse модель ошибки OAuth (RFC 6749, раздел 5.2)
+// swagger:model OAuthErrorResponse
+type OAuthErrorResponse struct {
+	// example: invalid_grant
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// IntrospectionResponse модель ответа /oauth/introspect (RFC 7662)
+// swagger:model IntrospectionResponse
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
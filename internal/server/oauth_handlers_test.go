@@ -0,0 +1,335 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/oidc"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// registerOAuthClient регистрирует клиента от имени администратора (пользователь 2)
+func registerOAuthClient(t *testing.T, svc services.OAuthService, req CreateOAuthClientRequest) CreateOAuthClientResponse {
+	t.Helper()
+	data, _ := json.Marshal(req)
+	r := withPrincipal(httptest.NewRequest(http.MethodPost, "/oauth/clients", bytes.NewReader(data)),
+		&auth.Principal{UserID: 2, Role: auth.RoleAdmin})
+	w := httptest.NewRecorder()
+	OAuthClientsHandler(svc)(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp CreateOAuthClientResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	return resp
+}
+
+// postForm отправляет форму; непустой clientID передаётся через HTTP Basic
+func postForm(handler http.HandlerFunc, path, clientID, secret string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientID != "" {
+		req.SetBasicAuth(clientID, secret)
+	}
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+// TestOAuthClientCredentials проверяет выдачу, проверку и отзыв токена client_credentials
+func TestOAuthClientCredentials(t *testing.T) {
+	tm := testTokenManager(t)
+	svc := &services.MockOAuthService{}
+	tokenSvc := &services.MockTokenService{}
+	token := OAuthTokenHandler(svc, testUsers(), tm)
+	introspect := OAuthIntrospectHandler(svc, tokenSvc, nil, tm)
+
+	client := registerOAuthClient(t, svc, CreateOAuthClientRequest{
+		Name:       "reporting",
+		GrantTypes: []string{"client_credentials"},
+		Scopes:     []string{"tasks:read", "tasks:admin"},
+	})
+	if client.ClientSecret == "" {
+		t.Fatal("Expected client secret for confidential client")
+	}
+	id := client.Client.ClientID
+
+	// Неверный секрет
+	w := postForm(token, "/oauth/token", id, "wrong", url.Values{"grant_type": {"client_credentials"}})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, got %d", w.Code)
+	}
+
+	// Scope вне набора клиента
+	w = postForm(token, "/oauth/token", id, client.ClientSecret,
+		url.Values{"grant_type": {"client_credentials"}, "scope": {"tasks:write"}})
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid_scope") {
+		t.Fatalf("Expected invalid_scope, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = postForm(token, "/oauth/token", id, client.ClientSecret,
+		url.Values{"grant_type": {"client_credentials"}, "scope": {"tasks:read"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp OAuthTokenResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.TokenType != "Bearer" || resp.Scope != "tasks:read" || resp.ExpiresIn <= 0 {
+		t.Fatalf("Unexpected token response: %+v", resp)
+	}
+
+	// Токен выдан от имени владельца клиента и ограничен scope
+	claims, err := tm.ParseToken(resp.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := claims.Principal()
+	if p.UserID != 2 || p.ClientID != id || !p.Can(auth.PermTasksRead) || p.Can(auth.PermTasksManage) {
+		t.Fatalf("Unexpected principal: %+v", p)
+	}
+
+	// Introspection видит активный токен
+	w = postForm(introspect, "/oauth/introspect", id, client.ClientSecret, url.Values{"token": {resp.AccessToken}})
+	var info IntrospectionResponse
+	json.NewDecoder(w.Body).Decode(&info)
+	if !info.Active || info.ClientID != id || info.Scope != "tasks:read" || info.Subject != "2" {
+		t.Fatalf("Unexpected introspection: %+v", info)
+	}
+
+	// После отзыва токен неактивен
+	w = postForm(OAuthRevokeHandler(svc, tokenSvc, tm), "/oauth/revoke", id, client.ClientSecret,
+		url.Values{"token": {resp.AccessToken}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	w = postForm(introspect, "/oauth/introspect", id, client.ClientSecret, url.Values{"token": {resp.AccessToken}})
+	info = IntrospectionResponse{}
+	json.NewDecoder(w.Body).Decode(&info)
+	if info.Active {
+		t.Fatal("Expected revoked token to be inactive")
+	}
+}
+
+// TestOAuthRevokedClient проверяет, что токены отозванного клиента перестают действовать
+func TestOAuthRevokedClient(t *testing.T) {
+	tm := testTokenManager(t)
+	svc := &services.MockOAuthService{}
+	tokenSvc := &services.MockTokenService{}
+	introspect := OAuthIntrospectHandler(svc, tokenSvc, nil, tm)
+	protected := auth.VerifyToken(tm, auth.WithDenylist(tokenSvc), auth.WithClients(svc))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	reporting := registerOAuthClient(t, svc, CreateOAuthClientRequest{
+		Name:       "reporting",
+		GrantTypes: []string{"client_credentials"},
+		Scopes:     []string{"tasks:read"},
+	})
+	gateway := registerOAuthClient(t, svc, CreateOAuthClientRequest{
+		Name:       "gateway",
+		GrantTypes: []string{"client_credentials"},
+		Scopes:     []string{"tasks:read"},
+	})
+
+	w := postForm(OAuthTokenHandler(svc, testUsers(), tm), "/oauth/token", reporting.Client.ClientID,
+		reporting.ClientSecret, url.Values{"grant_type": {"client_credentials"}})
+	var resp OAuthTokenResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+
+	call := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+resp.AccessToken)
+		w := httptest.NewRecorder()
+		protected.ServeHTTP(w, req)
+		return w.Code
+	}
+	active := func() bool {
+		w := postForm(introspect, "/oauth/introspect", gateway.Client.ClientID, gateway.ClientSecret,
+			url.Values{"token": {resp.AccessToken}})
+		var info IntrospectionResponse
+		json.NewDecoder(w.Body).Decode(&info)
+		return info.Active
+	}
+	if code := call(); code != http.StatusOK || !active() {
+		t.Fatalf("Expected active token before revocation, got %d", code)
+	}
+
+	if err := svc.RevokeClient(reporting.Client.ClientID); err != nil {
+		t.Fatal(err)
+	}
+	if code := call(); code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 after client revocation, got %d", code)
+	}
+	if active() {
+		t.Error("Expected introspection to report token of revoked client as inactive")
+	}
+}
+
+// TestOAuthAuthorizationCode проверяет authorization code flow с PKCE
+func TestOAuthAuthorizationCode(t *testing.T) {
+	tm := testTokenManager(t)
+	svc := &services.MockOAuthService{}
+	authorize := OAuthAuthorizeHandler(svc)
+	token := OAuthTokenHandler(svc, testUsers(), tm)
+
+	client := registerOAuthClient(t, svc, CreateOAuthClientRequest{
+		Name:         "spa",
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		GrantTypes:   []string{"authorization_code"},
+		Scopes:       []string{"tasks:read", "tasks:write"},
+		Public:       true,
+	})
+	if client.ClientSecret != "" {
+		t.Fatal("Public client must not get a secret")
+	}
+	id := client.Client.ClientID
+	verifier, _ := oidc.NewCodeVerifier()
+
+	// start выполняет /oauth/authorize от имени пользователя user и возвращает Location
+	start := func(user *auth.Principal, params url.Values) *url.URL {
+		t.Helper()
+		params.Set("response_type", "code")
+		params.Set("client_id", id)
+		req := withPrincipal(httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+params.Encode(), nil), user)
+		w := httptest.NewRecorder()
+		authorize(w, req)
+		if w.Code != http.StatusFound {
+			t.Fatalf("Expected status 302, got %d: %s", w.Code, w.Body.String())
+		}
+		loc, _ := url.Parse(w.Header().Get("Location"))
+		return loc
+	}
+	member := &auth.Principal{UserID: 1, Role: auth.RoleMember}
+
+	// Публичный клиент без PKCE
+	loc := start(member, url.Values{"state": {"xyz"}})
+	if loc.Query().Get("error") != "invalid_request" || loc.Query().Get("state") != "xyz" {
+		t.Fatalf("Expected invalid_request, got %s", loc)
+	}
+
+	// Viewer не может делегировать tasks:write
+	loc = start(&auth.Principal{UserID: 3, Role: auth.RoleViewer},
+		url.Values{"scope": {"tasks:write"}, "code_challenge": {oidc.CodeChallenge(verifier)}, "code_challenge_method": {"S256"}})
+	if loc.Query().Get("error") != "invalid_scope" {
+		t.Fatalf("Expected invalid_scope, got %s", loc)
+	}
+
+	loc = start(member, url.Values{
+		"state":                 {"xyz"},
+		"scope":                 {"tasks:read"},
+		"code_challenge":        {oidc.CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	})
+	code := loc.Query().Get("code")
+	if loc.Host != "app.example.com" || code == "" || loc.Query().Get("state") != "xyz" {
+		t.Fatalf("Unexpected redirect: %s", loc)
+	}
+
+	exchange := func(code, verifier string) *httptest.ResponseRecorder {
+		return postForm(token, "/oauth/token", "", "", url.Values{
+			"grant_type":    {"authorization_code"},
+			"client_id":     {id},
+			"code":          {code},
+			"redirect_uri":  {"https://app.example.com/callback"},
+			"code_verifier": {verifier},
+		})
+	}
+
+	// Публичный клиент аутентифицируется только client_id в теле
+	w := exchange(code, verifier)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp OAuthTokenResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	claims, err := tm.ParseToken(resp.AccessToken)
+	if err != nil || claims.UserID != 1 || claims.Scope != "tasks:read" || claims.ClientID != id {
+		t.Fatalf("Unexpected token claims: %+v (%v)", claims, err)
+	}
+
+	// Код одноразовый
+	if w := exchange(code, verifier); !strings.Contains(w.Body.String(), "invalid_grant") {
+		t.Fatalf("Expected invalid_grant on reuse, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Неверный code_verifier
+	loc = start(member, url.Values{"code_challenge": {oidc.CodeChallenge(verifier)}, "code_challenge_method": {"S256"}})
+	other, _ := oidc.NewCodeVerifier()
+	if w := exchange(loc.Query().Get("code"), other); !strings.Contains(w.Body.String(), "invalid_grant") {
+		t.Fatalf("Expected invalid_grant for wrong verifier, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// redirect_uri, переданный при авторизации, обязателен при обмене кода
+	loc = start(member, url.Values{
+		"redirect_uri":          {"https://app.example.com/callback"},
+		"code_challenge":        {oidc.CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	})
+	w = postForm(token, "/oauth/token", "", "", url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {id},
+		"code":          {loc.Query().Get("code")},
+		"code_verifier": {verifier},
+	})
+	if !strings.Contains(w.Body.String(), "invalid_grant") {
+		t.Fatalf("Expected invalid_grant without redirect_uri, got %d: %s", w.Code, w.Body.String())
+	}
+	loc = start(member, url.Values{
+		"redirect_uri":          {"https://app.example.com/callback"},
+		"code_challenge":        {oidc.CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	})
+	if w := exchange(loc.Query().Get("code"), verifier); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 with matching redirect_uri, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Токен, выданный клиенту, не может авторизовать другого клиента
+	req := withPrincipal(httptest.NewRequest(http.MethodGet, "/oauth/authorize?client_id="+id, nil),
+		&auth.Principal{UserID: 1, Role: auth.RoleMember, ClientID: id})
+	w = httptest.NewRecorder()
+	authorize(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, got %d", w.Code)
+	}
+}
+
+// TestOAuthIntrospectRevokedSession проверяет, что токен завершённой сессии неактивен
+func TestOAuthIntrospectRevokedSession(t *testing.T) {
+	tm := testTokenManager(t)
+	svc := &services.MockOAuthService{}
+	sessions := &services.MockSessionService{}
+	introspect := OAuthIntrospectHandler(svc, &services.MockTokenService{}, sessions, tm)
+	client := registerOAuthClient(t, svc, CreateOAuthClientRequest{
+		Name:       "gateway",
+		GrantTypes: []string{"client_credentials"},
+		Scopes:     []string{"tasks:read"},
+	})
+
+	sess, _ := sessions.CreateSession(1, "test", "127.0.0.1")
+	token, err := tm.GenerateToken(auth.Principal{UserID: 1, Role: auth.RoleMember, SessionID: sess.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	active := func() bool {
+		w := postForm(introspect, "/oauth/introspect", client.Client.ClientID, client.ClientSecret,
+			url.Values{"token": {token}})
+		var info IntrospectionResponse
+		json.NewDecoder(w.Body).Decode(&info)
+		return info.Active
+	}
+
+	if !active() {
+		t.Fatal("Expected token of active session to be active")
+	}
+	if err := sessions.RevokeSession(1, sess.ID); err != nil {
+		t.Fatal(err)
+	}
+	if active() {
+		t.Error("Expected token of revoked session to be inactive")
+	}
+}
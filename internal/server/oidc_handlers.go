@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/oidc"
+	"github.com/go-portfolio/rest-api/internal/problem"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// oidcStateCookie — cookie, привязывающая state к браузеру, начавшему вход
+const oidcStateCookie = "oidc_state"
+
+// OIDCHandler godoc
+// @Summary      Вход через OpenID Connect
+// @Description  /login перенаправляет на страницу входа провайдера (authorization code + PKCE).
+// @Description  /callback принимает код от провайдера и возвращает JWT и refresh-токен.
+// @Description  При первом входе учётная запись провайдера привязывается к пользователю
+// @Description  с тем же email, если его подтвердили и провайдер, и пользователь; иначе создаётся новый пользователь.
+// @Description  При account.require_verified_email: login пользователю без подтверждённого email вход запрещён (403).
+// @Description  Если у пользователя включена 2FA, вместо токенов возвращается challenge для POST /login/2fa.
+// @Tags         auth
+// @Produce      json
+// @Param        provider  path   string  true   "Имя провайдера из config.yaml"
+// @Param        code      query  string  false  "Код авторизации (callback)"
+// @Param        state     query  string  false  "state (callback)"
+// @Success      200  {object}  LoginResponse  "JWT токен, refresh-токен и данные пользователя"
+// @Success      202  {object}  TwoFactorChallengeResponse  "Включена 2FA: нужен второй шаг POST /login/2fa"
+// @Success      302  {string}  string  "Перенаправление к провайдеру"
+// @Failure      400  {object}  problem.Problem  "Некорректный или просроченный state"
+// @Failure      401  {object}  problem.Problem  "Провайдер отклонил вход или ID-токен недействителен"
+// @Failure      403  {object}  problem.Problem  "Учётная запись удалена или email не подтверждён"
+// @Failure      404  {string}  string  "Провайдер не настроен"
+// @Failure      503  {object}  problem.Problem  "Слишком много незавершённых входов"
+// @Router       /auth/oidc/{provider}/login [get]
+// @Router       /auth/oidc/{provider}/callback [get]
+func OIDCHandler(providers map[string]*oidc.Provider, states *oidc.StateStore,
+	identities services.IdentityService, tokenSvc services.TokenService, tm *auth.TokenManager,
+	twoFactor services.TwoFactorService, sessions *SessionRecorder, requireVerified bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// /auth/oidc/{provider}/{action}
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/auth/oidc/"), "/"), "/")
+		if len(parts) != 2 {
+			http.NotFound(w, r)
+			return
+		}
+		provider, ok := providers[parts[0]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch parts[1] {
+		// -----------------------------
+		// GET /auth/oidc/{provider}/login
+		// -----------------------------
+		case "login":
+			state, req, err := states.Begin(provider.Name())
+			if errors.Is(err, oidc.ErrTooManyStates) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(oidc.DefaultStateTTL.Seconds())))
+				problem.Write(w, r, http.StatusServiceUnavailable, "too many pending logins, try again later")
+				return
+			}
+			if err != nil {
+				problem.Write(w, r, http.StatusInternalServerError, "internal error")
+				return
+			}
+			authURL, err := provider.AuthCodeURL(r.Context(), state, req.Nonce, oidc.CodeChallenge(req.CodeVerifier))
+			if err != nil {
+				log.Printf("oidc %s: %v", provider.Name(), err)
+				problem.Write(w, r, http.StatusBadGateway, "identity provider unavailable")
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     oidcStateCookie,
+				Value:    state,
+				Path:     "/auth/oidc/",
+				MaxAge:   int(oidc.DefaultStateTTL.Seconds()),
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+			http.Redirect(w, r, authURL, http.StatusFound)
+
+		// -----------------------------
+		// GET /auth/oidc/{provider}/callback
+		// -----------------------------
+		case "callback":
+			q := r.URL.Query()
+			// Cookie удаляем в любом случае: state одноразовый
+			http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/auth/oidc/", MaxAge: -1})
+
+			if q.Get("error") != "" {
+				problem.Write(w, r, http.StatusUnauthorized, "login rejected by identity provider")
+				return
+			}
+			// state должен совпадать с cookie — иначе злоумышленник мог бы
+			// подсунуть жертве ссылку со своим кодом (login CSRF)
+			state := q.Get("state")
+			cookie, err := r.Cookie(oidcStateCookie)
+			if state == "" || err != nil || cookie.Value != state {
+				problem.Write(w, r, http.StatusBadRequest, "invalid state")
+				return
+			}
+			req, ok := states.Take(state)
+			if !ok || req.Provider != provider.Name() {
+				problem.Write(w, r, http.StatusBadRequest, "invalid or expired state")
+				return
+			}
+
+			claims, err := provider.Exchange(r.Context(), q.Get("code"), req.CodeVerifier, req.Nonce)
+			if err != nil {
+				log.Printf("oidc %s: %v", provider.Name(), err)
+				problem.Write(w, r, http.StatusUnauthorized, "login failed")
+				return
+			}
+
+			user, err := identities.LoginExternal(services.ExternalIdentity{
+				Provider:      provider.Name(),
+				Subject:       claims.Subject,
+				Email:         claims.Email,
+				EmailVerified: claims.EmailVerified,
+				Username:      claims.PreferredUsername,
+			})
+			if errors.Is(err, services.ErrUserNotFound) {
+				// Учётная запись, привязанная к провайдеру, удалена
+				problem.Write(w, r, http.StatusForbidden, "account deleted")
+				return
+			}
+			if err != nil {
+				problem.Write(w, r, http.StatusInternalServerError, "internal error")
+				return
+			}
+
+			// Та же проверка, что при входе по паролю: пользователь, чей email
+			// провайдер не подтвердил, создаётся без подтверждённого email
+			if requireVerified && user.EmailVerifiedAt == nil {
+				problem.Write(w, r, http.StatusForbidden, "email not verified")
+				return
+			}
+
+			// Вход через провайдера не заменяет второй фактор: иначе привязка
+			// по email позволила бы обойти 2FA существующей учётной записи
+			if requireSecondFactor(w, r, tm, twoFactor, user.ID) {
+				return
+			}
+
+			tokens, err := issueTokens(tokenSvc, tm, sessions, r, user)
+			if err != nil {
+				problem.Write(w, r, http.StatusInternalServerError, "internal error")
+				return
+			}
+			user.Password = ""
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(LoginResponse{
+				Token:        tokens.Token,
+				RefreshToken: tokens.RefreshToken,
+				User:         *user,
+			})
+
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/config"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/oidc"
+	"github.com/go-portfolio/rest-api/internal/oidc/oidctest"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// TestOIDCHandler проходит вход через заглушку провайдера OpenID Connect
+func TestOIDCHandler(t *testing.T) {
+	idp := oidctest.NewIdP("rest-api", "s3cret")
+	defer idp.Close()
+
+	provider, err := oidc.NewProvider(config.OIDCProviderConfig{
+		Name:         "corp",
+		Issuer:       idp.Issuer(),
+		ClientID:     "rest-api",
+		ClientSecret: "s3cret",
+		RedirectURL:  "http://localhost:8080/auth/oidc/corp/callback",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifiedAt := time.Now()
+	users := &services.MockUserService{
+		Users: []models.User{
+			{ID: 1, Username: "alex", Email: "alex@example.com", Password: "password123", Role: "member", EmailVerifiedAt: &verifiedAt},
+			{ID: 2, Username: "maria", Email: "maria@example.com", Password: "secret456", Role: "member"},
+		},
+	}
+	identities := &services.MockIdentityService{Users: users}
+	twoFactor := &services.MockTwoFactorService{}
+	states := oidc.NewStateStore(0)
+	// newHandler создаёт обработчик с общим хранилищем state
+	newHandler := func(requireVerified bool) http.HandlerFunc {
+		return OIDCHandler(map[string]*oidc.Provider{"corp": provider}, states,
+			identities, &services.MockTokenService{}, testTokenManager(t), twoFactor, nil, requireVerified)
+	}
+	handler := newHandler(false)
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	// startLogin начинает вход и возвращает URL callback с кодом и cookie со state
+	startLogin := func(t *testing.T) (*url.URL, *http.Cookie) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/corp/login", nil))
+		if w.Code != http.StatusFound {
+			t.Fatalf("Expected status 302, got %d: %s", w.Code, w.Body.String())
+		}
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != oidcStateCookie || !cookies[0].HttpOnly {
+			t.Fatalf("Expected HttpOnly state cookie, got %+v", cookies)
+		}
+
+		resp, err := noRedirect.Get(w.Header().Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		callback, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return callback, cookies[0]
+	}
+
+	// finishLogin вызывает callback с cookie
+	finishLogin := func(callback *url.URL, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	login := func(t *testing.T, user oidctest.User) LoginResponse {
+		t.Helper()
+		idp.User = user
+		callback, cookie := startLogin(t)
+		w := finishLogin(callback, cookie)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp LoginResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		if resp.Token == "" || resp.RefreshToken == "" {
+			t.Fatalf("Expected tokens, got %+v", resp)
+		}
+		return resp
+	}
+
+	// -----------------------------
+	// Привязка по подтверждённому email
+	// -----------------------------
+	t.Run("link by verified email", func(t *testing.T) {
+		resp := login(t, oidctest.User{Subject: "sso-1", Email: "alex@example.com", EmailVerified: true, PreferredUsername: "a.petrov"})
+		if resp.User.ID != 1 {
+			t.Errorf("Expected existing user 1, got %+v", resp.User)
+		}
+		// Повторный вход находит пользователя по subject, даже если email изменился
+		resp = login(t, oidctest.User{Subject: "sso-1", Email: "new@example.com", EmailVerified: true})
+		if resp.User.ID != 1 {
+			t.Errorf("Expected user 1 by subject, got %+v", resp.User)
+		}
+	})
+
+	// -----------------------------
+	// Неподтверждённый email не привязывается — создаётся новый пользователь
+	// -----------------------------
+	t.Run("unverified email provisions new user", func(t *testing.T) {
+		resp := login(t, oidctest.User{Subject: "sso-2", Email: "alex@example.com", EmailVerified: false, PreferredUsername: "alex"})
+		if resp.User.ID == 1 || resp.User.Username != "alex2" || resp.User.Email != "" {
+			t.Errorf("Expected new user alex2 without email, got %+v", resp.User)
+		}
+	})
+
+	// -----------------------------
+	// Неподтверждённая у нас учётная запись не привязывается: иначе чужой адрес,
+	// зарегистрированный с паролем злоумышленника, перешёл бы к нему вместе с входом владельца
+	// -----------------------------
+	t.Run("unverified local user is not linked", func(t *testing.T) {
+		resp := login(t, oidctest.User{Subject: "sso-6", Email: "maria@example.com", EmailVerified: true})
+		if resp.User.ID == 2 || resp.User.Email != "" {
+			t.Errorf("Expected new user without email, got %+v", resp.User)
+		}
+		if users.Users[1].EmailVerifiedAt != nil {
+			t.Error("Linking must not verify the local email")
+		}
+	})
+
+	// Email уникален с учётом регистра, поэтому сравнивается точно
+	t.Run("email match is exact", func(t *testing.T) {
+		resp := login(t, oidctest.User{Subject: "sso-7", Email: "ALEX@example.com", EmailVerified: true})
+		if resp.User.ID == 1 {
+			t.Errorf("Expected a new user for a differently cased email, got %+v", resp.User)
+		}
+	})
+
+	t.Run("new user with verified email", func(t *testing.T) {
+		resp := login(t, oidctest.User{Subject: "sso-3", Email: "Maria.Ivanova@example.com", EmailVerified: true})
+		if resp.User.Username != "maria.ivanova" || resp.User.EmailVerifiedAt == nil || resp.User.Password != "" {
+			t.Errorf("Unexpected provisioned user: %+v", resp.User)
+		}
+	})
+
+	// -----------------------------
+	// account.require_verified_email: login — без подтверждённого email токены не выдаются
+	// -----------------------------
+	t.Run("require verified email", func(t *testing.T) {
+		strict := newHandler(true)
+		finish := func(user oidctest.User) *httptest.ResponseRecorder {
+			idp.User = user
+			callback, cookie := startLogin(t)
+			req := httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+			req.AddCookie(cookie)
+			w := httptest.NewRecorder()
+			strict(w, req)
+			return w
+		}
+
+		w := finish(oidctest.User{Subject: "sso-8", Email: "new@example.org", EmailVerified: false})
+		if p := decodeProblem(t, w); w.Code != http.StatusForbidden || p.Detail != "email not verified" {
+			t.Errorf("Expected 403 problem, got %d %+v", w.Code, p)
+		}
+		if w := finish(oidctest.User{Subject: "sso-1", Email: "alex@example.com", EmailVerified: true}); w.Code != http.StatusOK {
+			t.Errorf("Verified user: expected status 200, got %d", w.Code)
+		}
+	})
+
+	// -----------------------------
+	// Пользователь с 2FA получает challenge, а не токены
+	// -----------------------------
+	t.Run("two factor required", func(t *testing.T) {
+		twoFactor.Secrets = map[int]*services.MockTOTP{1: {Confirmed: true}}
+		defer func() { twoFactor.Secrets = nil }()
+
+		idp.User = oidctest.User{Subject: "sso-1", Email: "alex@example.com", EmailVerified: true}
+		callback, cookie := startLogin(t)
+		w := finishLogin(callback, cookie)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+		}
+		var challenge TwoFactorChallengeResponse
+		json.NewDecoder(w.Body).Decode(&challenge)
+		if !challenge.TwoFactorRequired || challenge.ChallengeToken == "" {
+			t.Errorf("Unexpected challenge response: %+v", challenge)
+		}
+	})
+
+	// -----------------------------
+	// Ошибки state
+	// -----------------------------
+	t.Run("missing state cookie", func(t *testing.T) {
+		idp.User = oidctest.User{Subject: "sso-4"}
+		callback, _ := startLogin(t)
+		w := finishLogin(callback, nil)
+		if p := decodeProblem(t, w); w.Code != http.StatusBadRequest || p.Detail != "invalid state" {
+			t.Errorf("Expected 400 problem without cookie, got %d %+v", w.Code, p)
+		}
+	})
+
+	t.Run("state reused", func(t *testing.T) {
+		idp.User = oidctest.User{Subject: "sso-5"}
+		callback, cookie := startLogin(t)
+		if w := finishLogin(callback, cookie); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if w := finishLogin(callback, cookie); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for reused state, got %d", w.Code)
+		}
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/other/login", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
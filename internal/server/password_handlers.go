@@ -0,0 +1,242 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/mail"
+	"github.com/go-portfolio/rest-api/internal/problem"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// ForgotPasswordHandler godoc
+// @Summary      Запрос сброса пароля
+// @Description  Отправляет на email ссылку для сброса пароля. Ответ не зависит от того, зарегистрирован ли email.
+// @Description  Запросы ограничиваются по email и по IP так же, как попытки входа.
+// @Tags         auth
+// @Accept       json
+// @Param        request  body  ForgotPasswordRequest  true  "Email учётной записи"
+// @Success      202  {string}  string  "Если email зарегистрирован, письмо отправлено"
+// @Failure      400  {object}  map[string]string  "Некорректный JSON или ошибки валидации"
+// @Failure      429  {object}  problem.Problem  "Слишком много запросов, см. Retry-After"
+// @Router       /password/forgot [post]
+func ForgotPasswordHandler(userSvc services.UserService, resetSvc services.PasswordResetService,
+	mailer mail.Mailer, publicURL string, limiter *auth.LoginLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req ForgotPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if err := authValidate.Struct(req); err != nil {
+			writeValidationErrors(w, err)
+			return
+		}
+
+		// Каждый запрос учитывается как неудачная попытка: письма на один адрес
+		// и запросы с одного IP получают растущую задержку, а затем блокировку
+		key := mailLimitKey("password-reset", req.Email)
+		ip := limiter.ClientIP(r)
+		if wait := limiter.Check(key, ip); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			problem.Write(w, r, http.StatusTooManyRequests, "too many password reset requests")
+			return
+		}
+		limiter.Failure(key, ip)
+
+		// Чтобы ни по ответу, ни по времени ответа нельзя было перебирать
+		// зарегистрированные адреса, клиент сразу получает 202, а поиск
+		// пользователя и отправка письма выполняются в фоне; ошибки только логируются
+		email := req.Email
+		sendInBackground(func() {
+			if err := sendResetLink(userSvc, resetSvc, mailer, publicURL, email); err != nil {
+				log.Printf("password reset: %v", err)
+			}
+		})
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// sendInBackground выполняет f после ответа клиенту; тесты подменяют его синхронным вызовом
+var sendInBackground = func(f func()) { go f() }
+
+// mailLimitKey — ключ ограничителя для писем на email (сброс пароля, подтверждение).
+// Префикс purpose отделяет его от имён пользователей, которые ограничитель видит при входе.
+func mailLimitKey(purpose, email string) string {
+	return purpose + ":" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// sendResetLink выдаёт токен сброса и отправляет письмо, если email зарегистрирован
+func sendResetLink(userSvc services.UserService, resetSvc services.PasswordResetService,
+	mailer mail.Mailer, publicURL, email string) error {
+	user, err := userSvc.FindUserByEmail(email)
+	if errors.Is(err, services.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("find user: %w", err)
+	}
+	token, err := resetSvc.CreateResetToken(user.ID)
+	if err != nil {
+		return fmt.Errorf("create token: %w", err)
+	}
+	if err := mailer.Send(resetMessage(user.Email, user.Username, token, publicURL)); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
+
+// ResetPasswordHandler godoc
+// @Summary      Сброс пароля
+// @Description  Устанавливает новый пароль по токену из письма. Токен одноразовый; все сессии и refresh-токены пользователя отзываются.
+// @Description  Пароль проверяется политикой паролей (password.policy).
+// @Tags         auth
+// @Accept       json
+// @Param        request  body  ResetPasswordRequest  true  "Токен и новый пароль"
+// @Success      204  {string}  string  "Пароль изменён"
+// @Failure      400  {object}  map[string]string  "Некорректный JSON, ошибки валидации или недействительный токен"
+// @Router       /password/reset [post]
+func ResetPasswordHandler(resetSvc services.PasswordResetService, userSvc services.UserService,
+	hasher *auth.PasswordHasher, policy *auth.PasswordPolicy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req ResetPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if err := authValidate.Struct(req); err != nil {
+			writeValidationErrors(w, err)
+			return
+		}
+
+		// Политика запрещает логин в пароле, поэтому сначала находим владельца токена
+		userID, err := resetSvc.ResetTokenUser(req.Token)
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			http.Error(w, "invalid or expired token", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		user, err := userSvc.GetUserByID(userID)
+		if errors.Is(err, services.ErrUserNotFound) {
+			http.Error(w, "invalid or expired token", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		errs := map[string]string{}
+		checkPasswordPolicy(errs, policy, "Password", req.Password, user.Username)
+		if len(errs) > 0 {
+			writeFieldErrors(w, errs)
+			return
+		}
+
+		hash, err := hasher.Hash(req.Password)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		if _, err := resetSvc.ResetPassword(req.Token, hash); err != nil {
+			if errors.Is(err, services.ErrInvalidResetToken) {
+				http.Error(w, "invalid or expired token", http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// resetMessage формирует письмо со ссылкой для сброса пароля
+func resetMessage(to, username, token, publicURL string) mail.Message {
+	link := strings.TrimRight(publicURL, "/") + "/password/reset?token=" + url.QueryEscape(token)
+	return mail.Message{
+		To:      to,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hello, %s!\n\n"+
+			"To set a new password, open the link below or send the token to POST /password/reset:\n\n"+
+			"%s\n\nToken: %s\n\n"+
+			"If you did not request a password reset, ignore this email.\n",
+			username, link, token),
+	}
+}
+
+// writeValidationErrors отвечает 400 с картой поле → нарушенное правило
+func writeValidationErrors(w http.ResponseWriter, err error) {
+	writeFieldErrors(w, fieldErrors(err))
+}
+
+// fieldErrors переводит ошибки валидатора в карту поле → нарушенное правило
+func fieldErrors(err error) map[string]string {
+	errors := make(map[string]string)
+	if err == nil {
+		return errors
+	}
+	for _, e := range err.(validator.ValidationErrors) {
+		errors[e.Field()] = e.Tag()
+	}
+	return errors
+}
+
+// writeFieldErrors отвечает 400 с картой поле → нарушенное правило
+func writeFieldErrors(w http.ResponseWriter, errors map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(errors)
+}
+
+// checkPasswordPolicy добавляет в errs нарушение политики паролей для поля field.
+// Пустой пароль и поле, уже не прошедшее валидацию, не проверяются.
+func checkPasswordPolicy(errs map[string]string, policy *auth.PasswordPolicy, field, password, username string) {
+	if password == "" || errs[field] != "" {
+		return
+	}
+	var violation *auth.PolicyViolation
+	if err := policy.Check(password, username); errors.As(err, &violation) {
+		errs[field] = violation.Rule
+	}
+}
+
+// ForgotPasswordRequest модель запроса сброса пароля для Swagger
+// swagger:model ForgotPasswordRequest
+type ForgotPasswordRequest struct {
+	// Email учётной записи
+	// example: user@example.com
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest модель установки нового пароля для Swagger
+// swagger:model ResetPasswordRequest
+type ResetPasswordRequest struct {
+	// Токен из письма
+	Token string `json:"token" validate:"required"`
+	// Новый пароль
+	// example: newpass123
+	// Требования задаются политикой паролей (password.policy)
+	Password string `json:"password" validate:"required"`
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/config"
+	"github.com/go-portfolio/rest-api/internal/mail"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// postJSON отправляет POST с JSON-телом в обработчик
+func postJSON(handler http.HandlerFunc, path string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+// syncSend выполняет фоновую отправку писем синхронно до конца теста
+func syncSend(t *testing.T) {
+	prev := sendInBackground
+	sendInBackground = func(f func()) { f() }
+	t.Cleanup(func() { sendInBackground = prev })
+}
+
+// TestPasswordReset проверяет полный цикл: запрос письма, сброс, повторное использование токена
+func TestPasswordReset(t *testing.T) {
+	syncSend(t)
+	userSvc := &services.MockUserService{
+		Users: []models.User{
+			{ID: 1, Username: "alex", Email: "alex@example.com", Password: "password123"},
+		},
+	}
+	resetSvc := &services.MockPasswordResetService{Users: userSvc}
+	mailer := &mail.MemoryMailer{}
+	forgot := ForgotPasswordHandler(userSvc, resetSvc, mailer, "https://api.example.com/", nil)
+	reset := ResetPasswordHandler(resetSvc, userSvc, nil, nil)
+
+	// -----------------------------
+	// Неизвестный email: тот же ответ, письмо не отправляется
+	// -----------------------------
+	t.Run("unknown email", func(t *testing.T) {
+		w := postJSON(forgot, "/password/forgot", ForgotPasswordRequest{Email: "nobody@example.com"})
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", w.Code)
+		}
+		if len(mailer.Messages) != 0 {
+			t.Errorf("Expected no mail, got %d", len(mailer.Messages))
+		}
+	})
+
+	t.Run("invalid email", func(t *testing.T) {
+		w := postJSON(forgot, "/password/forgot", ForgotPasswordRequest{Email: "not-an-email"})
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	// -----------------------------
+	// Успешный сброс по токену из письма
+	// -----------------------------
+	t.Run("reset", func(t *testing.T) {
+		w := postJSON(forgot, "/password/forgot", ForgotPasswordRequest{Email: "alex@example.com"})
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", w.Code)
+		}
+		msg, ok := mailer.Last("alex@example.com")
+		if !ok {
+			t.Fatal("Expected reset email")
+		}
+		if !strings.Contains(msg.Body, "https://api.example.com/password/reset?token=") {
+			t.Errorf("Expected reset link in body: %s", msg.Body)
+		}
+		token := tokenFromMail(t, msg)
+
+		// Слишком короткий пароль не проходит валидацию, токен при этом не тратится
+		if w := postJSON(reset, "/password/reset", ResetPasswordRequest{Token: token, Password: "123"}); w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status 400 for short password, got %d", w.Code)
+		}
+
+		w = postJSON(reset, "/password/reset", ResetPasswordRequest{Token: token, Password: "newpass123"})
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+		}
+		if stored := userSvc.Users[0].Password; !strings.HasPrefix(stored, "$argon2id$") {
+			t.Errorf("Expected new password to be stored as argon2id hash, got %q", stored)
+		}
+		if _, err := userSvc.Authenticate("alex", "newpass123"); err != nil {
+			t.Errorf("Expected login with new password: %v", err)
+		}
+
+		// Токен одноразовый
+		w = postJSON(reset, "/password/reset", ResetPasswordRequest{Token: token, Password: "another123"})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for reused token, got %d", w.Code)
+		}
+	})
+
+	// -----------------------------
+	// Новый запрос аннулирует предыдущий токен
+	// -----------------------------
+	t.Run("previous token invalidated", func(t *testing.T) {
+		postJSON(forgot, "/password/forgot", ForgotPasswordRequest{Email: "alex@example.com"})
+		first, _ := mailer.Last("alex@example.com")
+		postJSON(forgot, "/password/forgot", ForgotPasswordRequest{Email: "alex@example.com"})
+
+		w := postJSON(reset, "/password/reset", ResetPasswordRequest{Token: tokenFromMail(t, first), Password: "newpass456"})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for superseded token, got %d", w.Code)
+		}
+	})
+}
+
+// tokenFromMail извлекает токен из строки "Token: ..." письма
+func tokenFromMail(t *testing.T, msg mail.Message) string {
+	t.Helper()
+	for _, line := range strings.Split(msg.Body, "\n") {
+		if token, ok := strings.CutPrefix(line, "Token: "); ok {
+			return token
+		}
+	}
+	t.Fatalf("No token in mail: %s", msg.Body)
+	return ""
+}
+
+// TestForgotPassword_Background проверяет, что письмо отправляется после ответа клиенту
+func TestForgotPassword_Background(t *testing.T) {
+	var queued []func()
+	prev := sendInBackground
+	sendInBackground = func(f func()) { queued = append(queued, f) }
+	t.Cleanup(func() { sendInBackground = prev })
+
+	userSvc := &services.MockUserService{
+		Users: []models.User{{ID: 1, Username: "alex", Email: "alex@example.com", Password: "password123"}},
+	}
+	resetSvc := &services.MockPasswordResetService{Users: userSvc}
+	mailer := &mail.MemoryMailer{}
+	forgot := ForgotPasswordHandler(userSvc, resetSvc, mailer, "https://api.example.com/", nil)
+
+	// Известный и неизвестный email обрабатываются одинаково: ответ без обращения к сервисам
+	for _, email := range []string{"alex@example.com", "nobody@example.com"} {
+		if w := postJSON(forgot, "/password/forgot", ForgotPasswordRequest{Email: email}); w.Code != http.StatusAccepted {
+			t.Fatalf("%s: expected status 202, got %d", email, w.Code)
+		}
+	}
+	if len(mailer.Messages) != 0 || len(queued) != 2 {
+		t.Fatalf("Expected mail to be queued, got %d sent, %d queued", len(mailer.Messages), len(queued))
+	}
+	for _, f := range queued {
+		f()
+	}
+	if _, ok := mailer.Last("alex@example.com"); !ok || len(mailer.Messages) != 1 {
+		t.Errorf("Expected one reset email after background send, got %d", len(mailer.Messages))
+	}
+}
+
+// TestForgotPassword_RateLimit проверяет ограничение запросов по email и по IP
+func TestForgotPassword_RateLimit(t *testing.T) {
+	syncSend(t)
+	userSvc := &services.MockUserService{
+		Users: []models.User{{ID: 1, Username: "alex", Email: "alex@example.com", Password: "password123"}},
+	}
+	mailer := &mail.MemoryMailer{}
+	limiter := auth.NewLoginLimiter(config.LoginLimitConfig{
+		MaxFailuresPerUser: 3,
+		MaxFailuresPerIP:   3,
+		BaseDelay:          time.Minute,
+		Lockout:            time.Hour,
+	})
+	forgot := ForgotPasswordHandler(userSvc, &services.MockPasswordResetService{Users: userSvc}, mailer, "", limiter)
+
+	if w := postJSON(forgot, "/password/forgot", ForgotPasswordRequest{Email: "alex@example.com"}); w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", w.Code)
+	}
+	// Повторный запрос на тот же адрес — только после задержки, регистр не важен
+	w := postJSON(forgot, "/password/forgot", ForgotPasswordRequest{Email: "ALEX@example.com"})
+	if p := decodeProblem(t, w); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("Expected 429 with Retry-After 60, got %d %q %+v", w.Code, w.Header().Get("Retry-After"), p)
+	}
+	if len(mailer.Messages) != 1 {
+		t.Errorf("Expected a single reset email, got %d", len(mailer.Messages))
+	}
+
+	// С одного IP нельзя перебирать адреса без ограничения
+	for i, email := range []string{"a@example.com", "b@example.com"} {
+		if w := postJSON(forgot, "/password/forgot", ForgotPasswordRequest{Email: email}); w.Code != http.StatusAccepted {
+			t.Fatalf("request %d: expected status 202, got %d", i, w.Code)
+		}
+	}
+	if w := postJSON(forgot, "/password/forgot", ForgotPasswordRequest{Email: "c@example.com"}); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected IP to be blocked, got %d", w.Code)
+	}
+}
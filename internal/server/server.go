@@ -2,60 +2,103 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 
 	"net/http"
 	"strconv"
 	"strings"
 
-	"github.com/go-playground/validator/v10"
 	_ "github.com/go-portfolio/rest-api/docs" // docs генерируется swag
 	"github.com/go-portfolio/rest-api/internal/auth"
 	"github.com/go-portfolio/rest-api/internal/config"
+	"github.com/go-portfolio/rest-api/internal/jsonpatch"
+	"github.com/go-portfolio/rest-api/internal/mail"
 	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/oidc"
+	"github.com/go-portfolio/rest-api/internal/problem"
 	"github.com/go-portfolio/rest-api/internal/services"
-	httpSwagger "github.com/swaggo/http-swagger"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 var requestCount = prometheus.NewCounterVec(
-    prometheus.CounterOpts{
-        Name: "http_requests_total",
-        Help: "Total number of HTTP requests",
-    },
-    []string{"path", "method"},
+	prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests",
+	},
+	[]string{"path", "method"},
 )
 
 func init() {
-    prometheus.MustRegister(requestCount)
+	prometheus.MustRegister(requestCount)
 }
 
-var taskValidate = validator.New()
+var taskValidate = problem.NewValidator()
 
 // TasksHandler godoc
 // @Summary      Управление задачами
-// @Description  Получение, создание, обновление и удаление задач
+// @Description  Получение, создание, обновление и удаление задач.
+// @Description  PATCH /tasks/{id} принимает application/merge-patch+json (RFC 7396)
+// @Description  или application/json-patch+json (RFC 6902); менять можно только title и status.
+// @Description  Ошибки возвращаются в формате application/problem+json (RFC 7807).
 // @Tags         tasks
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/merge-patch+json,application/json-patch+json
+// @Produce      json,application/problem+json
 // @Param        id      path      int          false  "ID задачи"  example(1)
+// @Param        If-None-Match      header  string  false  "ETag ранее полученной задачи"
+// @Param        If-Modified-Since  header  string  false  "Время Last-Modified ранее полученной задачи"
+// @Param        If-Match           header  string  false  "ETag задачи для PUT, PATCH и DELETE (обязателен при tasks.require_if_match)"
+// @Param        limit   query     int          false  "Размер страницы (по умолчанию 50, максимум 500)"
+// @Param        offset  query     int          false  "Смещение от начала списка"
+// @Param        cursor  query     string       false  "Курсор следующей страницы из заголовка Link"
+// @Param        status          query  []string  false  "Фильтр по статусу (можно несколько)"  collectionFormat(multi)
+// @Param        user_id         query  int       false  "Фильтр по ID пользователя"
+// @Param        created_after   query  string    false  "Созданы после (RFC3339)"
+// @Param        created_before  query  string    false  "Созданы до (RFC3339)"
+// @Param        updated_since   query  string    false  "Обновлены начиная с (RFC3339)"
+// @Param        sort            query  string    false  "Сортировка, например created_at,-title"
 // @Param        task    body      models.Task  false  "Данные задачи"
 // @Success      200     {array}   models.Task        "Список задач или обновленная задача"
+// @Header       200     {integer} X-Total-Count      "Общее количество задач"
+// @Header       200     {string}  Link               "Ссылка на следующую страницу (rel=next)"
+// @Header       200     {string}  ETag               "Версия задачи (GET, PUT и PATCH /tasks/{id})"
+// @Header       200     {string}  Last-Modified      "Время последнего изменения задачи (GET /tasks/{id})"
 // @Success      201     {object}  models.Task        "Созданная задача"
 // @Success      204     {string}  string             "Задача удалена"
-// @Failure      400     {string}  string             "Некорректный запрос"
-// @Failure      401     {string}  string             "Неавторизован"
-// @Failure      404     {string}  string             "Задача не найдена"
-// @Failure      500     {string}  string             "Внутренняя ошибка сервера"
+// @Success      304     {string}  string             "Задача не изменилась (If-None-Match / If-Modified-Since)"
+// @Failure      400     {object}  problem.Problem    "Некорректный запрос"
+// @Failure      401     {object}  problem.Problem    "Неавторизован"
+// @Failure      403     {object}  problem.Problem    "Недостаточно прав (роль viewer не может изменять задачи)"
+// @Failure      404     {object}  problem.Problem    "Задача не найдена"
+// @Failure      409     {object}  problem.Problem    "JSON Patch нельзя применить (нет пути, не прошла операция test)"
+// @Failure      412     {object}  problem.Problem    "If-Match не совпадает с ETag: задачу изменили"
+// @Failure      415     {object}  problem.Problem    "PATCH с неподдерживаемым Content-Type (см. заголовок Accept-Patch)"
+// @Failure      428     {object}  problem.Problem    "Нет заголовка If-Match"
+// @Failure      500     {object}  problem.Problem    "Внутренняя ошибка сервера"
 // @Router       /tasks [get]
+// @Router       /tasks/{id} [get]
 // @Router       /tasks [post]
 // @Router       /tasks/{id} [put]
+// @Router       /tasks/{id} [patch]
 // @Router       /tasks/{id} [delete]
-func TasksHandler(svc services.TaskService) http.HandlerFunc {
+func TasksHandler(svc services.TaskService, requireIfMatch bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Считаем количество запросов к /tasks:
 		requestCount.WithLabelValues(r.URL.Path, r.Method).Inc()
 
+		// Все операции выполняются от имени пользователя из JWT
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			problem.Write(w, r, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		// Обычные пользователи видят только свои задачи, администраторы — все
+		owner := principal.UserID
+		if principal.Can(auth.PermTasksManage) {
+			owner = services.AnyOwner
+		}
+
 		// Если URL содержит ID задачи (например, /tasks/1), извлекаем его
 		pathParts := strings.Split(r.URL.Path, "/")
 		var taskID int
@@ -63,7 +106,7 @@ func TasksHandler(svc services.TaskService) http.HandlerFunc {
 		if len(pathParts) == 3 && pathParts[2] != "" {
 			taskID, err = strconv.Atoi(pathParts[2])
 			if err != nil {
-				http.Error(w, "invalid task id", http.StatusBadRequest)
+				problem.Write(w, r, http.StatusBadRequest, "invalid task ID")
 				return
 			}
 		}
@@ -72,29 +115,48 @@ func TasksHandler(svc services.TaskService) http.HandlerFunc {
 		switch r.Method {
 
 		// -----------------------------
-		// Обработка GET /tasks
+		// Обработка GET /tasks и GET /tasks/{id}
 		// -----------------------------
 		case http.MethodGet:
-			// простая"валидация" query-параметров
-			limitStr := r.URL.Query().Get("limit")
-			if limitStr != "" {
-				limit, err := strconv.Atoi(limitStr)
-				if err != nil || limit < 1 {
-					http.Error(w, "invalid limit", http.StatusBadRequest)
+			// GET /tasks/{id} — одна задача с валидаторами для условных запросов
+			if taskID != 0 {
+				if taskID < 0 {
+					problem.Write(w, r, http.StatusBadRequest, "invalid task ID")
 					return
 				}
-				// используем limit
+				task, err := svc.GetTask(taskID, owner)
+				if err != nil {
+					writeTaskError(w, r, err)
+					return
+				}
+				setTaskValidators(w, task)
+				if notModified(r, taskETag(task), taskLastModified(task)) {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(task)
+				return
 			}
 
-			// Получаем список задач через сервис
-			tasks, err := svc.GetTasks()
+			// Разбираем параметры пагинации: limit, offset, cursor
+			query, err := parseTaskQuery(r.URL.Query())
 			if err != nil {
-				// Если произошла ошибка — возвращаем 500 Internal Server Error
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				problem.Write(w, r, http.StatusBadRequest, err.Error())
 				return
 			}
+
+			// Получаем страницу задач через сервис
+			page, err := svc.GetTasks(owner, query)
+			if err != nil {
+				// Некорректный курсор — 400, ошибки базы — 500 без подробностей
+				writeServiceError(w, r, err)
+				return
+			}
+			// Общее количество и ссылку на следующую страницу отдаём в заголовках
+			setPaginationHeaders(w, r, query, page)
 			// Кодируем список задач в JSON и отправляем в ответ
-			json.NewEncoder(w).Encode(tasks)
+			json.NewEncoder(w).Encode(page.Items)
 
 		// -----------------------------
 		// Обработка POST /tasks
@@ -103,29 +165,30 @@ func TasksHandler(svc services.TaskService) http.HandlerFunc {
 			var t models.Task
 
 			if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
-				http.Error(w, "Invalid JSON", http.StatusBadRequest)
+				writeDecodeError(w, r, err, "invalid JSON")
 				return
 			}
+			// Владелец задачи — текущий пользователь; user_id из тела
+			// учитывается только у администраторов
+			if t.UserID == 0 || owner != services.AnyOwner {
+				t.UserID = principal.UserID
+			}
 
 			if err := taskValidate.Struct(t); err != nil {
-				errors := make(map[string]string)
-				for _, e := range err.(validator.ValidationErrors) {
-					errors[e.Field()] = e.Tag()
-				}
-				w.WriteHeader(http.StatusBadRequest)
-				json.NewEncoder(w).Encode(errors)
+				problem.Validation(problem.FieldErrors(err)).Write(w, r)
 				return
 			}
 
 			// Создаём новую задачу через сервис, получаем её ID
 			id, err := svc.CreateTask(t.UserID, t.Title, t.Status)
 			if err != nil {
-				// Если ошибка при создании в сервисе — возвращаем 500
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				// Несуществующий владелец — 400, ошибки базы — 500 без подробностей
+				writeServiceError(w, r, err)
 				return
 			}
-			// Устанавливаем ID созданной задачи
+			// Устанавливаем ID созданной задачи; новая задача имеет версию 1
 			t.ID = id
+			t.Version = 1
 			// Отправляем созданную задачу обратно клиенту в формате JSON
 			json.NewEncoder(w).Encode(t)
 
@@ -136,32 +199,94 @@ func TasksHandler(svc services.TaskService) http.HandlerFunc {
 			idStr := strings.TrimPrefix(r.URL.Path, "/tasks/")
 			id, err := strconv.Atoi(idStr)
 			if err != nil || id <= 0 {
-				http.Error(w, "invalid task ID", http.StatusBadRequest)
+				problem.Write(w, r, http.StatusBadRequest, "invalid task ID")
 				return
 			}
 
 			// 2. Декодируем тело запроса
 			var t models.Task
 			if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
-				http.Error(w, "Invalid JSON", http.StatusBadRequest)
+				writeDecodeError(w, r, err, "invalid JSON")
 				return
 			}
+			// Сменить владельца через PUT нельзя; user_id из тела не используется
+			t.UserID = principal.UserID
 
 			// 3. Валидируем JSON
 			if err := taskValidate.Struct(t); err != nil {
-				errors := make(map[string]string)
-				for _, e := range err.(validator.ValidationErrors) {
-					errors[e.Field()] = e.Tag()
-				}
-				w.WriteHeader(http.StatusBadRequest)
-				json.NewEncoder(w).Encode(errors)
+				problem.Validation(problem.FieldErrors(err)).Write(w, r)
+				return
+			}
+
+			// 4. Проверяем If-Match и записываем, только если задача не изменилась
+			current := loadForUpdate(w, r, svc, taskID, owner, requireIfMatch)
+			if current == nil {
+				return
+			}
+			updated, err := svc.UpdateTask(taskID, owner, services.TaskChanges{
+				Title:   &t.Title,
+				Status:  &t.Status,
+				Version: current.Version,
+			})
+			if err != nil {
+				writeTaskError(w, r, err)
+				return
+			}
+			setTaskValidators(w, updated)
+			json.NewEncoder(w).Encode(updated)
+
+		// -----------------------------
+		// PATCH /tasks/{id}
+		// -----------------------------
+		case http.MethodPatch:
+			if taskID <= 0 {
+				problem.Write(w, r, http.StatusBadRequest, "invalid task ID")
+				return
+			}
+			current := loadForUpdate(w, r, svc, taskID, owner, requireIfMatch)
+			if current == nil {
+				return
+			}
+
+			// Применяем merge patch или JSON Patch к текущему состоянию задачи
+			r.Body = http.MaxBytesReader(w, r.Body, maxPatchSize)
+			t, err := applyTaskPatch(r, current)
+			var tooLarge *http.MaxBytesError
+			switch {
+			case errors.As(err, &tooLarge):
+				problem.Write(w, r, http.StatusRequestEntityTooLarge, "patch document is too large")
+				return
+			case errors.Is(err, errUnsupportedPatch):
+				w.Header().Set("Accept-Patch", acceptPatch)
+				problem.Write(w, r, http.StatusUnsupportedMediaType, "unsupported patch media type")
+				return
+			case errors.Is(err, jsonpatch.ErrNotApplicable):
+				problem.Write(w, r, http.StatusConflict, err.Error())
+				return
+			case errors.Is(err, jsonpatch.ErrInvalidPatch):
+				writeDecodeError(w, r, err, err.Error())
+				return
+			case err != nil:
+				writeServiceError(w, r, err)
 				return
 			}
-			updated, err := svc.UpdateTask(taskID, t.UserID, t.Title, t.Status)
+
+			// Результат проверяется по тем же правилам, что и при PUT
+			errs := readonlyTaskFields(current, t)
+			errs = append(errs, problem.FieldErrors(taskValidate.Struct(t))...)
+			if len(errs) > 0 {
+				problem.Validation(errs).Write(w, r)
+				return
+			}
+
+			// Записываем только изменившиеся колонки
+			updated, err := svc.UpdateTask(taskID, owner, taskChanges(current, t))
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeTaskError(w, r, err)
 				return
 			}
+			setTaskValidators(w, updated)
+			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(updated)
 
 		// -----------------------------
@@ -172,11 +297,15 @@ func TasksHandler(svc services.TaskService) http.HandlerFunc {
 			idStr := strings.TrimPrefix(r.URL.Path, "/tasks/")
 			id, err := strconv.Atoi(idStr)
 			if err != nil || id <= 0 {
-				http.Error(w, "invalid task ID", http.StatusBadRequest)
+				problem.Write(w, r, http.StatusBadRequest, "invalid task ID")
 				return
 			}
-			if err := svc.DeleteTask(taskID); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+			current := loadForUpdate(w, r, svc, taskID, owner, requireIfMatch)
+			if current == nil {
+				return
+			}
+			if err := svc.DeleteTask(taskID, owner, current.Version); err != nil {
+				writeTaskError(w, r, err)
 				return
 			}
 			w.WriteHeader(http.StatusNoContent)
@@ -186,26 +315,116 @@ func TasksHandler(svc services.TaskService) http.HandlerFunc {
 		// -----------------------------
 		default:
 			// Возвращаем 405 Method Not Allowed для остальных методов
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			problem.Write(w, r, http.StatusMethodNotAllowed, "method not allowed")
 		}
 	}
 }
 
+// Deps — сервисы и компоненты, от которых зависят обработчики
+type Deps struct {
+	Tasks          services.TaskService
+	Users          services.UserService
+	Tokens         services.TokenService
+	APIKeys        services.APIKeyService
+	PasswordResets services.PasswordResetService
+	Verifications  services.EmailVerificationService
+	TwoFactor      services.TwoFactorService
+	Identities     services.IdentityService
+	Sessions       services.SessionService
+	OAuth          services.OAuthService
+	Impersonations services.ImpersonationAuditService // журнал действий администраторов от имени пользователей
+	OIDCProviders  map[string]*oidc.Provider          // провайдеры OpenID Connect по имени
+	Mailer         mail.Mailer
+	TokenManager   *auth.TokenManager   // выпуск и проверка JWT с ключами из cfg.Jwt
+	Passwords      *auth.PasswordHasher // хэширование паролей по cfg.Password
+	PasswordPolicy *auth.PasswordPolicy // требования к новым паролям по cfg.Password.Policy
+}
+
 // StartServer запускает HTTP-сервер на порту 8080
-// svc — интерфейс TaskService, чтобы обработчики могли работать с задачами
-func StartServer(svc services.TaskService, userSvc services.UserService, cfg *config.Config) {
+func StartServer(d Deps, cfg *config.Config) {
+	svc, userSvc, tokenSvc, apiKeySvc, tm := d.Tasks, d.Users, d.Tokens, d.APIKeys, d.TokenManager
 	// Создаём новый HTTP-мультиплексор (router)
 	mux := http.NewServeMux()
+	limiter := auth.NewLoginLimiter(cfg.Login)
+	// Изменяющие запросы администратора от имени пользователя записываются в журнал
+	audit := &ImpersonationAudit{Events: d.Impersonations, Limiter: limiter}
+	// Проверка JWT с учётом отозванных токенов; API-ключи принимаются наравне с JWT
+	verify := auth.VerifyToken(tm,
+		auth.WithDenylist(tokenSvc),
+		auth.WithSessions(d.Sessions),
+		auth.WithClients(d.OAuth),
+		auth.WithAPIKeys(services.APIKeyPrefix, apiKeyPrincipal(apiKeySvc)),
+	)
+	requireAuth := func(next http.Handler) http.Handler {
+		return verify(audit.Middleware(next))
+	}
+
 	// Public endpoints
-	mux.HandleFunc("/login", LoginHandler(userSvc, cfg.Jwt.JwtSecretKey))
-	// Регистрируем маршрут /tasks и привязываем к нему handler
-	mux.Handle("/tasks", auth.VerifyToken(cfg.Jwt.JwtSecretKey)(TasksHandler(svc)))
-	mux.Handle("/tasks/", auth.VerifyToken(cfg.Jwt.JwtSecretKey)(TasksHandler(svc)))
+	// В режиме login токены (вход, регистрация, refresh) выдаются только с подтверждённым email
+	requireVerified := cfg.Account.RequireVerifiedEmail == config.RequireVerifiedForLogin
+	// Каждый вход открывает сессию, видимую в GET /me/sessions
+	sessions := &SessionRecorder{Sessions: d.Sessions, Limiter: limiter}
+	mux.HandleFunc("/login", LoginHandler(userSvc, tokenSvc, tm, LoginOptions{
+		Limiter:              limiter,
+		RequireVerifiedEmail: requireVerified,
+		TwoFactor:            d.TwoFactor,
+		Sessions:             sessions,
+	}))
+	mux.HandleFunc("/login/2fa", TwoFactorLoginHandler(userSvc, tokenSvc, tm, d.TwoFactor, limiter, sessions))
+	mux.HandleFunc("/register", RegisterHandler(userSvc, tokenSvc, tm, d.Verifications, d.Mailer, cfg.Account.PublicURL, sessions, d.Passwords, d.PasswordPolicy, requireVerified))
+	mux.HandleFunc("/token/refresh", RefreshHandler(userSvc, tokenSvc, tm, requireVerified))
+	// Запросы писем (сброс пароля, подтверждение email) ограничиваются отдельно
+	// от входа, чтобы не расходовать попытки входа
+	mailLimiter := auth.NewLoginLimiter(cfg.Login)
+	mux.HandleFunc("/verify-email", VerifyEmailHandler(d.Verifications))
+	mux.HandleFunc("/verify-email/resend", ResendVerificationHandler(userSvc, d.Verifications, d.Mailer, cfg.Account.PublicURL, mailLimiter))
+	mux.HandleFunc("/password/forgot", ForgotPasswordHandler(userSvc, d.PasswordResets, d.Mailer, cfg.Account.PublicURL, mailLimiter))
+	mux.HandleFunc("/password/reset", ResetPasswordHandler(d.PasswordResets, userSvc, d.Passwords, d.PasswordPolicy))
+	mux.HandleFunc("/.well-known/jwks.json", JWKSHandler(tm))
+	mux.HandleFunc("/auth/oidc/", OIDCHandler(d.OIDCProviders, oidc.NewStateStore(0), d.Identities, tokenSvc, tm, d.TwoFactor, sessions, requireVerified))
+	// Сервер авторизации OAuth 2.0
+	oauthClients := requireAuth(auth.RequirePermission(auth.PermUsersManage)(OAuthClientsHandler(d.OAuth)))
+	mux.Handle("/oauth/clients", oauthClients)
+	mux.Handle("/oauth/clients/", oauthClients)
+	mux.Handle("/oauth/authorize", requireAuth(OAuthAuthorizeHandler(d.OAuth)))
+	mux.HandleFunc("/oauth/token", OAuthTokenHandler(d.OAuth, userSvc, tm))
+	mux.HandleFunc("/oauth/introspect", OAuthIntrospectHandler(d.OAuth, tokenSvc, d.Sessions, tm))
+	mux.HandleFunc("/oauth/revoke", OAuthRevokeHandler(d.OAuth, tokenSvc, tm))
+	mux.Handle("/logout", requireAuth(LogoutHandler(tokenSvc, d.Sessions)))
+	mux.Handle("/me/sessions", requireAuth(SessionsHandler(d.Sessions)))
+	mux.Handle("/me/sessions/", requireAuth(SessionsHandler(d.Sessions)))
+	mux.Handle("/me", requireAuth(MeHandler(userSvc, tokenSvc, d.Passwords, d.PasswordPolicy, limiter)))
+	mux.Handle("/me/password", requireAuth(MeHandler(userSvc, tokenSvc, d.Passwords, d.PasswordPolicy, limiter)))
+	mux.Handle("/users", requireAuth(auth.RequirePermission(auth.PermUsersManage)(UsersHandler(userSvc))))
+	mux.Handle("/users/", requireAuth(auth.RequirePermission(auth.PermUsersManage)(ImpersonateHandler(userSvc, tm, audit))))
+	mux.Handle("/me/api-keys", requireAuth(APIKeysHandler(apiKeySvc)))
+	mux.Handle("/me/api-keys/", requireAuth(APIKeysHandler(apiKeySvc)))
+	mux.Handle("/me/2fa", requireAuth(TwoFactorHandler(userSvc, d.TwoFactor, totpIssuer(cfg))))
+	mux.Handle("/me/2fa/", requireAuth(TwoFactorHandler(userSvc, d.TwoFactor, totpIssuer(cfg))))
+	// Регистрируем маршрут /tasks и привязываем к нему handler:
+	// чтение требует tasks:read, изменения — tasks:write
+	var tasks http.Handler = TasksHandler(svc, cfg.Tasks.RequireIfMatch)
+	if cfg.Account.RequireVerifiedEmail == config.RequireVerifiedForTasks {
+		// Создание задач — только с подтверждённым email
+		tasks = RequireVerifiedEmail(userSvc)(tasks)
+	}
+	tasks = requireAuth(auth.RequireAccess(auth.PermTasksRead, auth.PermTasksWrite)(tasks))
+	mux.Handle("/tasks", tasks)
+	mux.Handle("/tasks/", tasks)
 	mux.Handle("/swagger/", httpSwagger.WrapHandler)
 	// Новый роут для метрик
-    mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/metrics", promhttp.Handler())
 
 	// Запускаем HTTP-сервер на порту 8080
 	// В реальном приложении можно добавить логирование и graceful shutdown
-	http.ListenAndServe(":8080", mux)
+	// Каждому запросу присваивается X-Request-ID, он попадает в ответы об ошибках
+	http.ListenAndServe(":8080", problem.RequestID(mux))
+}
+
+// totpIssuer — название сервиса в приложении-аутентификаторе
+func totpIssuer(cfg *config.Config) string {
+	if cfg.Jwt.Issuer != "" {
+		return cfg.Jwt.Issuer
+	}
+	return "rest-api"
 }
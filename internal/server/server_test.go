@@ -3,14 +3,45 @@ package server
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-portfolio/rest-api/internal/auth"
 	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/problem"
 	"github.com/go-portfolio/rest-api/internal/services"
 )
 
+// withUser добавляет в запрос пользователя, как это делает middleware auth.VerifyToken
+func withUser(req *http.Request, userID int) *http.Request {
+	return withPrincipal(req, &auth.Principal{UserID: userID})
+}
+
+// withPrincipal добавляет в запрос пользователя с произвольной ролью
+func withPrincipal(req *http.Request, p *auth.Principal) *http.Request {
+	return req.WithContext(auth.WithPrincipal(req.Context(), p))
+}
+
+// decodeProblem проверяет, что ответ — application/problem+json, и декодирует его
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) problem.Problem {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+		t.Fatalf("Expected Content-Type %s, got %q: %s", problem.ContentType, ct, w.Body.String())
+	}
+	var p problem.Problem
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != w.Code {
+		t.Errorf("Problem status %d does not match response %d", p.Status, w.Code)
+	}
+	return p
+}
+
 // TestTasksHandler тестирует обработчик /tasks с использованием мок-сервиса
 func TestTasksHandler(t *testing.T) {
 	// Создаём мок-сервис, который реализует интерфейс TaskService
@@ -23,11 +54,12 @@ func TestTasksHandler(t *testing.T) {
 	t.Run("GET /tasks", func(t *testing.T) {
 		// Создаём HTTP-запрос GET на маршрут /tasks
 		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req = withUser(req, 1)
 		// httptest.NewRecorder() — объект, который "ловит" ответ сервера для проверки
 		w := httptest.NewRecorder()
 
 		// Получаем handler для нашего мок-сервиса
-		handler := TasksHandler(mockSvc)
+		handler := TasksHandler(mockSvc, false)
 		// Вызываем handler как реальный HTTP-запрос
 		handler(w, req)
 
@@ -63,11 +95,12 @@ func TestTasksHandler(t *testing.T) {
 
 		// Создаём HTTP-запрос POST на /tasks с телом body
 		req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewReader(body))
+		req = withUser(req, 1)
 		// httptest.NewRecorder() — объект для записи ответа
 		w := httptest.NewRecorder()
 
 		// Получаем handler для мок-сервиса
-		handler := TasksHandler(mockSvc)
+		handler := TasksHandler(mockSvc, false)
 		// Вызываем handler
 		handler(w, req)
 
@@ -91,4 +124,465 @@ func TestTasksHandler(t *testing.T) {
 			t.Errorf("Unexpected created task: %+v", created)
 		}
 	})
+
+	// -----------------------------
+	// Тестируем пагинацию GET /tasks
+	// -----------------------------
+	t.Run("GET /tasks pagination", func(t *testing.T) {
+		pagedSvc := &services.MockTaskService{
+			Page: &services.TaskPage{
+				Items:      []models.Task{{ID: 5, Title: "Paged", Status: "todo"}},
+				NextCursor: "abc",
+				Total:      12,
+			},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/tasks?limit=1&offset=4", nil)
+		req = withUser(req, 1)
+		w := httptest.NewRecorder()
+		TasksHandler(pagedSvc, false)(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		// Параметры должны дойти до сервиса
+		if pagedSvc.LastQuery.Limit != 1 || pagedSvc.LastQuery.Offset != 4 {
+			t.Errorf("Unexpected query passed to service: %+v", pagedSvc.LastQuery)
+		}
+
+		if got := resp.Header.Get("X-Total-Count"); got != "12" {
+			t.Errorf("Expected X-Total-Count 12, got %q", got)
+		}
+		// Ссылка на следующую страницу использует курсор вместо offset
+		if got := resp.Header.Get("Link"); got != `</tasks?cursor=abc&limit=1>; rel="next"` {
+			t.Errorf("Unexpected Link header: %q", got)
+		}
+	})
+
+	// -----------------------------
+	// Тестируем некорректные параметры пагинации
+	// -----------------------------
+	t.Run("GET /tasks invalid pagination", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "limit=abc", "offset=-1", "cursor=abc&offset=2"} {
+			req := withUser(httptest.NewRequest(http.MethodGet, "/tasks?"+query, nil), 1)
+			w := httptest.NewRecorder()
+			TasksHandler(mockSvc, false)(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", query, w.Code)
+			}
+		}
+	})
+
+	// -----------------------------
+	// Тестируем фильтрацию и сортировку GET /tasks
+	// -----------------------------
+	t.Run("GET /tasks filters and sort", func(t *testing.T) {
+		filterSvc := &services.MockTaskService{}
+
+		url := "/tasks?status=todo&status=in_progress,done&user_id=3" +
+			"&created_after=2025-01-01T00:00:00Z&updated_since=2025-02-01T00:00:00Z&sort=created_at,-title"
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req = withUser(req, 1)
+		w := httptest.NewRecorder()
+		TasksHandler(filterSvc, false)(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		q := filterSvc.LastQuery
+		if len(q.Statuses) != 3 || q.Statuses[0] != "todo" || q.Statuses[2] != "done" {
+			t.Errorf("Unexpected statuses: %v", q.Statuses)
+		}
+		if q.UserID != 3 {
+			t.Errorf("Expected user_id 3, got %d", q.UserID)
+		}
+		if q.CreatedAfter == nil || q.CreatedAfter.Year() != 2025 || q.CreatedBefore != nil || q.UpdatedSince == nil {
+			t.Errorf("Unexpected date filters: %+v", q)
+		}
+		expectedSort := []services.TaskSort{{Column: "created_at"}, {Column: "title", Desc: true}}
+		if len(q.Sort) != 2 || q.Sort[0] != expectedSort[0] || q.Sort[1] != expectedSort[1] {
+			t.Errorf("Unexpected sort: %+v", q.Sort)
+		}
+	})
+
+	// -----------------------------
+	// Тестируем некорректные фильтры и сортировку
+	// -----------------------------
+	t.Run("GET /tasks invalid filters", func(t *testing.T) {
+		for _, query := range []string{"sort=password", "sort=title%3BDROP", "user_id=x", "created_before=yesterday", "sort=title&cursor=abc"} {
+			req := withUser(httptest.NewRequest(http.MethodGet, "/tasks?"+query, nil), 1)
+			w := httptest.NewRecorder()
+			TasksHandler(mockSvc, false)(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", query, w.Code)
+			}
+		}
+	})
+
+	// -----------------------------
+	// Задача создаётся от имени пользователя из токена
+	// -----------------------------
+	t.Run("POST /tasks ignores body user_id", func(t *testing.T) {
+		body, _ := json.Marshal(models.Task{UserID: 99, Title: "Mine", Status: "todo"})
+		req := withUser(httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewReader(body)), 5)
+		w := httptest.NewRecorder()
+		TasksHandler(mockSvc, false)(w, req)
+
+		var created models.Task
+		if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+			t.Fatal(err)
+		}
+		if created.UserID != 5 {
+			t.Errorf("Expected owner 5, got %d", created.UserID)
+		}
+	})
+
+	// -----------------------------
+	// Чужие задачи недоступны
+	// -----------------------------
+	t.Run("foreign task returns 404", func(t *testing.T) {
+		ownedSvc := &services.MockTaskService{
+			Tasks: []models.Task{{ID: 3, Title: "Alien", Status: "todo", UserID: 2}},
+		}
+
+		body, _ := json.Marshal(models.Task{Title: "Hijack", Status: "done"})
+		req := withUser(httptest.NewRequest(http.MethodPut, "/tasks/3", bytes.NewReader(body)), 1)
+		w := httptest.NewRecorder()
+		TasksHandler(ownedSvc, false)(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("PUT: expected status 404, got %d", w.Code)
+		}
+
+		req = withUser(httptest.NewRequest(http.MethodDelete, "/tasks/3", nil), 1)
+		w = httptest.NewRecorder()
+		TasksHandler(ownedSvc, false)(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("DELETE: expected status 404, got %d", w.Code)
+		}
+		if len(ownedSvc.Tasks) != 1 {
+			t.Errorf("foreign task must not be deleted")
+		}
+	})
+
+	// -----------------------------
+	// Тестируем GET /tasks/{id}
+	// -----------------------------
+	t.Run("GET /tasks/{id}", func(t *testing.T) {
+		created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+		updated := created.Add(90 * time.Minute)
+		deleted := updated.Add(time.Hour)
+		singleSvc := &services.MockTaskService{
+			Tasks: []models.Task{
+				{ID: 7, Title: "Mine", Status: "todo", UserID: 1, CreatedAt: created, UpdatedAt: updated},
+				{ID: 8, Title: "Alien", Status: "todo", UserID: 2, CreatedAt: created, UpdatedAt: created},
+				{ID: 9, Title: "Gone", Status: "done", UserID: 1, CreatedAt: created, UpdatedAt: updated, DeletedAt: &deleted},
+			},
+		}
+		get := func(path string, header http.Header) *httptest.ResponseRecorder {
+			req := withUser(httptest.NewRequest(http.MethodGet, path, nil), 1)
+			for k, v := range header {
+				req.Header[k] = v
+			}
+			w := httptest.NewRecorder()
+			TasksHandler(singleSvc, false)(w, req)
+			return w
+		}
+
+		w := get("/tasks/7", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var task models.Task
+		if err := json.NewDecoder(w.Body).Decode(&task); err != nil {
+			t.Fatal(err)
+		}
+		if task.ID != 7 || task.UserID != 1 || !task.CreatedAt.Equal(created) || !task.UpdatedAt.Equal(updated) {
+			t.Errorf("Unexpected task: %+v", task)
+		}
+		etag := w.Header().Get("ETag")
+		if !strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, "W/") {
+			t.Errorf("Expected strong ETag, got %q", etag)
+		}
+		if got := w.Header().Get("Last-Modified"); got != updated.Format(http.TimeFormat) {
+			t.Errorf("Unexpected Last-Modified: %q", got)
+		}
+
+		// Условные запросы
+		if w := get("/tasks/7", http.Header{"If-None-Match": {`"other", ` + etag}}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("If-None-Match: expected 304, got %d", w.Code)
+		}
+		if w := get("/tasks/7", http.Header{"If-None-Match": {`"other"`}}); w.Code != http.StatusOK {
+			t.Errorf("If-None-Match mismatch: expected 200, got %d", w.Code)
+		}
+		if w := get("/tasks/7", http.Header{"If-Modified-Since": {updated.Format(http.TimeFormat)}}); w.Code != http.StatusNotModified {
+			t.Errorf("If-Modified-Since: expected 304, got %d", w.Code)
+		}
+		if w := get("/tasks/7", http.Header{"If-Modified-Since": {created.Format(http.TimeFormat)}}); w.Code != http.StatusOK {
+			t.Errorf("If-Modified-Since before update: expected 200, got %d", w.Code)
+		}
+
+		// Чужие, удалённые и несуществующие задачи — 404
+		for _, path := range []string{"/tasks/8", "/tasks/9", "/tasks/100"} {
+			if w := get(path, nil); w.Code != http.StatusNotFound {
+				t.Errorf("%s: expected status 404, got %d", path, w.Code)
+			}
+		}
+		for _, path := range []string{"/tasks/abc", "/tasks/-1"} {
+			if w := get(path, nil); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", path, w.Code)
+			}
+		}
+	})
+
+	// -----------------------------
+	// Тестируем PATCH /tasks/{id}
+	// -----------------------------
+	t.Run("PATCH /tasks/{id}", func(t *testing.T) {
+		created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+		patchSvc := &services.MockTaskService{
+			Tasks: []models.Task{
+				{ID: 7, Title: "Mine", Status: "todo", UserID: 1, CreatedAt: created, UpdatedAt: created},
+				{ID: 8, Title: "Alien", Status: "todo", UserID: 2, CreatedAt: created, UpdatedAt: created},
+			},
+		}
+		patch := func(path, contentType, body string) *httptest.ResponseRecorder {
+			req := withUser(httptest.NewRequest(http.MethodPatch, path, strings.NewReader(body)), 1)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			TasksHandler(patchSvc, false)(w, req)
+			return w
+		}
+
+		// Merge patch меняет только статус
+		w := patch("/tasks/7", "application/merge-patch+json", `{"status":"done"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("merge patch: expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var task models.Task
+		json.NewDecoder(w.Body).Decode(&task)
+		if task.Status != "done" || task.Title != "Mine" {
+			t.Errorf("Unexpected task: %+v", task)
+		}
+		if c := patchSvc.LastChanges; c.Status == nil || *c.Status != "done" || c.Title != nil {
+			t.Errorf("Only status must be persisted, got %+v", c)
+		}
+
+		// JSON Patch с операцией test
+		w = patch("/tasks/7", "application/json-patch+json",
+			`[{"op":"test","path":"/status","value":"done"},{"op":"replace","path":"/title","value":"Renamed"}]`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("json patch: expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if c := patchSvc.LastChanges; c.Title == nil || *c.Title != "Renamed" || c.Status != nil {
+			t.Errorf("Only title must be persisted, got %+v", c)
+		}
+		if w := patch("/tasks/7", "application/json-patch+json", `[{"op":"test","path":"/status","value":"todo"}]`); w.Code != http.StatusConflict {
+			t.Errorf("failed test op: expected status 409, got %d", w.Code)
+		}
+
+		// Результат проверяется по правилам models.Task
+		for body, field := range map[string]string{
+			`{"status":"unknown"}`: "status",
+			`{"title":null}`:       "title",
+			`{"user_id":2}`:        "user_id",
+			`{"id":99}`:            "id",
+			`{"extra":1}`:          "extra",
+			`{"title":5}`:          "title",
+		} {
+			w := patch("/tasks/7", "application/merge-patch+json", body)
+			p := decodeProblem(t, w)
+			if w.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != field {
+				t.Errorf("%s: expected 400 with %s error, got %d %+v", body, field, w.Code, p.Errors)
+			}
+		}
+		for _, body := range []string{`{`} {
+			if w := patch("/tasks/7", "application/merge-patch+json", body); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", body, w.Code)
+			}
+		}
+		if patchSvc.Tasks[0].Title != "Renamed" || patchSvc.Tasks[0].Status != "done" {
+			t.Errorf("invalid patches must not be persisted: %+v", patchSvc.Tasks[0])
+		}
+
+		w = patch("/tasks/7", "application/json", `{"status":"todo"}`)
+		if w.Code != http.StatusUnsupportedMediaType || w.Header().Get("Accept-Patch") == "" {
+			t.Errorf("Expected 415 with Accept-Patch, got %d %q", w.Code, w.Header().Get("Accept-Patch"))
+		}
+		if w := patch("/tasks/8", "application/merge-patch+json", `{"status":"done"}`); w.Code != http.StatusNotFound {
+			t.Errorf("foreign task: expected status 404, got %d", w.Code)
+		}
+		huge := `{"title":"` + strings.Repeat("a", maxPatchSize) + `"}`
+		if w := patch("/tasks/7", "application/merge-patch+json", huge); w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("oversized patch: expected status 413, got %d", w.Code)
+		}
+	})
+
+	// -----------------------------
+	// Оптимистичная блокировка: If-Match
+	// -----------------------------
+	t.Run("If-Match preconditions", func(t *testing.T) {
+		versionSvc := &services.MockTaskService{
+			Tasks: []models.Task{{ID: 7, Title: "Mine", Status: "todo", UserID: 1, Version: 3}},
+		}
+		handler := TasksHandler(versionSvc, true)
+		call := func(method, ifMatch, contentType, body string) *httptest.ResponseRecorder {
+			req := withUser(httptest.NewRequest(method, "/tasks/7", strings.NewReader(body)), 1)
+			if ifMatch != "" {
+				req.Header.Set("If-Match", ifMatch)
+			}
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			handler(w, req)
+			return w
+		}
+		put := `{"title":"Mine","status":"done"}`
+
+		// ETag задачи — её версия
+		w := call(http.MethodGet, "", "", "")
+		if etag := w.Header().Get("ETag"); etag != `"3"` {
+			t.Fatalf("Expected ETag \"3\", got %q", etag)
+		}
+
+		// Без If-Match изменения запрещены
+		for _, method := range []string{http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			if w := call(method, "", "application/merge-patch+json", put); w.Code != http.StatusPreconditionRequired {
+				t.Errorf("%s without If-Match: expected status 428, got %d", method, w.Code)
+			}
+		}
+
+		// Устаревший или слабый ETag — 412, задача не меняется
+		for _, etag := range []string{`"2"`, `W/"3"`} {
+			if w := call(http.MethodPut, etag, "application/json", put); w.Code != http.StatusPreconditionFailed {
+				t.Errorf("If-Match %s: expected status 412, got %d", etag, w.Code)
+			}
+		}
+		if versionSvc.Tasks[0].Status != "todo" {
+			t.Fatalf("task must not change on failed precondition: %+v", versionSvc.Tasks[0])
+		}
+
+		// Актуальный ETag — изменение проходит, версия и ETag растут
+		w = call(http.MethodPut, `"1", "3"`, "application/json", put)
+		if w.Code != http.StatusOK || w.Header().Get("ETag") != `"4"` {
+			t.Fatalf("PUT: expected 200 with ETag \"4\", got %d %q", w.Code, w.Header().Get("ETag"))
+		}
+		w = call(http.MethodPatch, `"4"`, "application/merge-patch+json", `{"title":"Renamed"}`)
+		if w.Code != http.StatusOK || w.Header().Get("ETag") != `"5"` {
+			t.Fatalf("PATCH: expected 200 with ETag \"5\", got %d %q", w.Code, w.Header().Get("ETag"))
+		}
+		if w := call(http.MethodDelete, `"4"`, "", ""); w.Code != http.StatusPreconditionFailed {
+			t.Errorf("DELETE with stale ETag: expected status 412, got %d", w.Code)
+		}
+		if w := call(http.MethodDelete, "*", "", ""); w.Code != http.StatusNoContent {
+			t.Errorf("DELETE with If-Match *: expected status 204, got %d", w.Code)
+		}
+	})
+
+	// -----------------------------
+	// Ошибки сервиса переводятся в статусы без подробностей из базы
+	// -----------------------------
+	t.Run("service errors", func(t *testing.T) {
+		errSvc := &services.MockTaskService{}
+		call := func(method, path, body string) *httptest.ResponseRecorder {
+			req := withUser(httptest.NewRequest(method, path, strings.NewReader(body)), 1)
+			w := httptest.NewRecorder()
+			TasksHandler(errSvc, false)(w, req)
+			return w
+		}
+
+		// Удаление и изменение несуществующей задачи — 404, а не 204
+		if w := call(http.MethodDelete, "/tasks/99", ""); w.Code != http.StatusNotFound {
+			t.Errorf("DELETE missing task: expected status 404, got %d", w.Code)
+		}
+		if w := call(http.MethodPut, "/tasks/99", `{"title":"X","status":"todo"}`); w.Code != http.StatusNotFound {
+			t.Errorf("PUT missing task: expected status 404, got %d", w.Code)
+		}
+
+		errSvc.Err = services.ErrTaskOwnerNotFound
+		if w := call(http.MethodPost, "/tasks", `{"title":"X","status":"todo"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("unknown owner: expected status 400, got %d", w.Code)
+		}
+
+		errSvc.Err = errors.New(`pq: relation "tasks" does not exist`)
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodDelete} {
+			path := "/tasks"
+			if method == http.MethodDelete {
+				path = "/tasks/1"
+			}
+			w := call(method, path, `{"title":"X","status":"todo"}`)
+			if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "pq:") {
+				t.Errorf("%s: expected 500 without database details, got %d %q", method, w.Code, w.Body.String())
+			}
+		}
+	})
+
+	// -----------------------------
+	// Ошибки валидации — problem+json с JSON-именами полей
+	// -----------------------------
+	t.Run("POST /tasks validation problem", func(t *testing.T) {
+		req := withUser(httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title":"","status":"later"}`)), 1)
+		w := httptest.NewRecorder()
+		problem.RequestID(TasksHandler(mockSvc, false)).ServeHTTP(w, req)
+
+		p := decodeProblem(t, w)
+		if w.Code != http.StatusBadRequest || p.Type != problem.TypeValidation || p.Instance != "/tasks" || p.RequestID == "" {
+			t.Fatalf("Unexpected problem: %d %+v", w.Code, p)
+		}
+		if len(p.Errors) != 2 || p.Errors[0].Field != "status" || p.Errors[0].Code != "oneof" ||
+			p.Errors[1].Field != "title" || p.Errors[1].Message != "is required" {
+			t.Errorf("Unexpected field errors: %+v", p.Errors)
+		}
+	})
+
+	// -----------------------------
+	// Без пользователя в контексте — 401
+	// -----------------------------
+	t.Run("missing principal", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		w := httptest.NewRecorder()
+		TasksHandler(mockSvc, false)(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+
+	// -----------------------------
+	// Администратор управляет чужими задачами
+	// -----------------------------
+	t.Run("admin manages other users' tasks", func(t *testing.T) {
+		admin := &auth.Principal{UserID: 1, Role: auth.RoleAdmin}
+		adminSvc := &services.MockTaskService{
+			Tasks: []models.Task{{ID: 3, Title: "Alien", Status: "todo", UserID: 2}},
+		}
+
+		req := withPrincipal(httptest.NewRequest(http.MethodGet, "/tasks?user_id=2", nil), admin)
+		w := httptest.NewRecorder()
+		TasksHandler(adminSvc, false)(w, req)
+		if adminSvc.LastOwner != services.AnyOwner || adminSvc.LastQuery.UserID != 2 {
+			t.Errorf("admin list must not be scoped to own tasks: owner=%d query=%+v", adminSvc.LastOwner, adminSvc.LastQuery)
+		}
+
+		body, _ := json.Marshal(models.Task{UserID: 2, Title: "Assigned", Status: "todo"})
+		req = withPrincipal(httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewReader(body)), admin)
+		w = httptest.NewRecorder()
+		TasksHandler(adminSvc, false)(w, req)
+		var created models.Task
+		json.NewDecoder(w.Body).Decode(&created)
+		if created.UserID != 2 {
+			t.Errorf("admin must be able to create a task for another user, got owner %d", created.UserID)
+		}
+
+		req = withPrincipal(httptest.NewRequest(http.MethodDelete, "/tasks/3", nil), admin)
+		w = httptest.NewRecorder()
+		TasksHandler(adminSvc, false)(w, req)
+		if w.Code != http.StatusNoContent || len(adminSvc.Tasks) != 0 {
+			t.Errorf("admin DELETE: expected 204, got %d", w.Code)
+		}
+	})
 }
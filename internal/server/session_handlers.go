@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// SessionRecorder открывает сессию входа при выдаче токенов.
+// nil отключает сессии: токены выдаются без claim sid.
+type SessionRecorder struct {
+	Sessions services.SessionService
+	Limiter  *auth.LoginLimiter // определяет IP клиента с учётом trust_forwarded_for
+}
+
+// start сохраняет сессию для запроса r и возвращает её ID (0, если сессии отключены)
+func (s *SessionRecorder) start(r *http.Request, userID int) (int, error) {
+	if s == nil || s.Sessions == nil {
+		return 0, nil
+	}
+	sess, err := s.Sessions.CreateSession(userID, r.UserAgent(), s.Limiter.ClientIP(r))
+	if err != nil {
+		return 0, err
+	}
+	return sess.ID, nil
+}
+
+// SessionsHandler godoc
+// @Summary      Сессии пользователя
+// @Description  Список устройств, с которых выполнен вход, и завершение сессии.
+// @Description  Завершённая сессия отзывает свои refresh-токены, а её access-токены
+// @Description  перестают приниматься сразу, не дожидаясь истечения.
+// @Tags         sessions
+// @Produce      json
+// @Param        id  path  int  false  "ID сессии"
+// @Success      200  {array}   models.Session  "Активные сессии"
+// @Success      204  {string}  string          "Сессия завершена"
+// @Failure      400  {string}  string          "Некорректный ID"
+// @Failure      401  {string}  string          "Неавторизован"
+// @Failure      403  {string}  string          "Сессиями нельзя управлять с делегированными учётными данными"
+// @Failure      404  {string}  string          "Сессия не найдена"
+// @Security     BearerAuth
+// @Router       /me/sessions [get]
+// @Router       /me/sessions/{id} [delete]
+func SessionsHandler(sessions services.SessionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if principal.Delegated() {
+			http.Error(w, "sessions cannot be managed with delegated credentials", http.StatusForbidden)
+			return
+		}
+
+		idStr := strings.Trim(strings.TrimPrefix(r.URL.Path, "/me/sessions"), "/")
+
+		switch {
+		// -----------------------------
+		// GET /me/sessions
+		// -----------------------------
+		case r.Method == http.MethodGet && idStr == "":
+			list, err := sessions.ListSessions(principal.UserID)
+			if err != nil {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			for i := range list {
+				list[i].Current = list[i].ID == principal.SessionID
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(list)
+
+		// -----------------------------
+		// DELETE /me/sessions/{id}
+		// -----------------------------
+		case r.Method == http.MethodDelete && idStr != "":
+			id, err := strconv.Atoi(idStr)
+			if err != nil || id <= 0 {
+				http.Error(w, "invalid session ID", http.StatusBadRequest)
+				return
+			}
+			if err := sessions.RevokeSession(principal.UserID, id); err != nil {
+				if errors.Is(err, services.ErrSessionNotFound) {
+					http.Error(w, "session not found", http.StatusNotFound)
+					return
+				}
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
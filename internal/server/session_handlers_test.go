@@ -0,0 +1,98 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// TestSessions проверяет сохранение сессий при входе, их список и завершение с другого устройства
+func TestSessions(t *testing.T) {
+	tm := testTokenManager(t)
+	tokenSvc := &services.MockTokenService{}
+	sessionSvc := &services.MockSessionService{Tokens: tokenSvc}
+	login := LoginHandler(testUsers(), tokenSvc, tm, LoginOptions{
+		Sessions: &SessionRecorder{Sessions: sessionSvc},
+	})
+	requireAuth := auth.VerifyToken(tm, auth.WithDenylist(tokenSvc), auth.WithSessions(sessionSvc))
+	handler := requireAuth(SessionsHandler(sessionSvc))
+
+	// loginFrom выполняет вход пользователя alex с устройства userAgent
+	loginFrom := func(userAgent string) LoginResponse {
+		t.Helper()
+		body, _ := json.Marshal(LoginRequest{Username: "alex", Password: "password123"})
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		req.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		login(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Login: expected status 200, got %d", w.Code)
+		}
+		var resp LoginResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp
+	}
+	call := func(token, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	laptop := loginFrom("Firefox")
+	phone := loginFrom("Mobile Safari")
+
+	w := call(phone.Token, http.MethodGet, "/me/sessions")
+	var list []models.Session
+	json.NewDecoder(w.Body).Decode(&list)
+	if w.Code != http.StatusOK || len(list) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d: %+v", w.Code, list)
+	}
+	var laptopID int
+	for _, s := range list {
+		if s.UserAgent == "Firefox" {
+			laptopID = s.ID
+		}
+		if s.Current != (s.UserAgent == "Mobile Safari") {
+			t.Errorf("Unexpected current flag: %+v", s)
+		}
+		if s.IP == "" {
+			t.Errorf("Expected client IP to be stored: %+v", s)
+		}
+	}
+
+	// Обновлённый access-токен остаётся в той же сессии
+	rw := refresh(tm, tokenSvc, laptop.RefreshToken)
+	var refreshed TokenResponse
+	json.NewDecoder(rw.Body).Decode(&refreshed)
+	if claims, err := tm.ParseToken(refreshed.Token); err != nil || claims.SessionID != laptopID {
+		t.Fatalf("Expected refreshed token in session %d, got %+v (%v)", laptopID, claims, err)
+	}
+
+	// Завершаем сессию ноутбука с телефона
+	if w := call(phone.Token, http.MethodDelete, fmt.Sprintf("/me/sessions/%d", laptopID)); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if w := call(refreshed.Token, http.MethodGet, "/me/sessions"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected token of revoked session to be rejected, got %d", w.Code)
+	}
+	if w := refresh(tm, tokenSvc, refreshed.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected refresh token of revoked session to be rejected, got %d", w.Code)
+	}
+	if w := call(phone.Token, http.MethodGet, "/me/sessions"); w.Code != http.StatusOK {
+		t.Errorf("Other session must stay active, got %d", w.Code)
+	}
+
+	// Чужую или уже завершённую сессию завершить нельзя
+	if w := call(phone.Token, http.MethodDelete, fmt.Sprintf("/me/sessions/%d", laptopID)); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
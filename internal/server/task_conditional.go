@@ -0,0 +1,103 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/problem"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// taskETag возвращает сильный ETag задачи — её версию.
+// Версия увеличивается при каждом изменении, поэтому ETag меняется вместе с задачей.
+func taskETag(t *models.Task) string {
+	return `"` + strconv.Itoa(t.Version) + `"`
+}
+
+// taskLastModified возвращает время последнего изменения задачи
+func taskLastModified(t *models.Task) time.Time {
+	if t.UpdatedAt.After(t.CreatedAt) {
+		return t.UpdatedAt
+	}
+	return t.CreatedAt
+}
+
+// setTaskValidators выставляет заголовки ETag и Last-Modified для задачи
+func setTaskValidators(w http.ResponseWriter, t *models.Task) {
+	w.Header().Set("ETag", taskETag(t))
+	if modified := taskLastModified(t); !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// notModified проверяет условный GET: If-None-Match, а при его отсутствии —
+// If-Modified-Since (RFC 9110, разделы 13.1.2 и 13.1.3)
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagListMatches(inm, etag, true)
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modified.IsZero() {
+		return false
+	}
+	// Last-Modified передаётся с точностью до секунды
+	return !modified.Truncate(time.Second).After(ims)
+}
+
+// etagListMatches сравнивает etag со списком из заголовка If-None-Match или If-Match.
+// weak = true — слабое сравнение (префикс W/ игнорируется), как требует If-None-Match.
+func etagListMatches(header, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+			etag = strings.TrimPrefix(etag, "W/")
+		} else if strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// loadForUpdate читает задачу перед PUT, PATCH или DELETE и проверяет предусловие
+// If-Match (RFC 9110, раздел 13.1.1) со строгим сравнением ETag. Без заголовка
+// при requireIfMatch отвечает 428, при несовпадении ETag — 412.
+// При ошибке ответ уже записан и возвращается nil.
+func loadForUpdate(w http.ResponseWriter, r *http.Request, svc services.TaskService, id, owner int, requireIfMatch bool) *models.Task {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" && requireIfMatch {
+		problem.Write(w, r, http.StatusPreconditionRequired, "If-Match header is required")
+		return nil
+	}
+	current, err := svc.GetTask(id, owner)
+	if err != nil {
+		writeTaskError(w, r, err)
+		return nil
+	}
+	if ifMatch != "" && !etagListMatches(ifMatch, taskETag(current), false) {
+		problem.Write(w, r, http.StatusPreconditionFailed, "task has been modified")
+		return nil
+	}
+	return current
+}
+
+// writeTaskError отвечает на ошибку сервиса задач. Конфликт версий — это
+// несработавшее предусловие If-Match, поэтому 412, а не 409.
+func writeTaskError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, services.ErrVersionConflict) {
+		// Задачу изменили между чтением и записью
+		problem.Write(w, r, http.StatusPreconditionFailed, "task has been modified")
+		return
+	}
+	writeServiceError(w, r, err)
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/go-portfolio/rest-api/internal/jsonpatch"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/problem"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// acceptPatch — значение заголовка Accept-Patch для /tasks/{id} (RFC 5789)
+const acceptPatch = jsonpatch.MergePatchType + ", " + jsonpatch.JSONPatchType
+
+// maxPatchSize — максимальный размер тела PATCH-запроса
+const maxPatchSize = 1 << 20
+
+// errUnsupportedPatch — Content-Type PATCH-запроса не поддерживается
+var errUnsupportedPatch = errors.New("unsupported patch media type")
+
+// applyTaskPatch применяет тело PATCH-запроса к задаче current.
+// Формат определяется по Content-Type: application/merge-patch+json (RFC 7396)
+// или application/json-patch+json (RFC 6902). Тело длиннее maxPatchSize
+// даёт *http.MaxBytesError.
+func applyTaskPatch(r *http.Request, current *models.Task) (*models.Task, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var apply func(doc, patch []byte) ([]byte, error)
+	switch mediaType {
+	case jsonpatch.MergePatchType:
+		apply = jsonpatch.MergePatch
+	case jsonpatch.JSONPatchType:
+		apply = jsonpatch.Apply
+	default:
+		return nil, errUnsupportedPatch
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", jsonpatch.ErrInvalidPatch, err)
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := apply(doc, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	// Результат должен остаться задачей: неизвестные поля и неверные типы — ошибка
+	var t models.Task
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("%w: %w", jsonpatch.ErrInvalidPatch, err)
+	}
+	return &t, nil
+}
+
+// readonlyTaskFields возвращает ошибки для полей, которые нельзя менять через PATCH
+func readonlyTaskFields(current, patched *models.Task) []problem.FieldError {
+	var fields []string
+	if patched.ID != current.ID {
+		fields = append(fields, "id")
+	}
+	if patched.Version != current.Version {
+		fields = append(fields, "version")
+	}
+	if patched.UserID != current.UserID {
+		fields = append(fields, "user_id")
+	}
+	if !patched.CreatedAt.Equal(current.CreatedAt) {
+		fields = append(fields, "created_at")
+	}
+	if !patched.UpdatedAt.Equal(current.UpdatedAt) {
+		fields = append(fields, "updated_at")
+	}
+	if (patched.DeletedAt == nil) != (current.DeletedAt == nil) ||
+		(patched.DeletedAt != nil && !patched.DeletedAt.Equal(*current.DeletedAt)) {
+		fields = append(fields, "deleted_at")
+	}
+
+	errs := make([]problem.FieldError, 0, len(fields))
+	for _, f := range fields {
+		errs = append(errs, problem.FieldError{Field: f, Code: "readonly", Message: problem.Message("readonly", "")})
+	}
+	return errs
+}
+
+// taskChanges возвращает только изменившиеся поля задачи; запись
+// выполняется, только если задача всё ещё в версии current
+func taskChanges(current, patched *models.Task) services.TaskChanges {
+	c := services.TaskChanges{Version: current.Version}
+	if patched.Title != current.Title {
+		c.Title = &patched.Title
+	}
+	if patched.Status != current.Status {
+		c.Status = &patched.Status
+	}
+	return c
+}
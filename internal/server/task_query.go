@@ -0,0 +1,126 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+const (
+	// defaultTaskLimit — размер страницы, если limit не указан
+	defaultTaskLimit = 50
+	// maxTaskLimit — максимально допустимый размер страницы
+	maxTaskLimit = 500
+)
+
+// parseTaskQuery разбирает query-параметры пагинации, фильтрации и сортировки:
+// limit, offset, cursor, status (можно несколько), user_id,
+// created_after, created_before, updated_since (RFC3339) и sort
+func parseTaskQuery(values url.Values) (services.TaskQuery, error) {
+	q := services.TaskQuery{Limit: defaultTaskLimit}
+
+	if limitStr := values.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return q, errors.New("invalid limit")
+		}
+		if limit > maxTaskLimit {
+			limit = maxTaskLimit
+		}
+		q.Limit = limit
+	}
+
+	if offsetStr := values.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return q, errors.New("invalid offset")
+		}
+		q.Offset = offset
+	}
+
+	q.Cursor = values.Get("cursor")
+	if q.Cursor != "" && q.Offset > 0 {
+		return q, errors.New("cursor and offset cannot be used together")
+	}
+
+	// status=todo&status=done и status=todo,done эквивалентны
+	for _, v := range values["status"] {
+		for _, status := range strings.Split(v, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				q.Statuses = append(q.Statuses, status)
+			}
+		}
+	}
+
+	if userIDStr := values.Get("user_id"); userIDStr != "" {
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil || userID < 1 {
+			return q, errors.New("invalid user_id")
+		}
+		q.UserID = userID
+	}
+
+	var err error
+	if q.CreatedAfter, err = parseTimeParam(values, "created_after"); err != nil {
+		return q, err
+	}
+	if q.CreatedBefore, err = parseTimeParam(values, "created_before"); err != nil {
+		return q, err
+	}
+	if q.UpdatedSince, err = parseTimeParam(values, "updated_since"); err != nil {
+		return q, err
+	}
+
+	if sortStr := values.Get("sort"); sortStr != "" {
+		if q.Sort, err = services.ParseTaskSort(sortStr); err != nil {
+			return q, err
+		}
+		if q.Cursor != "" {
+			return q, errors.New("cursor cannot be used with sort")
+		}
+	}
+
+	return q, nil
+}
+
+// parseTimeParam разбирает необязательный параметр в формате RFC3339
+func parseTimeParam(values url.Values, name string) (*time.Time, error) {
+	v := values.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+	return &t, nil
+}
+
+// setPaginationHeaders выставляет X-Total-Count и Link с rel="next"
+func setPaginationHeaders(w http.ResponseWriter, r *http.Request, q services.TaskQuery, page *services.TaskPage) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+
+	// Ссылка на следующую страницу сохраняет все параметры запроса.
+	// При сортировке по умолчанию offset заменяется курсором,
+	// при пользовательской сортировке курсора нет — сдвигаем offset.
+	next := r.URL.Query()
+	switch {
+	case page.NextCursor != "":
+		next.Del("offset")
+		next.Set("cursor", page.NextCursor)
+	case len(q.Sort) > 0 && q.Offset+len(page.Items) < page.Total:
+		next.Set("offset", strconv.Itoa(q.Offset+len(page.Items)))
+	default:
+		return
+	}
+	next.Set("limit", strconv.Itoa(q.Limit))
+
+	nextURL := url.URL{Path: r.URL.Path, RawQuery: next.Encode()}
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, nextURL.String()))
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/problem"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// RefreshHandler godoc
+// @Summary      Обновление токенов
+// @Description  Обмен refresh-токена на новую пару access/refresh. Refresh-токен одноразовый:
+// @Description  повторное использование отзывает всю цепочку токенов этого входа.
+// @Description  При account.require_verified_email: login токены не выдаются пользователям с неподтверждённым email.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  RefreshRequest  true  "Refresh-токен"
+// @Success      200  {object}  TokenResponse  "Новая пара токенов"
+// @Failure      400  {string}  string  "Некорректный JSON"
+// @Failure      401  {string}  string  "Токен недействителен, истёк или отозван"
+// @Failure      403  {object}  problem.Problem  "Email не подтверждён"
+// @Router       /token/refresh [post]
+func RefreshHandler(userSvc services.UserService, tokenSvc services.TokenService, tm *auth.TokenManager,
+	requireVerified bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req RefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		rotated, err := tokenSvc.RotateRefreshToken(req.RefreshToken)
+		switch {
+		case errors.Is(err, services.ErrInvalidRefreshToken), errors.Is(err, services.ErrRefreshTokenReused):
+			http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+			return
+		case err != nil:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		// Роль берём из базы: она могла измениться с момента входа
+		user, err := userSvc.GetUserByID(rotated.UserID)
+		if err != nil {
+			http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+			return
+		}
+		// Та же проверка, что при входе: refresh не должен её обходить
+		if requireVerified && user.EmailVerifiedAt == nil {
+			problem.Write(w, r, http.StatusForbidden, "email not verified")
+			return
+		}
+		// Новый access-токен остаётся в сессии, открытой при входе
+		principal := principalFor(user)
+		principal.SessionID = rotated.SessionID
+		token, err := tm.GenerateToken(principal)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(TokenResponse{Token: token, RefreshToken: rotated.Token})
+	}
+}
+
+// LogoutHandler godoc
+// @Summary      Выход
+// @Description  Отзывает текущий access-токен и цепочку переданного refresh-токена
+// @Tags         auth
+// @Accept       json
+// @Param        request  body  LogoutRequest  false  "Refresh-токен текущего входа"
+// @Success      204  {string}  string  "Токены отозваны"
+// @Failure      401  {string}  string  "Неавторизован"
+// @Security     BearerAuth
+// @Router       /logout [post]
+func LogoutHandler(tokenSvc services.TokenService, sessions services.SessionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Тело необязательно: без refresh-токена отзывается только access-токен
+		var req LogoutRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid JSON", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if req.RefreshToken != "" {
+			err := tokenSvc.RevokeRefreshFamily(principal.UserID, req.RefreshToken)
+			// Неизвестный или чужой токен не мешает выходу
+			if err != nil && !errors.Is(err, services.ErrInvalidRefreshToken) {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if principal.TokenID != "" {
+			if err := tokenSvc.RevokeAccessToken(principal.TokenID, principal.ExpiresAt); err != nil {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// Выход завершает сессию: она пропадает из списка устройств
+		if sessions != nil && principal.SessionID != 0 {
+			err := sessions.RevokeSession(principal.UserID, principal.SessionID)
+			if err != nil && !errors.Is(err, services.ErrSessionNotFound) {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// JWKSHandler godoc
+// @Summary      Открытые ключи JWT
+// @Description  JWKS (RFC 7517) с открытыми ключами, которыми можно проверить подпись токенов
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  auth.JWKS  "Набор ключей"
+// @Router       /.well-known/jwks.json [get]
+func JWKSHandler(tm *auth.TokenManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		// Ключи меняются редко — разрешаем клиентам кэшировать набор
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		json.NewEncoder(w).Encode(tm.JWKS())
+	}
+}
+
+// principalFor описывает пользователя для access-токена: роль и её разрешения как scope
+func principalFor(user *models.User) auth.Principal {
+	return auth.Principal{UserID: user.ID, Role: user.Role, Scopes: auth.RoleScopes(user.Role)}
+}
+
+// issueTokens открывает сессию входа и выдаёт в ней access-токен и refresh-токен новой цепочки
+func issueTokens(tokenSvc services.TokenService, tm *auth.TokenManager, sessions *SessionRecorder,
+	r *http.Request, user *models.User) (*TokenResponse, error) {
+	sessionID, err := sessions.start(r, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	principal := principalFor(user)
+	principal.SessionID = sessionID
+	token, err := tm.GenerateToken(principal)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := tokenSvc.IssueRefreshToken(user.ID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenResponse{Token: token, RefreshToken: refreshToken}, nil
+}
+
+// RefreshRequest модель запроса обновления токенов
+// swagger:model RefreshRequest
+type RefreshRequest struct {
+	// Refresh-токен, полученный при входе или предыдущем обновлении
+	RefreshToken string `json:"refresh_token"`
+}
+
+// LogoutRequest модель запроса выхода
+// swagger:model LogoutRequest
+type LogoutRequest struct {
+	// Refresh-токен, цепочку которого нужно отозвать
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse модель ответа с парой токенов
+// swagger:model TokenResponse
+type TokenResponse struct {
+	// JWT access-токен
+	Token string `json:"token"`
+	// Новый refresh-токен
+	RefreshToken string `json:"refresh_token"`
+}
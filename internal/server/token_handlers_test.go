@@ -0,0 +1,120 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/config"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// testTokenManager создаёт HS256-менеджер токенов для тестов обработчиков
+func testTokenManager(t *testing.T) *auth.TokenManager {
+	t.Helper()
+	tm, err := auth.NewTokenManager(config.JwtConfig{JwtSecretKey: "test-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm
+}
+
+// testUsers возвращает мок-сервис с пользователями разных ролей
+func testUsers() *services.MockUserService {
+	return &services.MockUserService{
+		Users: []models.User{
+			{ID: 1, Username: "alex", Password: "password123", Role: auth.RoleMember},
+			{ID: 2, Username: "boss", Password: "admin123", Role: auth.RoleAdmin},
+			{ID: 3, Username: "guest", Password: "guest123", Role: auth.RoleViewer},
+		},
+	}
+}
+
+// refresh отправляет POST /token/refresh с переданным refresh-токеном
+func refresh(tm *auth.TokenManager, tokenSvc services.TokenService, refreshToken string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(RefreshRequest{RefreshToken: refreshToken})
+	req := httptest.NewRequest(http.MethodPost, "/token/refresh", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	RefreshHandler(testUsers(), tokenSvc, tm, false)(w, req)
+	return w
+}
+
+// TestRefreshHandler проверяет ротацию refresh-токенов и обнаружение повторного использования
+func TestRefreshHandler(t *testing.T) {
+	tm := testTokenManager(t)
+	tokenSvc := &services.MockTokenService{}
+	first, _ := tokenSvc.IssueRefreshToken(1, 0)
+
+	// Первый обмен успешен и выдаёт новый refresh-токен
+	w := refresh(tm, tokenSvc, first)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var pair TokenResponse
+	if err := json.NewDecoder(w.Body).Decode(&pair); err != nil {
+		t.Fatal(err)
+	}
+	if pair.Token == "" || pair.RefreshToken == "" || pair.RefreshToken == first {
+		t.Fatalf("Unexpected token pair: %+v", pair)
+	}
+
+	// Повторное использование старого токена отклоняется...
+	if w := refresh(tm, tokenSvc, first); w.Code != http.StatusUnauthorized {
+		t.Errorf("Reuse: expected status 401, got %d", w.Code)
+	}
+	// ...и отзывает всю цепочку, включая свежий токен
+	if w := refresh(tm, tokenSvc, pair.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("Family revoked: expected status 401, got %d", w.Code)
+	}
+
+	// Неизвестный токен
+	if w := refresh(tm, tokenSvc, "garbage"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Unknown token: expected status 401, got %d", w.Code)
+	}
+}
+
+// TestLogoutHandler проверяет отзыв access-токена и цепочки refresh-токенов
+func TestLogoutHandler(t *testing.T) {
+	tm := testTokenManager(t)
+	tokenSvc := &services.MockTokenService{}
+	tokens, err := issueTokens(tokenSvc, tm, nil, httptest.NewRequest(http.MethodPost, "/login", nil), &testUsers().Users[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Защищённый обработчик, как в StartServer
+	protected := auth.VerifyToken(tm, auth.WithDenylist(tokenSvc))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }),
+	)
+	logout := auth.VerifyToken(tm, auth.WithDenylist(tokenSvc))(LogoutHandler(tokenSvc, nil))
+
+	call := func(h http.Handler, body []byte) int {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+tokens.Token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := call(protected, nil); code != http.StatusOK {
+		t.Fatalf("Before logout: expected status 200, got %d", code)
+	}
+
+	body, _ := json.Marshal(LogoutRequest{RefreshToken: tokens.RefreshToken})
+	if code := call(logout, body); code != http.StatusNoContent {
+		t.Fatalf("Logout: expected status 204, got %d", code)
+	}
+
+	// Access-токен попал в denylist
+	if code := call(protected, nil); code != http.StatusUnauthorized {
+		t.Errorf("After logout: expected status 401, got %d", code)
+	}
+	// Refresh-токен больше не обменивается
+	if w := refresh(tm, tokenSvc, tokens.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("Refresh after logout: expected status 401, got %d", w.Code)
+	}
+}
@@ -0,0 +1,267 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// TwoFactorHandler godoc
+// @Summary      Двухфакторная аутентификация (TOTP)
+// @Description  GET — состояние 2FA; POST /me/2fa — начать подключение (секрет и otpauth:// URI);
+// @Description  POST /me/2fa/confirm — подтвердить кодом из приложения и получить коды восстановления;
+// @Description  DELETE /me/2fa — отключить (требуется действующий код)
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  TwoFactorCodeRequest  false  "Код из приложения (для confirm и DELETE)"
+// @Success      200  {object}  TwoFactorEnrollResponse  "Секрет и URI для приложения-аутентификатора"
+// @Success      204  {string}  string  "2FA отключена"
+// @Failure      400  {string}  string  "Неверный код"
+// @Failure      401  {string}  string  "Неавторизован"
+// @Failure      403  {string}  string  "2FA нельзя настраивать с помощью API-ключа"
+// @Failure      409  {string}  string  "2FA уже подключена или не подключена"
+// @Security     BearerAuth
+// @Router       /me/2fa [get]
+// @Router       /me/2fa [post]
+// @Router       /me/2fa [delete]
+// @Router       /me/2fa/confirm [post]
+func TwoFactorHandler(userSvc services.UserService, svc services.TwoFactorService, issuer string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if principal.Delegated() {
+			http.Error(w, "two-factor settings cannot be managed with delegated credentials", http.StatusForbidden)
+			return
+		}
+
+		action := strings.Trim(strings.TrimPrefix(r.URL.Path, "/me/2fa"), "/")
+
+		switch {
+		// -----------------------------
+		// GET /me/2fa
+		// -----------------------------
+		case r.Method == http.MethodGet && action == "":
+			enabled, err := svc.Enabled(principal.UserID)
+			if err != nil {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]bool{"enabled": enabled})
+
+		// -----------------------------
+		// POST /me/2fa
+		// -----------------------------
+		case r.Method == http.MethodPost && action == "":
+			user, err := userSvc.GetUserByID(principal.UserID)
+			if err != nil {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			secret, err := svc.BeginEnrollment(principal.UserID)
+			if err != nil {
+				writeTwoFactorError(w, err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(TwoFactorEnrollResponse{
+				Secret:     secret,
+				OTPAuthURI: auth.TOTPURI(issuer, user.Username, secret),
+			})
+
+		// -----------------------------
+		// POST /me/2fa/confirm
+		// -----------------------------
+		case r.Method == http.MethodPost && action == "confirm":
+			var req TwoFactorCodeRequest
+			if !decodeTwoFactorCode(w, r, &req) {
+				return
+			}
+			codes, err := svc.ConfirmEnrollment(principal.UserID, req.Code)
+			if err != nil {
+				writeTwoFactorError(w, err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(TwoFactorConfirmResponse{RecoveryCodes: codes})
+
+		// -----------------------------
+		// DELETE /me/2fa
+		// -----------------------------
+		case r.Method == http.MethodDelete && action == "":
+			var req TwoFactorCodeRequest
+			if !decodeTwoFactorCode(w, r, &req) {
+				return
+			}
+			if err := svc.Disable(principal.UserID, req.Code); err != nil {
+				writeTwoFactorError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// TwoFactorLoginHandler godoc
+// @Summary      Второй шаг входа (2FA)
+// @Description  Обменивает challenge_token из ответа POST /login и код из приложения
+// @Description  (или код восстановления) на JWT и refresh-токен
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body  TwoFactorLoginRequest  true  "Challenge-токен и код"
+// @Success      200  {object}  LoginResponse  "JWT токен, refresh-токен и данные пользователя"
+// @Failure      400  {string}  string  "Некорректный JSON"
+// @Failure      401  {string}  string  "Недействительный challenge-токен или неверный код"
+// @Failure      429  {string}  string  "Слишком много неудачных попыток, см. заголовок Retry-After"
+// @Router       /login/2fa [post]
+func TwoFactorLoginHandler(userSvc services.UserService, tokenSvc services.TokenService, tm *auth.TokenManager,
+	svc services.TwoFactorService, limiter *auth.LoginLimiter, sessions *SessionRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req TwoFactorLoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		claims, err := tm.ParseChallengeToken(req.ChallengeToken)
+		if err != nil {
+			http.Error(w, "invalid challenge token", http.StatusUnauthorized)
+			return
+		}
+		// Challenge-токен одноразовый: после успешного входа его jti попадает в denylist
+		if revoked, err := tokenSvc.IsRevoked(claims.ID); err != nil || revoked {
+			http.Error(w, "invalid challenge token", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := userSvc.GetUserByID(claims.UserID)
+		if err != nil {
+			http.Error(w, "invalid challenge token", http.StatusUnauthorized)
+			return
+		}
+
+		// Перебор кодов ограничивается так же, как перебор паролей
+		ip := limiter.ClientIP(r)
+		if wait := limiter.Check(user.Username, ip); wait > 0 {
+			loginAttempts.WithLabelValues("blocked").Inc()
+			tooManyAttempts(w, r, wait)
+			return
+		}
+		if err := svc.Verify(user.ID, req.Code); err != nil {
+			if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+				loginAttempts.WithLabelValues("failure").Inc()
+				_, locked := limiter.Failure(user.Username, ip)
+				for _, scope := range locked {
+					loginLockouts.WithLabelValues(string(scope)).Inc()
+				}
+				http.Error(w, "invalid code", http.StatusUnauthorized)
+				return
+			}
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		loginAttempts.WithLabelValues("success").Inc()
+		limiter.Success(user.Username)
+
+		if err := tokenSvc.RevokeAccessToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		tokens, err := issueTokens(tokenSvc, tm, sessions, r, user)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(LoginResponse{
+			Token:        tokens.Token,
+			RefreshToken: tokens.RefreshToken,
+			User:         *user,
+		})
+	}
+}
+
+// decodeTwoFactorCode читает и валидирует тело с кодом; при ошибке отвечает 400
+func decodeTwoFactorCode(w http.ResponseWriter, r *http.Request, req *TwoFactorCodeRequest) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return false
+	}
+	if err := authValidate.Struct(req); err != nil {
+		writeValidationErrors(w, err)
+		return false
+	}
+	return true
+}
+
+// writeTwoFactorError переводит ошибки TwoFactorService в HTTP-статусы
+func writeTwoFactorError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		http.Error(w, "invalid code", http.StatusBadRequest)
+	case errors.Is(err, services.ErrTwoFactorEnabled):
+		http.Error(w, "two-factor authentication already enabled", http.StatusConflict)
+	case errors.Is(err, services.ErrTwoFactorNotEnabled):
+		http.Error(w, "two-factor authentication not enabled", http.StatusConflict)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+// TwoFactorCodeRequest — код из приложения-аутентификатора
+// swagger:model TwoFactorCodeRequest
+type TwoFactorCodeRequest struct {
+	// example: 123456
+	Code string `json:"code" validate:"required"`
+}
+
+// TwoFactorEnrollResponse — данные для подключения приложения-аутентификатора
+// swagger:model TwoFactorEnrollResponse
+type TwoFactorEnrollResponse struct {
+	// Секрет в base32 для ручного ввода
+	Secret string `json:"secret"`
+	// URI для QR-кода
+	// example: otpauth://totp/rest-api:alex?secret=...&issuer=rest-api
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorConfirmResponse — одноразовые коды восстановления, показываются один раз
+// swagger:model TwoFactorConfirmResponse
+type TwoFactorConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorChallengeResponse — ответ POST /login, если у пользователя включена 2FA
+// swagger:model TwoFactorChallengeResponse
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool `json:"two_factor_required"`
+	// Токен для POST /login/2fa, действует 5 минут
+	ChallengeToken string `json:"challenge_token"`
+}
+
+// TwoFactorLoginRequest — второй шаг входа
+// swagger:model TwoFactorLoginRequest
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	// TOTP-код или код восстановления
+	// example: 123456
+	Code string `json:"code"`
+}
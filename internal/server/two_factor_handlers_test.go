@@ -0,0 +1,127 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// TestTwoFactor проверяет подключение 2FA и двухшаговый вход
+func TestTwoFactor(t *testing.T) {
+	tm := testTokenManager(t)
+	users := testUsers()
+	tokenSvc := &services.MockTokenService{}
+	svc := &services.MockTwoFactorService{}
+	settings := TwoFactorHandler(users, svc, "rest-api")
+	login := LoginHandler(users, tokenSvc, tm, LoginOptions{TwoFactor: svc})
+	secondStep := TwoFactorLoginHandler(users, tokenSvc, tm, svc, nil, nil)
+
+	// call отправляет запрос к /me/2fa от имени пользователя 1
+	call := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := withUser(httptest.NewRequest(method, path, bytes.NewReader(data)), 1)
+		w := httptest.NewRecorder()
+		settings(w, req)
+		return w
+	}
+
+	// -----------------------------
+	// Подключение: секрет, подтверждение кодом, коды восстановления
+	// -----------------------------
+	w := call(http.MethodPost, "/me/2fa", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var enroll TwoFactorEnrollResponse
+	json.NewDecoder(w.Body).Decode(&enroll)
+	if enroll.Secret == "" || !strings.HasPrefix(enroll.OTPAuthURI, "otpauth://totp/rest-api:alex?") {
+		t.Fatalf("Unexpected enrollment response: %+v", enroll)
+	}
+
+	if w := call(http.MethodPost, "/me/2fa/confirm", TwoFactorCodeRequest{Code: "000000"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for wrong code, got %d", w.Code)
+	}
+	code, _ := auth.TOTPCode(enroll.Secret, time.Now())
+	w = call(http.MethodPost, "/me/2fa/confirm", TwoFactorCodeRequest{Code: code})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var confirm TwoFactorConfirmResponse
+	json.NewDecoder(w.Body).Decode(&confirm)
+	if len(confirm.RecoveryCodes) != services.RecoveryCodeCount {
+		t.Fatalf("Expected %d recovery codes, got %d", services.RecoveryCodeCount, len(confirm.RecoveryCodes))
+	}
+	if w := call(http.MethodPost, "/me/2fa", nil); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 when already enabled, got %d", w.Code)
+	}
+
+	// -----------------------------
+	// Вход: пароль → challenge → код восстановления → JWT
+	// -----------------------------
+	data, _ := json.Marshal(LoginRequest{Username: "alex", Password: "password123"})
+	w = httptest.NewRecorder()
+	login(w, httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(data)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", w.Code)
+	}
+	var challenge TwoFactorChallengeResponse
+	json.NewDecoder(w.Body).Decode(&challenge)
+	if !challenge.TwoFactorRequired || challenge.ChallengeToken == "" {
+		t.Fatalf("Unexpected challenge response: %+v", challenge)
+	}
+	// Challenge-токен не даёт доступа к API
+	if _, err := tm.ParseToken(challenge.ChallengeToken); err == nil {
+		t.Error("Challenge token must not be accepted as access token")
+	}
+
+	step := func(code string) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(TwoFactorLoginRequest{ChallengeToken: challenge.ChallengeToken, Code: code})
+		w := httptest.NewRecorder()
+		secondStep(w, httptest.NewRequest(http.MethodPost, "/login/2fa", bytes.NewReader(data)))
+		return w
+	}
+
+	if w := step("000000"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for wrong code, got %d", w.Code)
+	}
+	// Код, уже использованный при подтверждении, повторно не принимается
+	if w := step(code); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for replayed code, got %d", w.Code)
+	}
+	w = step(strings.ToUpper(confirm.RecoveryCodes[0]))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp LoginResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Token == "" || resp.RefreshToken == "" || resp.User.ID != 1 {
+		t.Errorf("Unexpected login response: %+v", resp)
+	}
+
+	// Challenge-токен одноразовый
+	if w := step(confirm.RecoveryCodes[1]); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for reused challenge, got %d", w.Code)
+	}
+
+	// -----------------------------
+	// Отключение
+	// -----------------------------
+	if w := call(http.MethodDelete, "/me/2fa", TwoFactorCodeRequest{Code: confirm.RecoveryCodes[0]}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for used recovery code, got %d", w.Code)
+	}
+	if w := call(http.MethodDelete, "/me/2fa", TwoFactorCodeRequest{Code: confirm.RecoveryCodes[2]}); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	login(w, httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(data)))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected direct login after disabling 2FA, got %d", w.Code)
+	}
+}
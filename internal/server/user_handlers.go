@@ -0,0 +1,312 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+const (
+	// defaultUserLimit — размер страницы /users, если limit не указан
+	defaultUserLimit = 50
+	// maxUserLimit — максимально допустимый размер страницы /users
+	maxUserLimit = 500
+)
+
+// MeHandler godoc
+// @Summary      Профиль текущего пользователя
+// @Description  GET возвращает профиль, PATCH меняет логин, email и отображаемое имя
+// @Description  (смена email требует current_password и сбрасывает подтверждение), PUT /me/password меняет пароль
+// @Description  по текущему паролю (новый пароль проверяется политикой паролей) и завершает остальные сессии, DELETE удаляет учётную запись
+// @Description  (soft-delete): задачи пользователя удаляются, ключи и токены отзываются.
+// @Description  Изменять учётную запись с API-ключом или токеном OAuth-клиента нельзя.
+// @Description  Проверки текущего пароля ограничиваются так же, как попытки входа.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        profile   body  UpdateProfileRequest   false  "Изменяемые поля (PATCH /me)"
+// @Param        password  body  ChangePasswordRequest  false  "Текущий и новый пароль (PUT /me/password)"
+// @Success      200  {object}  models.User  "Профиль"
+// @Success      204  {string}  string       "Пароль изменён или учётная запись удалена"
+// @Failure      400  {object}  map[string]string  "Ошибки валидации"
+// @Failure      401  {string}  string       "Неавторизован"
+// @Failure      403  {string}  string       "Неверный текущий пароль или делегированные учётные данные"
+// @Failure      409  {string}  string       "Логин или email уже заняты"
+// @Failure      429  {object}  problem.Problem  "Слишком много неверных паролей, см. Retry-After"
+// @Security     BearerAuth
+// @Router       /me [get]
+// @Router       /me [patch]
+// @Router       /me [delete]
+// @Router       /me/password [put]
+func MeHandler(userSvc services.UserService, tokenSvc services.TokenService,
+	hasher *auth.PasswordHasher, policy *auth.PasswordPolicy, limiter *auth.LoginLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		// Ключ, токен стороннего приложения или администратор от имени пользователя
+		// не должны позволять захватить учётную запись
+		delegated := principal.Delegated()
+
+		switch {
+		// -----------------------------
+		// GET /me
+		// -----------------------------
+		case r.Method == http.MethodGet && r.URL.Path == "/me":
+			user, err := userSvc.GetUserByID(principal.UserID)
+			if err != nil {
+				writeServiceError(w, r, err)
+				return
+			}
+			user.Password = ""
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(user)
+
+		// -----------------------------
+		// PATCH /me
+		// -----------------------------
+		case r.Method == http.MethodPatch && r.URL.Path == "/me":
+			if delegated {
+				http.Error(w, "profile cannot be changed with delegated credentials", http.StatusForbidden)
+				return
+			}
+			var req UpdateProfileRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid JSON", http.StatusBadRequest)
+				return
+			}
+			if err := authValidate.Struct(req); err != nil {
+				writeValidationErrors(w, err)
+				return
+			}
+
+			// Смена email открывает сброс пароля на новый адрес, поэтому
+			// одного токена недостаточно — нужен текущий пароль
+			if req.Email != nil {
+				user, err := userSvc.GetUserByID(principal.UserID)
+				if err != nil {
+					writeServiceError(w, r, err)
+					return
+				}
+				if *req.Email != user.Email {
+					if req.CurrentPassword == "" {
+						writeFieldErrors(w, map[string]string{"CurrentPassword": "required"})
+						return
+					}
+					if !checkCurrentPassword(w, r, userSvc, limiter, user.Username, req.CurrentPassword) {
+						return
+					}
+				}
+			}
+
+			user, err := userSvc.UpdateProfile(principal.UserID, services.ProfileUpdate{
+				Username:    req.Username,
+				Email:       req.Email,
+				DisplayName: req.DisplayName,
+			})
+			if err != nil {
+				writeServiceError(w, r, err)
+				return
+			}
+			user.Password = ""
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(user)
+
+		// -----------------------------
+		// PUT /me/password
+		// -----------------------------
+		case r.Method == http.MethodPut && r.URL.Path == "/me/password":
+			if delegated {
+				http.Error(w, "password cannot be changed with delegated credentials", http.StatusForbidden)
+				return
+			}
+			var req ChangePasswordRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid JSON", http.StatusBadRequest)
+				return
+			}
+			if err := authValidate.Struct(req); err != nil {
+				writeValidationErrors(w, err)
+				return
+			}
+
+			user, err := userSvc.GetUserByID(principal.UserID)
+			if err != nil {
+				writeServiceError(w, r, err)
+				return
+			}
+			if !checkCurrentPassword(w, r, userSvc, limiter, user.Username, req.CurrentPassword) {
+				return
+			}
+			errs := map[string]string{}
+			checkPasswordPolicy(errs, policy, "NewPassword", req.NewPassword, user.Username)
+			if len(errs) > 0 {
+				writeFieldErrors(w, errs)
+				return
+			}
+			hash, err := hasher.Hash(req.NewPassword)
+			if err != nil {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			// Сессия, из которой меняется пароль, остаётся; остальные завершаются
+			if err := userSvc.UpdatePassword(principal.UserID, hash, principal.SessionID); err != nil {
+				writeServiceError(w, r, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		// -----------------------------
+		// DELETE /me
+		// -----------------------------
+		case r.Method == http.MethodDelete && r.URL.Path == "/me":
+			if delegated {
+				http.Error(w, "account cannot be deleted with delegated credentials", http.StatusForbidden)
+				return
+			}
+			if err := userSvc.DeleteUser(principal.UserID); err != nil {
+				writeServiceError(w, r, err)
+				return
+			}
+			// Refresh-токены отозваны сервисом; текущий access-токен отзываем сами
+			if principal.TokenID != "" {
+				if err := tokenSvc.RevokeAccessToken(principal.TokenID, principal.ExpiresAt); err != nil {
+					http.Error(w, "internal error", http.StatusInternalServerError)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// checkCurrentPassword проверяет текущий пароль пользователя username.
+// Неверные пароли учитываются limiter так же, как при входе, чтобы украденным
+// токеном нельзя было подбирать пароль. При ошибке отвечает клиенту и возвращает false.
+func checkCurrentPassword(w http.ResponseWriter, r *http.Request, userSvc services.UserService,
+	limiter *auth.LoginLimiter, username, password string) bool {
+	ip := limiter.ClientIP(r)
+	if wait := limiter.Check(username, ip); wait > 0 {
+		tooManyAttempts(w, r, wait)
+		return false
+	}
+	if _, err := userSvc.Authenticate(username, password); err != nil {
+		_, locked := limiter.Failure(username, ip)
+		for _, scope := range locked {
+			loginLockouts.WithLabelValues(string(scope)).Inc()
+		}
+		http.Error(w, "invalid current password", http.StatusForbidden)
+		return false
+	}
+	limiter.Success(username)
+	return true
+}
+
+// UsersHandler godoc
+// @Summary      Список пользователей
+// @Description  Постраничный список активных пользователей с поиском по логину, email
+// @Description  и отображаемому имени. Только для администраторов (users:admin).
+// @Tags         users
+// @Produce      json
+// @Param        search  query  string  false  "Подстрока для поиска (без учёта регистра)"
+// @Param        limit   query  int     false  "Размер страницы (по умолчанию 50, максимум 500)"
+// @Param        offset  query  int     false  "Смещение от начала списка"
+// @Success      200  {array}   models.User  "Пользователи"
+// @Header       200  {integer} X-Total-Count  "Количество найденных пользователей"
+// @Header       200  {string}  Link           "Ссылка на следующую страницу (rel=next)"
+// @Failure      400  {string}  string  "Некорректные параметры"
+// @Failure      401  {string}  string  "Неавторизован"
+// @Failure      403  {string}  string  "Требуется разрешение users:admin"
+// @Security     BearerAuth
+// @Router       /users [get]
+func UsersHandler(userSvc services.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query, err := parseUserQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		page, err := userSvc.ListUsers(query)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+		if next := query.Offset + len(page.Items); next < page.Total {
+			values := r.URL.Query()
+			values.Set("offset", strconv.Itoa(next))
+			values.Set("limit", strconv.Itoa(query.Limit))
+			nextURL := url.URL{Path: r.URL.Path, RawQuery: values.Encode()}
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, nextURL.String()))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page.Items)
+	}
+}
+
+// parseUserQuery разбирает параметры search, limit и offset
+func parseUserQuery(values url.Values) (services.UserQuery, error) {
+	q := services.UserQuery{Search: values.Get("search"), Limit: defaultUserLimit}
+
+	if limitStr := values.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return q, errors.New("invalid limit")
+		}
+		if limit > maxUserLimit {
+			limit = maxUserLimit
+		}
+		q.Limit = limit
+	}
+	if offsetStr := values.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return q, errors.New("invalid offset")
+		}
+		q.Offset = offset
+	}
+	return q, nil
+}
+
+// UpdateProfileRequest модель запроса PATCH /me; отсутствующие поля не меняются
+// swagger:model UpdateProfileRequest
+type UpdateProfileRequest struct {
+	// Новый логин
+	// example: user123
+	Username *string `json:"username" validate:"omitnil,min=3,max=20"`
+	// Новый email; требует повторного подтверждения
+	// example: user@example.com
+	Email *string `json:"email" validate:"omitnil,email"`
+	// Отображаемое имя; пустая строка удаляет его
+	// example: Алексей
+	DisplayName *string `json:"display_name" validate:"omitnil,max=100"`
+	// Текущий пароль; обязателен при смене email
+	CurrentPassword string `json:"current_password,omitempty"`
+}
+
+// ChangePasswordRequest модель запроса PUT /me/password
+// swagger:model ChangePasswordRequest
+type ChangePasswordRequest struct {
+	// Текущий пароль
+	CurrentPassword string `json:"current_password" validate:"required"`
+	// Новый пароль; требования задаются политикой паролей (password.policy)
+	// example: newpass123
+	NewPassword string `json:"new_password" validate:"required"`
+}
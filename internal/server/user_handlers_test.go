@@ -0,0 +1,248 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+	"github.com/go-portfolio/rest-api/internal/config"
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// TestMeHandler проверяет просмотр и изменение профиля, смену пароля и удаление учётной записи
+func TestMeHandler(t *testing.T) {
+	users := testUsers()
+	verified := time.Now()
+	users.Users[0].Email = "alex@example.com"
+	users.Users[0].EmailVerifiedAt = &verified
+	tokenSvc := &services.MockTokenService{}
+	handler := MeHandler(users, tokenSvc, nil, nil, nil)
+
+	// call отправляет запрос от имени пользователя p
+	call := func(p *auth.Principal, method, path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := withPrincipal(httptest.NewRequest(method, path, bytes.NewReader(data)), p)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+	alex := &auth.Principal{UserID: 1, Role: auth.RoleMember, TokenID: "jti-1", ExpiresAt: time.Now().Add(time.Hour)}
+
+	w := call(alex, http.MethodGet, "/me", nil)
+	var me models.User
+	json.NewDecoder(w.Body).Decode(&me)
+	if w.Code != http.StatusOK || me.Username != "alex" || me.Password != "" {
+		t.Fatalf("Unexpected profile: %d %+v", w.Code, me)
+	}
+
+	// -----------------------------
+	// PATCH /me
+	// -----------------------------
+	if w := call(alex, http.MethodPatch, "/me", map[string]string{"username": "ab"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for short username, got %d", w.Code)
+	}
+	if w := call(alex, http.MethodPatch, "/me", map[string]string{"username": "boss"}); w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409 for taken username, got %d", w.Code)
+	}
+	if w := call(&auth.Principal{UserID: 1, APIKeyID: 7}, http.MethodPatch, "/me", map[string]string{"display_name": "x"}); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403 for api key, got %d", w.Code)
+	}
+
+	// Смена email требует текущего пароля
+	if w := call(alex, http.MethodPatch, "/me", map[string]string{"email": "new@example.com"}); w.Code != http.StatusBadRequest ||
+		!strings.Contains(w.Body.String(), "CurrentPassword") {
+		t.Fatalf("Expected status 400 without current password, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(alex, http.MethodPatch, "/me", map[string]string{"email": "new@example.com", "current_password": "wrong"}); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403 for wrong password, got %d", w.Code)
+	}
+	// Тот же email пароля не требует
+	if w := call(alex, http.MethodPatch, "/me", map[string]string{"email": "alex@example.com"}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for unchanged email, got %d", w.Code)
+	}
+
+	w = call(alex, http.MethodPatch, "/me", map[string]string{"email": "new@example.com", "display_name": "Алексей", "current_password": "password123"})
+	me = models.User{}
+	json.NewDecoder(w.Body).Decode(&me)
+	if w.Code != http.StatusOK || me.Email != "new@example.com" || me.DisplayName != "Алексей" || me.Username != "alex" {
+		t.Fatalf("Unexpected update: %d %+v", w.Code, me)
+	}
+	if me.EmailVerifiedAt != nil {
+		t.Error("Changing email must reset verification")
+	}
+
+	// -----------------------------
+	// PUT /me/password
+	// -----------------------------
+	if w := call(alex, http.MethodPut, "/me/password", ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "newpass123"}); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403 for wrong password, got %d", w.Code)
+	}
+	if w := call(alex, http.MethodPut, "/me/password", ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "newpass123"}); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if stored := users.Users[0].Password; !strings.HasPrefix(stored, "$argon2id$") {
+		t.Errorf("Expected new password to be stored as argon2id hash, got %q", stored)
+	}
+	if _, err := users.Authenticate("alex", "newpass123"); err != nil {
+		t.Errorf("Expected login with new password: %v", err)
+	}
+
+	// -----------------------------
+	// DELETE /me
+	// -----------------------------
+	if w := call(alex, http.MethodDelete, "/me", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if revoked, _ := tokenSvc.IsRevoked("jti-1"); !revoked {
+		t.Error("Expected current access token to be revoked")
+	}
+	if _, err := users.GetUserByID(1); err != services.ErrUserNotFound {
+		t.Errorf("Expected deleted user to be hidden, got %v", err)
+	}
+	if w := call(alex, http.MethodGet, "/me", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404 after delete, got %d", w.Code)
+	}
+}
+
+// TestUsersHandler проверяет поиск и пагинацию списка пользователей
+func TestUsersHandler(t *testing.T) {
+	users := testUsers()
+	handler := UsersHandler(users)
+
+	list := func(query string) ([]models.User, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, "/users"+query, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		var items []models.User
+		json.NewDecoder(w.Body).Decode(&items)
+		return items, w
+	}
+
+	items, w := list("?limit=2")
+	if w.Code != http.StatusOK || len(items) != 2 || w.Header().Get("X-Total-Count") != "3" {
+		t.Fatalf("Unexpected page: %d %+v", w.Code, items)
+	}
+	if link := w.Header().Get("Link"); link != `</users?limit=2&offset=2>; rel="next"` {
+		t.Errorf("Unexpected Link header: %q", link)
+	}
+	for _, u := range items {
+		if u.Password != "" {
+			t.Fatal("Password hash must not be listed")
+		}
+	}
+
+	items, w = list("?search=BOS")
+	if len(items) != 1 || items[0].Username != "boss" || w.Header().Get("Link") != "" {
+		t.Fatalf("Unexpected search result: %+v", items)
+	}
+
+	if _, w := list("?limit=0"); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for invalid limit, got %d", w.Code)
+	}
+}
+
+// TestPasswordChangeRevokesSessions проверяет, что смена и сброс пароля завершают сессии,
+// и access-токены этих сессий перестают приниматься
+func TestPasswordChangeRevokesSessions(t *testing.T) {
+	tm := testTokenManager(t)
+	tokenSvc := &services.MockTokenService{}
+	sessions := &services.MockSessionService{Tokens: tokenSvc}
+	users := testUsers()
+	users.Sessions = sessions
+	requireAuth := auth.VerifyToken(tm, auth.WithDenylist(tokenSvc), auth.WithSessions(sessions))
+	me := requireAuth(MeHandler(users, tokenSvc, nil, nil, nil))
+
+	// login открывает сессию пользователя alex и возвращает её access-токен
+	login := func() string {
+		sess, _ := sessions.CreateSession(1, "test", "127.0.0.1")
+		tokenSvc.IssueRefreshToken(1, sess.ID)
+		token, err := tm.GenerateToken(auth.Principal{UserID: 1, Role: auth.RoleMember, SessionID: sess.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	call := func(token, method, path string, body interface{}) int {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		me.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	current, attacker := login(), login()
+	if code := call(current, http.MethodPut, "/me/password",
+		ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "newpass123"}); code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", code)
+	}
+	if code := call(attacker, http.MethodGet, "/me", nil); code != http.StatusUnauthorized {
+		t.Errorf("Expected other session to be revoked, got %d", code)
+	}
+	if code := call(current, http.MethodGet, "/me", nil); code != http.StatusOK {
+		t.Errorf("Expected current session to stay active, got %d", code)
+	}
+	// Refresh-токены отозваны у всех сессий, кроме текущей (ID 1)
+	for _, rt := range tokenSvc.RefreshTokens {
+		if kept := rt.SessionID == 1; rt.Revoked == kept {
+			t.Errorf("Refresh token of session %d: revoked=%v", rt.SessionID, rt.Revoked)
+		}
+	}
+
+	// Сброс пароля по токену из письма завершает все сессии, включая текущую
+	resetSvc := &services.MockPasswordResetService{Users: users}
+	resetToken, _ := resetSvc.CreateResetToken(1)
+	w := postJSON(ResetPasswordHandler(resetSvc, users, nil, nil), "/password/reset",
+		ResetPasswordRequest{Token: resetToken, Password: "another123"})
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if code := call(current, http.MethodGet, "/me", nil); code != http.StatusUnauthorized {
+		t.Errorf("Expected all sessions to be revoked after reset, got %d", code)
+	}
+}
+
+// TestMeHandler_PasswordLimit проверяет, что подбор текущего пароля по токену ограничен
+func TestMeHandler_PasswordLimit(t *testing.T) {
+	limiter := auth.NewLoginLimiter(config.LoginLimitConfig{
+		MaxFailuresPerUser: 2,
+		BaseDelay:          time.Minute,
+		Lockout:            time.Hour,
+	})
+	users := testUsers()
+	handler := MeHandler(users, &services.MockTokenService{}, nil, nil, limiter)
+	alex := &auth.Principal{UserID: 1, Role: auth.RoleMember}
+	call := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := withPrincipal(httptest.NewRequest(method, path, bytes.NewReader(data)), alex)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	if w := call(http.MethodPut, "/me/password", ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "newpass123"}); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, got %d", w.Code)
+	}
+	// После неудачи действует задержка — даже верный пароль не проверяется
+	w := call(http.MethodPut, "/me/password", ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "newpass123"})
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("Expected 429 with Retry-After 60, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	// Та же задержка действует для смены email и для входа
+	w = call(http.MethodPatch, "/me", map[string]string{"email": "new@example.com", "current_password": "password123"})
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 for email change, got %d", w.Code)
+	}
+	if wait := limiter.Check("alex", "192.0.2.1"); wait <= 0 {
+		t.Error("Expected login to be delayed as well")
+	}
+	if users.Users[0].Password != "password123" {
+		t.Error("Password must not change while limited")
+	}
+}
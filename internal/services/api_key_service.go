@@ -0,0 +1,155 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/lib/pq"
+)
+
+// APIKeyPrefix — начало каждого API-ключа; по нему middleware отличает ключ от JWT
+const APIKeyPrefix = "rak_"
+
+var (
+	// ErrAPIKeyNotFound — ключ не существует или принадлежит другому пользователю
+	ErrAPIKeyNotFound = newError(ErrNotFound, "api key not found")
+	// ErrInvalidAPIKey — ключ неизвестен, отозван или истёк
+	ErrInvalidAPIKey = errors.New("invalid api key")
+)
+
+// -----------------------------
+// Интерфейс APIKeyService
+// -----------------------------
+// Управляет API-ключами. В базе хранится только SHA-256 ключа,
+// сам ключ возвращается один раз — при создании.
+type APIKeyService interface {
+	// Создать ключ; возвращает сам ключ и его описание
+	CreateAPIKey(userID int, name string, scopes []string, expiresAt *time.Time) (string, *models.APIKey, error)
+	// Список ключей пользователя, включая отозванные
+	ListAPIKeys(userID int) ([]models.APIKey, error)
+	// Отозвать ключ пользователя
+	RevokeAPIKey(userID, id int) error
+	// Проверить ключ и отметить его использование; возвращает ключ и роль владельца
+	AuthenticateAPIKey(key string) (*models.APIKey, string, error)
+}
+
+// -----------------------------
+// Реализация APIKeyService для PostgreSQL
+// -----------------------------
+type PostgresAPIKeyService struct {
+	DB *sql.DB
+}
+
+// Конструктор PostgresAPIKeyService
+func NewPostgresAPIKeyService(db *sql.DB) *PostgresAPIKeyService {
+	return &PostgresAPIKeyService{DB: db}
+}
+
+// CreateAPIKey генерирует ключ вида rak_<prefix>_<secret> и сохраняет его хэш
+func (s *PostgresAPIKeyService) CreateAPIKey(userID int, name string, scopes []string, expiresAt *time.Time) (string, *models.APIKey, error) {
+	key, prefix, err := generateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	k := models.APIKey{UserID: userID, Name: name, Prefix: prefix, Scopes: scopes, ExpiresAt: expiresAt}
+	err = s.DB.QueryRow(
+		`INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		userID, name, prefix, HashToken(key), pq.Array(scopes), expiresAt,
+	).Scan(&k.ID, &k.CreatedAt)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, &k, nil
+}
+
+// ListAPIKeys возвращает ключи пользователя, новые первыми
+func (s *PostgresAPIKeyService) ListAPIKeys(userID int) ([]models.APIKey, error) {
+	rows, err := s.DB.Query(
+		`SELECT id, user_id, name, prefix, scopes, created_at, last_used_at, expires_at, revoked_at
+		 FROM api_keys WHERE user_id=$1 ORDER BY created_at DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		if err := rows.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, pq.Array(&k.Scopes),
+			&k.CreatedAt, &k.LastUsedAt, &k.ExpiresAt, &k.RevokedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey помечает ключ отозванным
+func (s *PostgresAPIKeyService) RevokeAPIKey(userID, id int) error {
+	res, err := s.DB.Exec(
+		`UPDATE api_keys SET revoked_at=NOW() WHERE id=$1 AND user_id=$2 AND revoked_at IS NULL`,
+		id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey находит действующий ключ по хэшу и обновляет last_used_at одним запросом
+func (s *PostgresAPIKeyService) AuthenticateAPIKey(key string) (*models.APIKey, string, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, "", ErrInvalidAPIKey
+	}
+
+	var k models.APIKey
+	var role string
+	err := s.DB.QueryRow(
+		`UPDATE api_keys k SET last_used_at=NOW()
+		 FROM users u
+		 WHERE k.key_hash=$1 AND k.revoked_at IS NULL
+		   AND (k.expires_at IS NULL OR k.expires_at > NOW())
+		   AND u.id = k.user_id AND u.deleted_at IS NULL
+		 RETURNING k.id, k.user_id, k.name, k.prefix, k.scopes, k.created_at, k.last_used_at, k.expires_at, u.role`,
+		HashToken(key),
+	).Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, pq.Array(&k.Scopes),
+		&k.CreatedAt, &k.LastUsedAt, &k.ExpiresAt, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return &k, role, nil
+}
+
+// generateAPIKey возвращает ключ и его открытую часть (prefix)
+func generateAPIKey() (key, prefix string, err error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	prefix = APIKeyPrefix + hex.EncodeToString(b)
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return prefix + "_" + secret, prefix, nil
+}
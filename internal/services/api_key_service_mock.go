@@ -0,0 +1,91 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/models"
+)
+
+// -----------------------------
+// MockAPIKeyService
+// -----------------------------
+// In-memory реализация APIKeyService для юнит-тестов.
+// Роль владельца ключа берётся из Roles (по умолчанию member).
+type MockAPIKeyService struct {
+	Keys  []models.APIKey
+	Roles map[int]string
+	// hashes хранит хэш ключа по его ID
+	hashes map[int]string
+}
+
+// CreateAPIKey создаёт ключ в памяти
+func (m *MockAPIKeyService) CreateAPIKey(userID int, name string, scopes []string, expiresAt *time.Time) (string, *models.APIKey, error) {
+	key, prefix, err := generateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+	if m.hashes == nil {
+		m.hashes = map[int]string{}
+	}
+
+	k := models.APIKey{
+		ID:        len(m.Keys) + 1,
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	m.Keys = append(m.Keys, k)
+	m.hashes[k.ID] = HashToken(key)
+	return key, &k, nil
+}
+
+// ListAPIKeys возвращает ключи пользователя
+func (m *MockAPIKeyService) ListAPIKeys(userID int) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	for _, k := range m.Keys {
+		if k.UserID == userID {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey помечает ключ отозванным
+func (m *MockAPIKeyService) RevokeAPIKey(userID, id int) error {
+	for i, k := range m.Keys {
+		if k.ID == id && k.UserID == userID && k.RevokedAt == nil {
+			now := time.Now()
+			m.Keys[i].RevokedAt = &now
+			return nil
+		}
+	}
+	return ErrAPIKeyNotFound
+}
+
+// AuthenticateAPIKey ищет действующий ключ по хэшу
+func (m *MockAPIKeyService) AuthenticateAPIKey(key string) (*models.APIKey, string, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, "", ErrInvalidAPIKey
+	}
+	for i, k := range m.Keys {
+		if m.hashes[k.ID] != HashToken(key) || k.RevokedAt != nil {
+			continue
+		}
+		if k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt) {
+			continue
+		}
+
+		now := time.Now()
+		m.Keys[i].LastUsedAt = &now
+		role := m.Roles[k.UserID]
+		if role == "" {
+			role = "member"
+		}
+		return &m.Keys[i], role, nil
+	}
+	return nil, "", ErrInvalidAPIKey
+}
@@ -3,52 +3,122 @@ package services
 import (
 	"database/sql"
 	"errors"
+	"log"
+	"strings"
 
+	"github.com/go-portfolio/rest-api/internal/auth"
 	"github.com/go-portfolio/rest-api/internal/models"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/lib/pq"
 )
 
 // Интерфейс для работы с пользователями
 type UserService interface {
-    Authenticate(username, password string) (*models.User, error)
-	CreateUser(email, hashed string) (int, error)	
+	Authenticate(username, password string) (*models.User, error)
+	// Создать пользователя с уже захэшированным паролем
+	CreateUser(username, email, hashed string) (*models.User, error)
+	// Получить пользователя по ID; ErrUserNotFound, если его нет
+	GetUserByID(id int) (*models.User, error)
+	// Найти пользователя по email; ErrUserNotFound, если его нет
+	FindUserByEmail(email string) (models.User, error)
+	// Изменить профиль; nil-поля не меняются, смена email сбрасывает его подтверждение
+	UpdateProfile(id int, upd ProfileUpdate) (*models.User, error)
+	// Заменить хэш пароля и завершить все входы пользователя, кроме сессии
+	// keepSessionID, из которой меняется пароль (0 — завершить все)
+	UpdatePassword(id int, hashed string, keepSessionID int) error
+	// Soft-удалить пользователя: его задачи удаляются, ключи и токены отзываются
+	DeleteUser(id int) error
+	// Получить страницу активных пользователей
+	ListUsers(q UserQuery) (*UserPage, error)
 }
 
+// ProfileUpdate — изменяемые поля профиля; nil означает "не менять"
+type ProfileUpdate struct {
+	Username    *string
+	Email       *string
+	DisplayName *string
+}
+
+// UserQuery — параметры выборки списка пользователей
+type UserQuery struct {
+	// Search — подстрока логина, email или отображаемого имени (без учёта регистра)
+	Search string
+	Limit  int
+	Offset int
+}
+
+// UserPage — страница пользователей
+type UserPage struct {
+	Items []models.User
+	// Total — количество пользователей, подходящих под поиск
+	Total int
+}
+
+var (
+	ErrUserNotFound = newError(ErrNotFound, "user not found")
+	// ErrUsernameTaken — пользователь с таким логином уже существует
+	ErrUsernameTaken = newError(ErrConflict, "username already taken")
+	// ErrEmailTaken — пользователь с таким email уже существует
+	ErrEmailTaken = newError(ErrConflict, "email already taken")
+)
+
 // Реализация UserService для Postgres
 type PostgresUserService struct {
-    DB *sql.DB
+	DB    *sql.DB
 	Users []models.User
+	// Hasher проверяет пароли и пересчитывает устаревшие хэши при входе;
+	// nil — параметры по умолчанию
+	Hasher *auth.PasswordHasher
 }
 
 // Конструктор
 func NewPostgresUserService(db *sql.DB) *PostgresUserService {
-    return &PostgresUserService{DB: db}
+	return &PostgresUserService{DB: db}
 }
 
-
-
 func (p *PostgresUserService) Authenticate(username, password string) (*models.User, error) {
-    var user models.User
-    err := p.DB.QueryRow(`SELECT id, username, password_hash FROM users WHERE username=$1`, username).
-        Scan(&user.ID, &user.Username, &user.Password)
-    if err != nil {
-        return nil, err
-    }
+	var user models.User
+	var email sql.NullString
+	err := p.DB.QueryRow(
+		`SELECT id, username, email, password_hash, COALESCE(display_name, ''), role, email_verified_at, created_at
+		 FROM users WHERE username=$1 AND deleted_at IS NULL`,
+		username,
+	).Scan(&user.ID, &user.Username, &email, &user.Password, &user.DisplayName, &user.Role, &user.EmailVerifiedAt, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	user.Email = email.String
 
-    if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-        return nil, errors.New("invalid password")
-    }
+	needsRehash, err := p.Hasher.Verify(user.Password, password)
+	if err != nil {
+		return nil, errors.New("invalid password")
+	}
+	if needsRehash {
+		p.rehashPassword(&user, password)
+	}
 
-    return &user, nil
+	return &user, nil
 }
 
-
-var ErrUserNotFound = errors.New("user not found")
+// rehashPassword пересчитывает хэш пароля текущим алгоритмом и параметрами.
+// Хэш заменяется, только если его не успели изменить параллельно; ошибка
+// не мешает входу — хэш будет пересчитан при следующем входе.
+func (p *PostgresUserService) rehashPassword(user *models.User, password string) {
+	hash, err := p.Hasher.Hash(password)
+	if err == nil {
+		_, err = p.DB.Exec(`UPDATE users SET password_hash=$1 WHERE id=$2 AND password_hash=$3`,
+			hash, user.ID, user.Password)
+	}
+	if err != nil {
+		log.Printf("password rehash for user %d: %v", user.ID, err)
+		return
+	}
+	user.Password = hash
+}
 
 func (p *PostgresUserService) FindUserByEmail(email string) (models.User, error) {
 	var u models.User
-	row := p.DB.QueryRow(`SELECT id, email, password FROM users WHERE email = $1`, email)
-	if err := row.Scan(&u.ID, &u.Email, &u.Password); err != nil {
+	row := p.DB.QueryRow(`SELECT id, username, email, password_hash FROM users WHERE email = $1 AND deleted_at IS NULL`, email)
+	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.Password); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.User{}, ErrUserNotFound
 		}
@@ -57,8 +127,183 @@ func (p *PostgresUserService) FindUserByEmail(email string) (models.User, error)
 	return u, nil
 }
 
-func (p *PostgresUserService) CreateUser(email, hashed string) (int, error) {
-	var id int
-	err := p.DB.QueryRow(`INSERT INTO users(email, password) VALUES ($1, $2) RETURNING id`, email, hashed).Scan(&id)
-	return id, err
+// userColumns — поля пользователя без хэша пароля, в порядке scanUser
+const userColumns = `id, username, email, COALESCE(display_name, ''), role, email_verified_at, created_at`
+
+// scanUser читает строку, выбранную с userColumns
+func scanUser(row interface{ Scan(...interface{}) error }) (*models.User, error) {
+	var u models.User
+	var email sql.NullString
+	if err := row.Scan(&u.ID, &u.Username, &email, &u.DisplayName, &u.Role, &u.EmailVerifiedAt, &u.CreatedAt); err != nil {
+		return nil, err
+	}
+	u.Email = email.String
+	return &u, nil
+}
+
+// GetUserByID возвращает активного пользователя без хэша пароля
+func (p *PostgresUserService) GetUserByID(id int) (*models.User, error) {
+	u, err := scanUser(p.DB.QueryRow(`SELECT `+userColumns+` FROM users WHERE id=$1 AND deleted_at IS NULL`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return u, err
+}
+
+// UpdateProfile меняет заданные поля одним запросом; пустое отображаемое имя удаляется
+func (p *PostgresUserService) UpdateProfile(id int, upd ProfileUpdate) (*models.User, error) {
+	u, err := scanUser(p.DB.QueryRow(
+		`UPDATE users SET
+		     username = COALESCE($2::text, username),
+		     email = COALESCE($3::text, email),
+		     display_name = CASE WHEN $4::text IS NULL THEN display_name ELSE NULLIF($4::text, '') END,
+		     email_verified_at = CASE WHEN $3::text IS DISTINCT FROM email AND $3::text IS NOT NULL
+		                              THEN NULL ELSE email_verified_at END
+		 WHERE id=$1 AND deleted_at IS NULL
+		 RETURNING `+userColumns,
+		id, upd.Username, upd.Email, upd.DisplayName,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, uniqueViolation(err)
+	}
+	return u, nil
+}
+
+// UpdatePassword в одной транзакции меняет хэш пароля, завершает сессии
+// и отзывает refresh-токены пользователя, кроме сессии keepSessionID
+func (p *PostgresUserService) UpdatePassword(id int, hashed string, keepSessionID int) error {
+	tx, err := p.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE users SET password_hash=$1 WHERE id=$2 AND deleted_at IS NULL`, hashed, id)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrUserNotFound
+	}
+	// Токены завершённых сессий (claim sid) отклоняются auth.WithSessions
+	for _, query := range []string{
+		`UPDATE sessions SET revoked_at=NOW() WHERE user_id=$1 AND revoked_at IS NULL AND id <> $2`,
+		`UPDATE refresh_tokens SET revoked_at=NOW()
+		 WHERE user_id=$1 AND revoked_at IS NULL AND ($2 = 0 OR session_id IS DISTINCT FROM $2)`,
+	} {
+		if _, err := tx.Exec(query, id, keepSessionID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteUser помечает пользователя удалённым. В той же транзакции
+// soft-удаляются его задачи и отзываются refresh-токены, сессии,
+// API-ключи и OAuth-клиенты. Логин и email остаются занятыми.
+func (p *PostgresUserService) DeleteUser(id int) error {
+	tx, err := p.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE users SET deleted_at=NOW() WHERE id=$1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrUserNotFound
+	}
+
+	for _, query := range []string{
+		`UPDATE tasks SET deleted_at=NOW() WHERE user_id=$1 AND deleted_at IS NULL`,
+		`UPDATE refresh_tokens SET revoked_at=NOW() WHERE user_id=$1 AND revoked_at IS NULL`,
+		`UPDATE sessions SET revoked_at=NOW() WHERE user_id=$1 AND revoked_at IS NULL`,
+		`UPDATE api_keys SET revoked_at=NOW() WHERE user_id=$1 AND revoked_at IS NULL`,
+		`UPDATE oauth_clients SET revoked_at=NOW() WHERE owner_id=$1 AND revoked_at IS NULL`,
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListUsers возвращает активных пользователей по возрастанию ID
+func (p *PostgresUserService) ListUsers(q UserQuery) (*UserPage, error) {
+	where := &sqlWhere{}
+	where.add("deleted_at IS NULL")
+	if q.Search != "" {
+		pattern := "%" + escapeLike(q.Search) + "%"
+		where.add("(username ILIKE %[1]s OR email ILIKE %[1]s OR display_name ILIKE %[1]s)", pattern)
+	}
+
+	page := &UserPage{Items: []models.User{}}
+	if err := p.DB.QueryRow("SELECT COUNT(*) FROM users WHERE "+where.String(), where.args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + userColumns + " FROM users WHERE " + where.String() + " ORDER BY id"
+	if q.Limit > 0 {
+		query += " LIMIT " + where.arg(q.Limit)
+	}
+	if q.Offset > 0 {
+		query += " OFFSET " + where.arg(q.Offset)
+	}
+	rows, err := p.DB.Query(query, where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, *u)
+	}
+	return page, rows.Err()
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// CreateUser добавляет пользователя; нарушение уникальности логина или email
+// возвращается как ErrUsernameTaken / ErrEmailTaken
+func (p *PostgresUserService) CreateUser(username, email, hashed string) (*models.User, error) {
+	u := models.User{Username: username, Email: email, Password: hashed}
+	err := p.DB.QueryRow(
+		`INSERT INTO users(username, email, password_hash) VALUES ($1, $2, $3) RETURNING id, role, created_at`,
+		username, email, hashed,
+	).Scan(&u.ID, &u.Role, &u.CreatedAt)
+	if err != nil {
+		return nil, uniqueViolation(err)
+	}
+	return &u, nil
+}
+
+// uniqueViolation переводит ошибку уникальности PostgreSQL (код 23505) в доменную ошибку
+func uniqueViolation(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		return err
+	}
+	switch pqErr.Constraint {
+	case "users_username_key":
+		return ErrUsernameTaken
+	case "users_email_key":
+		return ErrEmailTaken
+	}
+	return err
 }
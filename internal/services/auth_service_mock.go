@@ -2,19 +2,173 @@ package services
 
 import (
 	"errors"
+	"strings"
+	"time"
 
+	"github.com/go-portfolio/rest-api/internal/auth"
 	"github.com/go-portfolio/rest-api/internal/models"
 )
 
 type MockUserService struct {
-    Users []models.User // можно хранить пароль в явном виде для простоты
+	Users []models.User // можно хранить пароль в явном виде для простоты
+	// Hasher проверяет хэши паролей, сохранённые обработчиками; устаревшие хэши
+	// пересчитываются, как в PostgresUserService
+	Hasher *auth.PasswordHasher
+	// Sessions, если задан, получает завершение сессий при смене пароля
+	Sessions *MockSessionService
 }
 
 func (m *MockUserService) Authenticate(username, password string) (*models.User, error) {
-    for _, u := range m.Users {
-        if u.Username == username && u.Password == password { // в mock можно хранить plain password
-            return &u, nil
-        }
-    }
-    return nil, errors.New("invalid username or password")
+	for i, u := range m.Users {
+		if u.Username != username || u.DeletedAt != nil {
+			continue
+		}
+		if u.Password == password { // в mock можно хранить plain password
+			return &u, nil
+		}
+		needsRehash, err := m.Hasher.Verify(u.Password, password)
+		if err != nil {
+			break
+		}
+		if needsRehash {
+			if hash, err := m.Hasher.Hash(password); err == nil {
+				m.Users[i].Password = hash
+				u.Password = hash
+			}
+		}
+		return &u, nil
+	}
+	return nil, errors.New("invalid username or password")
+}
+
+// CreateUser добавляет пользователя в m.Users, проверяя уникальность логина и email
+func (m *MockUserService) CreateUser(username, email, hashed string) (*models.User, error) {
+	for _, u := range m.Users {
+		if u.Username == username {
+			return nil, ErrUsernameTaken
+		}
+		if email != "" && u.Email == email {
+			return nil, ErrEmailTaken
+		}
+	}
+
+	u := models.User{
+		ID:        len(m.Users) + 1,
+		Username:  username,
+		Email:     email,
+		Password:  hashed,
+		Role:      "member",
+		CreatedAt: time.Now(),
+	}
+	m.Users = append(m.Users, u)
+	return &u, nil
+}
+
+// GetUserByID ищет пользователя в m.Users
+func (m *MockUserService) GetUserByID(id int) (*models.User, error) {
+	for _, u := range m.Users {
+		if u.ID == id && u.DeletedAt == nil {
+			return &u, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// FindUserByEmail ищет пользователя в m.Users по email
+func (m *MockUserService) FindUserByEmail(email string) (models.User, error) {
+	for _, u := range m.Users {
+		if email != "" && u.Email == email && u.DeletedAt == nil {
+			return u, nil
+		}
+	}
+	return models.User{}, ErrUserNotFound
+}
+
+// UpdateProfile меняет поля пользователя в m.Users, проверяя уникальность
+func (m *MockUserService) UpdateProfile(id int, upd ProfileUpdate) (*models.User, error) {
+	i := m.index(id)
+	if i < 0 {
+		return nil, ErrUserNotFound
+	}
+	for _, u := range m.Users {
+		if u.ID == id {
+			continue
+		}
+		if upd.Username != nil && u.Username == *upd.Username {
+			return nil, ErrUsernameTaken
+		}
+		if upd.Email != nil && *upd.Email != "" && u.Email == *upd.Email {
+			return nil, ErrEmailTaken
+		}
+	}
+
+	u := &m.Users[i]
+	if upd.Username != nil {
+		u.Username = *upd.Username
+	}
+	if upd.Email != nil && *upd.Email != u.Email {
+		u.Email = *upd.Email
+		u.EmailVerifiedAt = nil
+	}
+	if upd.DisplayName != nil {
+		u.DisplayName = *upd.DisplayName
+	}
+	result := *u
+	return &result, nil
+}
+
+// UpdatePassword сохраняет новый хэш пароля и завершает остальные сессии
+func (m *MockUserService) UpdatePassword(id int, hashed string, keepSessionID int) error {
+	i := m.index(id)
+	if i < 0 {
+		return ErrUserNotFound
+	}
+	m.Users[i].Password = hashed
+	m.Sessions.revokeUserSessions(id, keepSessionID)
+	return nil
+}
+
+// DeleteUser помечает пользователя удалённым
+func (m *MockUserService) DeleteUser(id int) error {
+	i := m.index(id)
+	if i < 0 {
+		return ErrUserNotFound
+	}
+	now := time.Now()
+	m.Users[i].DeletedAt = &now
+	return nil
+}
+
+// ListUsers возвращает активных пользователей, подходящих под поиск
+func (m *MockUserService) ListUsers(q UserQuery) (*UserPage, error) {
+	search := strings.ToLower(q.Search)
+	page := &UserPage{Items: []models.User{}}
+	for _, u := range m.Users {
+		if u.DeletedAt != nil {
+			continue
+		}
+		if search != "" &&
+			!strings.Contains(strings.ToLower(u.Username), search) &&
+			!strings.Contains(strings.ToLower(u.Email), search) &&
+			!strings.Contains(strings.ToLower(u.DisplayName), search) {
+			continue
+		}
+		page.Total++
+		if page.Total <= q.Offset || (q.Limit > 0 && len(page.Items) >= q.Limit) {
+			continue
+		}
+		u.Password = ""
+		page.Items = append(page.Items, u)
+	}
+	return page, nil
+}
+
+// index возвращает позицию активного пользователя в m.Users или -1
+func (m *MockUserService) index(id int) int {
+	for i, u := range m.Users {
+		if u.ID == id && u.DeletedAt == nil {
+			return i
+		}
+	}
+	return -1
 }
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// DefaultVerifyTokenTTL — срок жизни ссылки подтверждения email по умолчанию
+const DefaultVerifyTokenTTL = 48 * time.Hour
+
+// ErrInvalidVerifyToken — токен не найден, истёк, уже использован или email с тех пор изменился
+var ErrInvalidVerifyToken = errors.New("invalid or expired verification token")
+
+// -----------------------------
+// Интерфейс EmailVerificationService
+// -----------------------------
+// Одноразовые токены подтверждения email. Токен привязан к адресу,
+// на который отправлено письмо: после смены email он перестаёт действовать.
+type EmailVerificationService interface {
+	// Выдать токен подтверждения адреса email пользователя userID
+	CreateVerificationToken(userID int, email string) (string, error)
+	// Подтвердить email по токену; возвращает ID пользователя
+	VerifyEmail(token string) (int, error)
+}
+
+// -----------------------------
+// Реализация EmailVerificationService для PostgreSQL
+// -----------------------------
+type PostgresEmailVerificationService struct {
+	DB  *sql.DB
+	TTL time.Duration // срок жизни токена
+}
+
+// Конструктор PostgresEmailVerificationService
+func NewPostgresEmailVerificationService(db *sql.DB) *PostgresEmailVerificationService {
+	return &PostgresEmailVerificationService{DB: db, TTL: DefaultVerifyTokenTTL}
+}
+
+// CreateVerificationToken сохраняет хэш нового токена
+func (s *PostgresEmailVerificationService) CreateVerificationToken(userID int, email string) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	_, err = s.DB.Exec(
+		`INSERT INTO email_verification_tokens (user_id, email, token_hash, expires_at) VALUES ($1, $2, $3, $4)`,
+		userID, email, HashToken(token), time.Now().Add(s.TTL),
+	)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// VerifyEmail гасит токен и отмечает email подтверждённым, если адрес не менялся
+func (s *PostgresEmailVerificationService) VerifyEmail(token string) (int, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var (
+		id, userID int
+		email      string
+		expiresAt  time.Time
+		usedAt     sql.NullTime
+	)
+	err = tx.QueryRow(
+		`SELECT id, user_id, email, expires_at, used_at FROM email_verification_tokens
+		 WHERE token_hash=$1 FOR UPDATE`,
+		HashToken(token),
+	).Scan(&id, &userID, &email, &expiresAt, &usedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidVerifyToken
+	}
+	if err != nil {
+		return 0, err
+	}
+	if usedAt.Valid || time.Now().After(expiresAt) {
+		return 0, ErrInvalidVerifyToken
+	}
+
+	if _, err := tx.Exec(`UPDATE email_verification_tokens SET used_at=NOW() WHERE id=$1`, id); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(
+		`UPDATE users SET email_verified_at=COALESCE(email_verified_at, NOW()) WHERE id=$1 AND email=$2`,
+		userID, email,
+	)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected == 0 {
+		return 0, ErrInvalidVerifyToken
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
@@ -0,0 +1,56 @@
+package services
+
+import "time"
+
+// -----------------------------
+// MockEmailVerificationService
+// -----------------------------
+// In-memory реализация EmailVerificationService для юнит-тестов.
+// Подтверждение записывается пользователю в Users.
+type MockEmailVerificationService struct {
+	Users  *MockUserService
+	Tokens map[string]*MockVerifyToken // ключ — сам токен
+}
+
+// MockVerifyToken — состояние токена подтверждения в моке
+type MockVerifyToken struct {
+	UserID    int
+	Email     string
+	ExpiresAt time.Time
+	Used      bool
+}
+
+// CreateVerificationToken выдаёт токен
+func (m *MockEmailVerificationService) CreateVerificationToken(userID int, email string) (string, error) {
+	if m.Tokens == nil {
+		m.Tokens = map[string]*MockVerifyToken{}
+	}
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	m.Tokens[token] = &MockVerifyToken{UserID: userID, Email: email, ExpiresAt: time.Now().Add(DefaultVerifyTokenTTL)}
+	return token, nil
+}
+
+// VerifyEmail гасит токен и отмечает email пользователя подтверждённым
+func (m *MockEmailVerificationService) VerifyEmail(token string) (int, error) {
+	t, ok := m.Tokens[token]
+	if !ok || t.Used || time.Now().After(t.ExpiresAt) {
+		return 0, ErrInvalidVerifyToken
+	}
+	t.Used = true
+	if m.Users != nil {
+		for i := range m.Users.Users {
+			u := &m.Users.Users[i]
+			if u.ID == t.UserID && u.Email == t.Email {
+				if u.EmailVerifiedAt == nil {
+					now := time.Now()
+					u.EmailVerifiedAt = &now
+				}
+				return t.UserID, nil
+			}
+		}
+	}
+	return 0, ErrInvalidVerifyToken
+}
@@ -0,0 +1,37 @@
+package services
+
+import "errors"
+
+// -----------------------------
+// Доменные ошибки
+// -----------------------------
+// Категории ошибок сервисного слоя. Конкретные ошибки (ErrTaskNotFound,
+// ErrUsernameTaken, ...) относятся к одной из категорий, поэтому обработчики
+// выбирают HTTP-статус через errors.Is, не зная о каждой ошибке отдельно.
+// Текст доменных ошибок можно показывать клиенту; остальные ошибки
+// (например, ошибки базы данных) клиенту не передаются.
+var (
+	// ErrNotFound — объект не существует или недоступен пользователю
+	ErrNotFound = errors.New("not found")
+	// ErrConflict — операция противоречит текущему состоянию (дубликат, устаревшая версия)
+	ErrConflict = errors.New("conflict")
+	// ErrForbidden — операция запрещена пользователю
+	ErrForbidden = errors.New("forbidden")
+	// ErrValidation — некорректные входные данные
+	ErrValidation = errors.New("validation failed")
+)
+
+// domainError — ошибка со своим текстом, относящаяся к категории kind
+type domainError struct {
+	kind error
+	msg  string
+}
+
+func (e *domainError) Error() string { return e.msg }
+
+func (e *domainError) Unwrap() error { return e.kind }
+
+// newError создаёт доменную ошибку категории kind с текстом msg
+func newError(kind error, msg string) error {
+	return &domainError{kind: kind, msg: msg}
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-portfolio/rest-api/internal/models"
+)
+
+// ExternalIdentity — пользователь внешнего провайдера (claims ID-токена)
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string // предпочтительный логин (preferred_username)
+}
+
+// -----------------------------
+// Интерфейс IdentityService
+// -----------------------------
+// Вход через внешних провайдеров. Пользователь ищется по паре
+// (provider, subject); при первом входе учётная запись привязывается
+// к пользователю с тем же email — только если email подтвердили и провайдер,
+// и сам пользователь, — иначе создаётся новый пользователь без пароля.
+type IdentityService interface {
+	LoginExternal(id ExternalIdentity) (*models.User, error)
+}
+
+// -----------------------------
+// Реализация IdentityService для PostgreSQL
+// -----------------------------
+type PostgresIdentityService struct {
+	DB *sql.DB
+}
+
+// Конструктор PostgresIdentityService
+func NewPostgresIdentityService(db *sql.DB) *PostgresIdentityService {
+	return &PostgresIdentityService{DB: db}
+}
+
+// externalPasswordHash — заведомо неподходящий хэш: войти по паролю нельзя,
+// пока пользователь не задаст пароль через сброс
+const externalPasswordHash = "!external"
+
+// maxUsernameAttempts — сколько суффиксов пробуем при занятом логине
+const maxUsernameAttempts = 20
+
+// LoginExternal находит, привязывает или создаёт пользователя
+func (s *PostgresIdentityService) LoginExternal(id ExternalIdentity) (*models.User, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1. Уже привязанная учётная запись
+	var userID int
+	err = tx.QueryRow(
+		`UPDATE user_identities SET last_login_at=NOW() WHERE provider=$1 AND subject=$2 RETURNING user_id`,
+		id.Provider, id.Subject,
+	).Scan(&userID)
+	switch {
+	case err == nil:
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return s.user(userID)
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	// 2. Привязка к существующему пользователю по подтверждённому email.
+	// Email должен быть подтверждён и провайдером, и у нас: иначе любой мог бы
+	// зарегистрировать чужой адрес со своим паролем и дождаться входа владельца.
+	// Email уникален с учётом регистра, поэтому сравниваем точно — так
+	// совпадение всегда однозначно.
+	withEmail := id.EmailVerified && id.Email != ""
+	if withEmail {
+		var verified bool
+		err = tx.QueryRow(
+			`SELECT id, email_verified_at IS NOT NULL AND deleted_at IS NULL FROM users WHERE email=$1`,
+			id.Email,
+		).Scan(&userID, &verified)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			// Адрес свободен — пользователь будет создан с ним
+		case err != nil:
+			return nil, err
+		case !verified:
+			// Адрес занят неподтверждённой или удалённой учётной записью:
+			// не привязываемся к ней и создаём пользователя без email
+			userID, withEmail = 0, false
+		}
+	}
+
+	// 3. Новый пользователь
+	if userID == 0 {
+		if userID, err = s.createUser(tx, id, withEmail); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO user_identities (user_id, provider, subject, email, last_login_at) VALUES ($1, $2, $3, NULLIF($4, ''), NOW())`,
+		userID, id.Provider, id.Subject, id.Email,
+	); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.user(userID)
+}
+
+// createUser создаёт пользователя без пароля, подбирая свободный логин.
+// Email провайдера сохраняется (подтверждённым) только при withEmail.
+func (s *PostgresIdentityService) createUser(tx *sql.Tx, id ExternalIdentity, withEmail bool) (int, error) {
+	var email interface{}
+	if withEmail {
+		email = id.Email
+	}
+
+	base := ExternalUsername(id)
+	for i := 0; i < maxUsernameAttempts; i++ {
+		username := base
+		if i > 0 {
+			username = fmt.Sprintf("%s%d", truncate(base, 18), i+1)
+		}
+
+		// Точка сохранения позволяет продолжить транзакцию после конфликта логина
+		if _, err := tx.Exec(`SAVEPOINT create_user`); err != nil {
+			return 0, err
+		}
+		var userID int
+		err := tx.QueryRow(
+			`INSERT INTO users (username, email, password_hash, email_verified_at)
+			 VALUES ($1, $2, $3, CASE WHEN $4 THEN NOW() END) RETURNING id`,
+			username, email, externalPasswordHash, email != nil,
+		).Scan(&userID)
+		if err == nil {
+			return userID, nil
+		}
+		if _, rbErr := tx.Exec(`ROLLBACK TO SAVEPOINT create_user`); rbErr != nil {
+			return 0, rbErr
+		}
+		if !errors.Is(uniqueViolation(err), ErrUsernameTaken) {
+			return 0, uniqueViolation(err)
+		}
+	}
+	return 0, ErrUsernameTaken
+}
+
+func (s *PostgresIdentityService) user(id int) (*models.User, error) {
+	return (&PostgresUserService{DB: s.DB}).GetUserByID(id)
+}
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// ExternalUsername подбирает логин из preferred_username или email:
+// допустимые символы, длина 3–20 (как у models.User)
+func ExternalUsername(id ExternalIdentity) string {
+	name := id.Username
+	if name == "" {
+		name, _, _ = strings.Cut(id.Email, "@")
+	}
+	name = usernameInvalidChars.ReplaceAllString(strings.ToLower(name), "")
+	name = truncate(name, 20)
+	for len(name) < 3 {
+		name += "_"
+	}
+	return name
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/models"
+)
+
+// -----------------------------
+// MockIdentityService
+// -----------------------------
+// In-memory реализация IdentityService для юнит-тестов.
+// Пользователи создаются и ищутся в Users.
+type MockIdentityService struct {
+	Users      *MockUserService
+	Identities map[string]int // "provider|subject" → ID пользователя
+}
+
+// LoginExternal повторяет правила PostgresIdentityService
+func (m *MockIdentityService) LoginExternal(id ExternalIdentity) (*models.User, error) {
+	if m.Identities == nil {
+		m.Identities = map[string]int{}
+	}
+	key := id.Provider + "|" + id.Subject
+	if userID, ok := m.Identities[key]; ok {
+		return m.Users.GetUserByID(userID)
+	}
+
+	email := ""
+	if id.EmailVerified {
+		email = id.Email
+	}
+	if email != "" {
+		for _, u := range m.Users.Users {
+			if u.Email != email {
+				continue
+			}
+			if u.EmailVerifiedAt == nil || u.DeletedAt != nil {
+				email = ""
+				break
+			}
+			m.Identities[key] = u.ID
+			return m.Users.GetUserByID(u.ID)
+		}
+	}
+
+	base := ExternalUsername(id)
+	for i := 0; i < maxUsernameAttempts; i++ {
+		username := base
+		if i > 0 {
+			username = fmt.Sprintf("%s%d", truncate(base, 18), i+1)
+		}
+		u, err := m.Users.CreateUser(username, email, externalPasswordHash)
+		if err == ErrUsernameTaken {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if email != "" {
+			now := time.Now()
+			m.Users.Users[len(m.Users.Users)-1].EmailVerifiedAt = &now
+			u.EmailVerifiedAt = &now
+		}
+		m.Identities[key] = u.ID
+		return u, nil
+	}
+	return nil, ErrUsernameTaken
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"database/sql"
+
+	"github.com/go-portfolio/rest-api/internal/models"
+)
+
+// -----------------------------
+// Интерфейс ImpersonationAuditService
+// -----------------------------
+// Журнал действий администраторов от имени пользователей. Запись создаётся
+// до выполнения запроса (если записать не удалось, запрос не выполняется),
+// а статус ответа дописывается после.
+type ImpersonationAuditService interface {
+	// Записать действие; возвращает ID записи
+	RecordImpersonation(e models.ImpersonationEvent) (int, error)
+	// Дописать HTTP-статус ответа к записи id
+	CompleteImpersonation(id, status int) error
+}
+
+// -----------------------------
+// Реализация ImpersonationAuditService для PostgreSQL
+// -----------------------------
+type PostgresImpersonationAuditService struct {
+	DB *sql.DB
+}
+
+// Конструктор PostgresImpersonationAuditService
+func NewPostgresImpersonationAuditService(db *sql.DB) *PostgresImpersonationAuditService {
+	return &PostgresImpersonationAuditService{DB: db}
+}
+
+// RecordImpersonation сохраняет запись журнала
+func (s *PostgresImpersonationAuditService) RecordImpersonation(e models.ImpersonationEvent) (int, error) {
+	var id int
+	err := s.DB.QueryRow(
+		`INSERT INTO impersonation_audit (admin_id, user_id, token_id, action, method, path, ip, status)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		e.AdminID, e.UserID, e.TokenID, e.Action, e.Method, e.Path, e.IP, e.Status,
+	).Scan(&id)
+	return id, err
+}
+
+// CompleteImpersonation записывает статус ответа
+func (s *PostgresImpersonationAuditService) CompleteImpersonation(id, status int) error {
+	_, err := s.DB.Exec(`UPDATE impersonation_audit SET status=$2 WHERE id=$1`, id, status)
+	return err
+}
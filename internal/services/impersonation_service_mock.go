@@ -0,0 +1,38 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/models"
+)
+
+// -----------------------------
+// MockImpersonationAuditService
+// -----------------------------
+// In-memory реализация ImpersonationAuditService для юнит-тестов.
+// Err, если задан, возвращается при записи — для проверки отказа журнала.
+type MockImpersonationAuditService struct {
+	Events []models.ImpersonationEvent
+	Err    error
+}
+
+// RecordImpersonation сохраняет запись в памяти
+func (m *MockImpersonationAuditService) RecordImpersonation(e models.ImpersonationEvent) (int, error) {
+	if m.Err != nil {
+		return 0, m.Err
+	}
+	e.ID = len(m.Events) + 1
+	e.CreatedAt = time.Now()
+	m.Events = append(m.Events, e)
+	return e.ID, nil
+}
+
+// CompleteImpersonation записывает статус ответа
+func (m *MockImpersonationAuditService) CompleteImpersonation(id, status int) error {
+	if id < 1 || id > len(m.Events) {
+		return errors.New("impersonation event not found")
+	}
+	m.Events[id-1].Status = status
+	return nil
+}
@@ -67,7 +67,11 @@ func TestFullIntegration(t *testing.T) {
 	// --------------------------
 	// 1. Генерация JWT для пользователя
 	// --------------------------
-	token, err := auth.GenerateToken(1, cfg.Jwt.JwtSecretKey) // user_id = 1
+	tm, err := auth.NewTokenManager(cfg.Jwt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := tm.GenerateToken(auth.Principal{UserID: 1}) // user_id = 1
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -77,8 +81,8 @@ func TestFullIntegration(t *testing.T) {
 	// Создаём тестовый сервер с middleware проверки токена
 	// --------------------------
 	ts := httptest.NewServer(
-		auth.VerifyToken(cfg.Jwt.JwtSecretKey)(
-			http.HandlerFunc(server.TasksHandler(taskSvc)),
+		auth.VerifyToken(tm)(
+			http.HandlerFunc(server.TasksHandler(taskSvc, false)),
 		),
 	)
 	defer ts.Close()
@@ -38,9 +38,13 @@ func TestCreateAndGetTask(t *testing.T) {
 
 	userID := 1
 	// Генерируем тестовый токен для пользователя с ID=1
-	token, _ := auth.GenerateToken(userID, cfg.Jwt.JwtSecretKey)
-	ts := httptest.NewServer(auth.VerifyToken(cfg.Jwt.JwtSecretKey)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		server.TasksHandler(realSvc).ServeHTTP(w, r)
+	tm, err := auth.NewTokenManager(cfg.Jwt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := tm.GenerateToken(auth.Principal{UserID: userID})
+	ts := httptest.NewServer(auth.VerifyToken(tm)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.TasksHandler(realSvc, false).ServeHTTP(w, r)
 	})))
 	defer ts.Close() // Закрываем сервер после завершения теста
 
@@ -112,11 +116,15 @@ func TestUpdateTask(t *testing.T) {
 
 	userID := 1
 	// Генерируем тестовый токен
-	token, _ := auth.GenerateToken(userID, cfg.Jwt.JwtSecretKey)
+	tm, err := auth.NewTokenManager(cfg.Jwt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := tm.GenerateToken(auth.Principal{UserID: userID})
 
 	// Создаём HTTP тестовый сервер с авторизацией
-	ts := httptest.NewServer(auth.VerifyToken(cfg.Jwt.JwtSecretKey)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		server.TasksHandler(realSvc).ServeHTTP(w, r)
+	ts := httptest.NewServer(auth.VerifyToken(tm)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.TasksHandler(realSvc, false).ServeHTTP(w, r)
 	})))
 	defer ts.Close()
 
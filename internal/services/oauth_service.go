@@ -0,0 +1,272 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/models"
+	"github.com/lib/pq"
+)
+
+// OAuthClientPrefix — начало идентификатора каждого OAuth-клиента
+const OAuthClientPrefix = "cl_"
+
+// DefaultAuthCodeTTL — срок жизни кода авторизации (RFC 6749 рекомендует не больше 10 минут)
+const DefaultAuthCodeTTL = 5 * time.Minute
+
+var (
+	// ErrOAuthClientNotFound — клиент не существует или уже отозван
+	ErrOAuthClientNotFound = newError(ErrNotFound, "oauth client not found")
+	// ErrInvalidClient — неизвестный или отозванный клиент либо неверный секрет
+	ErrInvalidClient = errors.New("invalid oauth client")
+	// ErrInvalidAuthCode — код не найден, истёк, уже использован или выдан другому клиенту
+	ErrInvalidAuthCode = errors.New("invalid authorization code")
+)
+
+// AuthCode — данные, сохранённые при выдаче кода авторизации
+type AuthCode struct {
+	ClientID        string
+	UserID          int
+	RedirectURI     string
+	RedirectURISent bool // redirect_uri передан явно — тогда он обязателен в /oauth/token (RFC 6749, 4.1.3)
+	Scopes          []string
+	CodeChallenge   string // PKCE S256; пустой, если клиент не передал code_challenge
+}
+
+// -----------------------------
+// Интерфейс OAuthService
+// -----------------------------
+// Хранит клиентов OAuth 2.0 и коды авторизации. Секреты клиентов и
+// коды хранятся только в виде SHA-256 и показываются один раз.
+type OAuthService interface {
+	// Зарегистрировать клиента; для конфиденциального клиента возвращает секрет
+	CreateClient(c models.OAuthClient) (secret string, client *models.OAuthClient, err error)
+	// Список всех клиентов, включая отозванные
+	ListClients() ([]models.OAuthClient, error)
+	// Отозвать клиента
+	RevokeClient(clientID string) error
+	// Найти действующего клиента
+	GetClient(clientID string) (*models.OAuthClient, error)
+	// Проверить, что клиент существует и не отозван (для токенов, выданных клиенту)
+	ClientActive(clientID string) (bool, error)
+	// Проверить client_id и секрет; у публичного клиента секрет должен быть пустым
+	AuthenticateClient(clientID, secret string) (*models.OAuthClient, error)
+	// Выдать одноразовый код авторизации
+	CreateAuthCode(code AuthCode) (string, error)
+	// Погасить код, выданный клиенту clientID
+	ConsumeAuthCode(code, clientID string) (*AuthCode, error)
+}
+
+// -----------------------------
+// Реализация OAuthService для PostgreSQL
+// -----------------------------
+type PostgresOAuthService struct {
+	DB      *sql.DB
+	CodeTTL time.Duration // срок жизни кода авторизации
+}
+
+// Конструктор PostgresOAuthService
+func NewPostgresOAuthService(db *sql.DB) *PostgresOAuthService {
+	return &PostgresOAuthService{DB: db, CodeTTL: DefaultAuthCodeTTL}
+}
+
+// CreateClient генерирует client_id (и секрет для конфиденциального клиента) и сохраняет клиента
+func (s *PostgresOAuthService) CreateClient(c models.OAuthClient) (string, *models.OAuthClient, error) {
+	clientID, secret, err := generateClientCredentials(c.Public)
+	if err != nil {
+		return "", nil, err
+	}
+	var secretHash sql.NullString
+	if secret != "" {
+		secretHash = sql.NullString{String: HashToken(secret), Valid: true}
+	}
+
+	c.ClientID = clientID
+	err = s.DB.QueryRow(
+		`INSERT INTO oauth_clients (client_id, secret_hash, name, owner_id, redirect_uris, grant_types, scopes, public)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`,
+		clientID, secretHash, c.Name, c.OwnerID,
+		pq.Array(c.RedirectURIs), pq.Array(c.GrantTypes), pq.Array(c.Scopes), c.Public,
+	).Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		return "", nil, err
+	}
+	return secret, &c, nil
+}
+
+// ListClients возвращает всех клиентов, новые первыми
+func (s *PostgresOAuthService) ListClients() ([]models.OAuthClient, error) {
+	rows, err := s.DB.Query(
+		`SELECT id, client_id, name, owner_id, redirect_uris, grant_types, scopes, public, created_at, revoked_at
+		 FROM oauth_clients ORDER BY created_at DESC, id DESC`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []models.OAuthClient{}
+	for rows.Next() {
+		var c models.OAuthClient
+		if err := rows.Scan(&c.ID, &c.ClientID, &c.Name, &c.OwnerID, pq.Array(&c.RedirectURIs),
+			pq.Array(&c.GrantTypes), pq.Array(&c.Scopes), &c.Public, &c.CreatedAt, &c.RevokedAt); err != nil {
+			return nil, err
+		}
+		clients = append(clients, c)
+	}
+	return clients, rows.Err()
+}
+
+// RevokeClient помечает клиента отозванным; его неиспользованные коды удаляются
+func (s *PostgresOAuthService) RevokeClient(clientID string) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE oauth_clients SET revoked_at=NOW() WHERE client_id=$1 AND revoked_at IS NULL`,
+		clientID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrOAuthClientNotFound
+	}
+	if _, err := tx.Exec(`DELETE FROM oauth_authorization_codes WHERE client_id=$1`, clientID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetClient находит действующего клиента по client_id
+func (s *PostgresOAuthService) GetClient(clientID string) (*models.OAuthClient, error) {
+	c, _, err := s.getClient(clientID)
+	return c, err
+}
+
+// ClientActive сообщает, что клиент существует и не отозван
+func (s *PostgresOAuthService) ClientActive(clientID string) (bool, error) {
+	var active bool
+	err := s.DB.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM oauth_clients WHERE client_id=$1 AND revoked_at IS NULL)`,
+		clientID,
+	).Scan(&active)
+	return active, err
+}
+
+// AuthenticateClient сверяет секрет клиента за постоянное время
+func (s *PostgresOAuthService) AuthenticateClient(clientID, secret string) (*models.OAuthClient, error) {
+	c, secretHash, err := s.getClient(clientID)
+	if errors.Is(err, ErrOAuthClientNotFound) {
+		return nil, ErrInvalidClient
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !clientSecretMatches(c, secretHash, secret) {
+		return nil, ErrInvalidClient
+	}
+	return c, nil
+}
+
+// getClient возвращает действующего клиента и хэш его секрета
+func (s *PostgresOAuthService) getClient(clientID string) (*models.OAuthClient, string, error) {
+	var c models.OAuthClient
+	var secretHash sql.NullString
+	err := s.DB.QueryRow(
+		`SELECT id, client_id, secret_hash, name, owner_id, redirect_uris, grant_types, scopes, public, created_at
+		 FROM oauth_clients WHERE client_id=$1 AND revoked_at IS NULL`,
+		clientID,
+	).Scan(&c.ID, &c.ClientID, &secretHash, &c.Name, &c.OwnerID, pq.Array(&c.RedirectURIs),
+		pq.Array(&c.GrantTypes), pq.Array(&c.Scopes), &c.Public, &c.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrOAuthClientNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return &c, secretHash.String, nil
+}
+
+// CreateAuthCode сохраняет хэш нового кода авторизации
+func (s *PostgresOAuthService) CreateAuthCode(code AuthCode) (string, error) {
+	raw, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	var challenge sql.NullString
+	if code.CodeChallenge != "" {
+		challenge = sql.NullString{String: code.CodeChallenge, Valid: true}
+	}
+
+	_, err = s.DB.Exec(
+		`INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, redirect_uri_sent, scopes, code_challenge, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		HashToken(raw), code.ClientID, code.UserID, code.RedirectURI, code.RedirectURISent, pq.Array(code.Scopes), challenge,
+		time.Now().Add(s.CodeTTL),
+	)
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// ConsumeAuthCode помечает код использованным одним запросом, поэтому
+// параллельные обмены одного кода не выдадут два токена
+func (s *PostgresOAuthService) ConsumeAuthCode(code, clientID string) (*AuthCode, error) {
+	var c AuthCode
+	var challenge sql.NullString
+	err := s.DB.QueryRow(
+		`UPDATE oauth_authorization_codes SET used_at=NOW()
+		 WHERE code_hash=$1 AND client_id=$2 AND used_at IS NULL AND expires_at > NOW()
+		 RETURNING client_id, user_id, redirect_uri, redirect_uri_sent, scopes, code_challenge`,
+		HashToken(code), clientID,
+	).Scan(&c.ClientID, &c.UserID, &c.RedirectURI, &c.RedirectURISent, pq.Array(&c.Scopes), &challenge)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidAuthCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.CodeChallenge = challenge.String
+	return &c, nil
+}
+
+// generateClientCredentials возвращает client_id и секрет (пустой для публичного клиента)
+func generateClientCredentials(public bool) (clientID, secret string, err error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	clientID = OAuthClientPrefix + hex.EncodeToString(b)
+	if public {
+		return clientID, "", nil
+	}
+	secret, err = randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return clientID, secret, nil
+}
+
+// clientSecretMatches сравнивает хэш предъявленного секрета с сохранённым
+func clientSecretMatches(c *models.OAuthClient, secretHash, secret string) bool {
+	if c.Public {
+		return secret == ""
+	}
+	if secret == "" || secretHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashToken(secret)), []byte(secretHash)) == 1
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/models"
+)
+
+// -----------------------------
+// MockOAuthService
+// -----------------------------
+// In-memory реализация OAuthService для юнит-тестов
+type MockOAuthService struct {
+	Clients []models.OAuthClient
+	// secrets хранит хэш секрета по client_id
+	secrets map[string]string
+	// codes хранит выданные коды по их хэшу
+	codes map[string]*mockAuthCode
+}
+
+type mockAuthCode struct {
+	AuthCode
+	expiresAt time.Time
+	used      bool
+}
+
+// CreateClient регистрирует клиента в памяти
+func (m *MockOAuthService) CreateClient(c models.OAuthClient) (string, *models.OAuthClient, error) {
+	clientID, secret, err := generateClientCredentials(c.Public)
+	if err != nil {
+		return "", nil, err
+	}
+	if m.secrets == nil {
+		m.secrets = map[string]string{}
+	}
+
+	c.ID = len(m.Clients) + 1
+	c.ClientID = clientID
+	c.CreatedAt = time.Now()
+	m.Clients = append(m.Clients, c)
+	if secret != "" {
+		m.secrets[clientID] = HashToken(secret)
+	}
+	return secret, &c, nil
+}
+
+// ListClients возвращает всех клиентов
+func (m *MockOAuthService) ListClients() ([]models.OAuthClient, error) {
+	return append([]models.OAuthClient{}, m.Clients...), nil
+}
+
+// RevokeClient помечает клиента отозванным
+func (m *MockOAuthService) RevokeClient(clientID string) error {
+	for i, c := range m.Clients {
+		if c.ClientID == clientID && c.RevokedAt == nil {
+			now := time.Now()
+			m.Clients[i].RevokedAt = &now
+			return nil
+		}
+	}
+	return ErrOAuthClientNotFound
+}
+
+// GetClient находит действующего клиента
+func (m *MockOAuthService) GetClient(clientID string) (*models.OAuthClient, error) {
+	for i, c := range m.Clients {
+		if c.ClientID == clientID && c.RevokedAt == nil {
+			return &m.Clients[i], nil
+		}
+	}
+	return nil, ErrOAuthClientNotFound
+}
+
+// ClientActive сообщает, что клиент зарегистрирован и не отозван
+func (m *MockOAuthService) ClientActive(clientID string) (bool, error) {
+	_, err := m.GetClient(clientID)
+	return err == nil, nil
+}
+
+// AuthenticateClient проверяет секрет клиента
+func (m *MockOAuthService) AuthenticateClient(clientID, secret string) (*models.OAuthClient, error) {
+	c, err := m.GetClient(clientID)
+	if err != nil {
+		return nil, ErrInvalidClient
+	}
+	if !clientSecretMatches(c, m.secrets[clientID], secret) {
+		return nil, ErrInvalidClient
+	}
+	return c, nil
+}
+
+// CreateAuthCode выдаёт код в памяти
+func (m *MockOAuthService) CreateAuthCode(code AuthCode) (string, error) {
+	raw, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	if m.codes == nil {
+		m.codes = map[string]*mockAuthCode{}
+	}
+	m.codes[HashToken(raw)] = &mockAuthCode{AuthCode: code, expiresAt: time.Now().Add(DefaultAuthCodeTTL)}
+	return raw, nil
+}
+
+// ConsumeAuthCode гасит код
+func (m *MockOAuthService) ConsumeAuthCode(code, clientID string) (*AuthCode, error) {
+	c, ok := m.codes[HashToken(code)]
+	if !ok || c.used || c.ClientID != clientID || time.Now().After(c.expiresAt) {
+		return nil, ErrInvalidAuthCode
+	}
+	c.used = true
+	result := c.AuthCode
+	return &result, nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// DefaultResetTokenTTL — срок жизни токена сброса пароля по умолчанию
+const DefaultResetTokenTTL = time.Hour
+
+// ErrInvalidResetToken — токен не найден, истёк или уже использован
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// -----------------------------
+// Интерфейс PasswordResetService
+// -----------------------------
+// Одноразовые токены сброса пароля. В базе хранится только хэш токена;
+// выдача нового токена аннулирует предыдущие неиспользованные.
+type PasswordResetService interface {
+	// Выдать токен сброса пароля для пользователя
+	CreateResetToken(userID int) (string, error)
+	// Узнать ID пользователя по действующему токену, не погашая его
+	ResetTokenUser(token string) (int, error)
+	// Установить новый пароль (уже захэшированный) по токену; возвращает ID пользователя.
+	// Все сессии и refresh-токены пользователя при этом отзываются.
+	ResetPassword(token, hashed string) (int, error)
+}
+
+// -----------------------------
+// Реализация PasswordResetService для PostgreSQL
+// -----------------------------
+type PostgresPasswordResetService struct {
+	DB  *sql.DB
+	TTL time.Duration // срок жизни токена
+}
+
+// Конструктор PostgresPasswordResetService
+func NewPostgresPasswordResetService(db *sql.DB) *PostgresPasswordResetService {
+	return &PostgresPasswordResetService{DB: db, TTL: DefaultResetTokenTTL}
+}
+
+// CreateResetToken аннулирует прежние токены пользователя и выдаёт новый
+func (s *PostgresPasswordResetService) CreateResetToken(userID int) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`UPDATE password_reset_tokens SET used_at=NOW() WHERE user_id=$1 AND used_at IS NULL`, userID,
+	); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`,
+		userID, HashToken(token), time.Now().Add(s.TTL),
+	); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ResetTokenUser возвращает владельца действующего токена
+func (s *PostgresPasswordResetService) ResetTokenUser(token string) (int, error) {
+	var userID int
+	err := s.DB.QueryRow(
+		`SELECT user_id FROM password_reset_tokens
+		 WHERE token_hash=$1 AND used_at IS NULL AND expires_at > NOW()`,
+		HashToken(token),
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidResetToken
+	}
+	return userID, err
+}
+
+// ResetPassword в одной транзакции гасит токен, меняет пароль, завершает все сессии
+// и отзывает refresh-токены
+func (s *PostgresPasswordResetService) ResetPassword(token, hashed string) (int, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var (
+		userID    int
+		expiresAt time.Time
+		usedAt    sql.NullTime
+	)
+	err = tx.QueryRow(
+		`SELECT user_id, expires_at, used_at FROM password_reset_tokens WHERE token_hash=$1 FOR UPDATE`,
+		HashToken(token),
+	).Scan(&userID, &expiresAt, &usedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidResetToken
+	}
+	if err != nil {
+		return 0, err
+	}
+	if usedAt.Valid || time.Now().After(expiresAt) {
+		return 0, ErrInvalidResetToken
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE password_reset_tokens SET used_at=NOW() WHERE user_id=$1 AND used_at IS NULL`, userID,
+	); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`UPDATE users SET password_hash=$1 WHERE id=$2`, hashed, userID); err != nil {
+		return 0, err
+	}
+	// Пароль мог быть скомпрометирован — завершаем все существующие входы
+	for _, query := range []string{
+		`UPDATE sessions SET revoked_at=NOW() WHERE user_id=$1 AND revoked_at IS NULL`,
+		`UPDATE refresh_tokens SET revoked_at=NOW() WHERE user_id=$1 AND revoked_at IS NULL`,
+	} {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import "time"
+
+// -----------------------------
+// MockPasswordResetService
+// -----------------------------
+// In-memory реализация PasswordResetService для юнит-тестов.
+// Новый пароль записывается пользователю в Users.
+type MockPasswordResetService struct {
+	Users  *MockUserService
+	Tokens map[string]*MockResetToken // ключ — сам токен
+}
+
+// MockResetToken — состояние токена сброса в моке
+type MockResetToken struct {
+	UserID    int
+	ExpiresAt time.Time
+	Used      bool
+}
+
+// CreateResetToken аннулирует прежние токены пользователя и выдаёт новый
+func (m *MockPasswordResetService) CreateResetToken(userID int) (string, error) {
+	if m.Tokens == nil {
+		m.Tokens = map[string]*MockResetToken{}
+	}
+	for _, t := range m.Tokens {
+		if t.UserID == userID {
+			t.Used = true
+		}
+	}
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	m.Tokens[token] = &MockResetToken{UserID: userID, ExpiresAt: time.Now().Add(DefaultResetTokenTTL)}
+	return token, nil
+}
+
+// ResetTokenUser возвращает владельца действующего токена
+func (m *MockPasswordResetService) ResetTokenUser(token string) (int, error) {
+	t, ok := m.Tokens[token]
+	if !ok || t.Used || time.Now().After(t.ExpiresAt) {
+		return 0, ErrInvalidResetToken
+	}
+	return t.UserID, nil
+}
+
+// ResetPassword гасит токен, меняет пароль пользователя и завершает его сессии
+func (m *MockPasswordResetService) ResetPassword(token, hashed string) (int, error) {
+	t, ok := m.Tokens[token]
+	if !ok || t.Used || time.Now().After(t.ExpiresAt) {
+		return 0, ErrInvalidResetToken
+	}
+	t.Used = true
+	if m.Users != nil {
+		for i := range m.Users.Users {
+			if m.Users.Users[i].ID == t.UserID {
+				m.Users.Users[i].Password = hashed
+			}
+		}
+		m.Users.Sessions.revokeUserSessions(t.UserID, 0)
+	}
+	return t.UserID, nil
+}
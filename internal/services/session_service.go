@@ -0,0 +1,138 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/models"
+)
+
+// SessionTouchInterval — как часто обновляется last_seen_at сессии.
+// Запись при каждом запросе не нужна: точности до минуты достаточно.
+const SessionTouchInterval = time.Minute
+
+// maxUserAgentLength — сколько символов User-Agent сохраняется в сессии
+const maxUserAgentLength = 512
+
+// ErrSessionNotFound — сессия не существует, уже завершена или принадлежит другому пользователю
+var ErrSessionNotFound = newError(ErrNotFound, "session not found")
+
+// -----------------------------
+// Интерфейс SessionService
+// -----------------------------
+// Хранит сессии входа. Сессия создаётся при выдаче токенов после логина;
+// её ID записывается в access-токены (claim sid) и в цепочку refresh-токенов.
+// Завершение сессии отзывает её refresh-токены, а middleware перестаёт
+// принимать её access-токены.
+type SessionService interface {
+	// Открыть сессию для входа с устройства userAgent и адреса ip
+	CreateSession(userID int, userAgent, ip string) (*models.Session, error)
+	// Активные сессии пользователя, последние использованные первыми
+	ListSessions(userID int) ([]models.Session, error)
+	// Завершить сессию пользователя и отозвать её refresh-токены
+	RevokeSession(userID, id int) error
+	// Проверить, что сессия активна, и отметить её использование (реализует auth.SessionChecker)
+	SessionActive(id int) (bool, error)
+}
+
+// -----------------------------
+// Реализация SessionService для PostgreSQL
+// -----------------------------
+type PostgresSessionService struct {
+	DB *sql.DB
+}
+
+// Конструктор PostgresSessionService
+func NewPostgresSessionService(db *sql.DB) *PostgresSessionService {
+	return &PostgresSessionService{DB: db}
+}
+
+// CreateSession сохраняет новую сессию
+func (s *PostgresSessionService) CreateSession(userID int, userAgent, ip string) (*models.Session, error) {
+	sess := models.Session{UserID: userID, UserAgent: truncate(userAgent, maxUserAgentLength), IP: ip}
+	err := s.DB.QueryRow(
+		`INSERT INTO sessions (user_id, user_agent, ip) VALUES ($1, $2, $3) RETURNING id, created_at, last_seen_at`,
+		userID, sess.UserAgent, ip,
+	).Scan(&sess.ID, &sess.CreatedAt, &sess.LastSeenAt)
+	if err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// ListSessions возвращает незавершённые сессии пользователя
+func (s *PostgresSessionService) ListSessions(userID int) ([]models.Session, error) {
+	rows, err := s.DB.Query(
+		`SELECT id, user_id, user_agent, ip, created_at, last_seen_at
+		 FROM sessions WHERE user_id=$1 AND revoked_at IS NULL
+		 ORDER BY last_seen_at DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var sess models.Session
+		if err := rows.Scan(&sess.ID, &sess.UserID, &sess.UserAgent, &sess.IP, &sess.CreatedAt, &sess.LastSeenAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession в одной транзакции завершает сессию и отзывает её refresh-токены
+func (s *PostgresSessionService) RevokeSession(userID, id int) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE sessions SET revoked_at=NOW() WHERE id=$1 AND user_id=$2 AND revoked_at IS NULL`,
+		id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+	if _, err := tx.Exec(
+		`UPDATE refresh_tokens SET revoked_at=NOW() WHERE session_id=$1 AND revoked_at IS NULL`, id,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SessionActive читает состояние сессии и не чаще раза в SessionTouchInterval обновляет last_seen_at
+func (s *PostgresSessionService) SessionActive(id int) (bool, error) {
+	var revokedAt sql.NullTime
+	var lastSeen time.Time
+	err := s.DB.QueryRow(`SELECT revoked_at, last_seen_at FROM sessions WHERE id=$1`, id).Scan(&revokedAt, &lastSeen)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if revokedAt.Valid {
+		return false, nil
+	}
+	if time.Since(lastSeen) >= SessionTouchInterval {
+		if _, err := s.DB.Exec(`UPDATE sessions SET last_seen_at=NOW() WHERE id=$1`, id); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/models"
+)
+
+// -----------------------------
+// MockSessionService
+// -----------------------------
+// In-memory реализация SessionService для юнит-тестов.
+// Если задан Tokens, завершение сессии отзывает её refresh-токены.
+type MockSessionService struct {
+	Sessions []models.Session
+	Tokens   *MockTokenService
+	// revoked — ID завершённых сессий
+	revoked map[int]bool
+}
+
+// CreateSession сохраняет сессию в памяти
+func (m *MockSessionService) CreateSession(userID int, userAgent, ip string) (*models.Session, error) {
+	now := time.Now()
+	sess := models.Session{
+		ID:         len(m.Sessions) + 1,
+		UserID:     userID,
+		UserAgent:  truncate(userAgent, maxUserAgentLength),
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	m.Sessions = append(m.Sessions, sess)
+	return &sess, nil
+}
+
+// ListSessions возвращает активные сессии пользователя
+func (m *MockSessionService) ListSessions(userID int) ([]models.Session, error) {
+	sessions := []models.Session{}
+	for _, sess := range m.Sessions {
+		if sess.UserID == userID && !m.revoked[sess.ID] {
+			sessions = append(sessions, sess)
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeSession завершает сессию
+func (m *MockSessionService) RevokeSession(userID, id int) error {
+	for _, sess := range m.Sessions {
+		if sess.ID != id || sess.UserID != userID || m.revoked[id] {
+			continue
+		}
+		if m.revoked == nil {
+			m.revoked = map[int]bool{}
+		}
+		m.revoked[id] = true
+		if m.Tokens != nil {
+			m.Tokens.RevokeSession(id)
+		}
+		return nil
+	}
+	return ErrSessionNotFound
+}
+
+// revokeUserSessions завершает сессии пользователя, кроме keepID.
+// Вызывается моками при смене и сбросе пароля; nil-значение ничего не делает.
+func (m *MockSessionService) revokeUserSessions(userID, keepID int) {
+	if m == nil {
+		return
+	}
+	for _, sess := range m.Sessions {
+		if sess.UserID == userID && sess.ID != keepID && !m.revoked[sess.ID] {
+			m.RevokeSession(userID, sess.ID)
+		}
+	}
+}
+
+// SessionActive проверяет, что сессия существует и не завершена
+func (m *MockSessionService) SessionActive(id int) (bool, error) {
+	for i, sess := range m.Sessions {
+		if sess.ID == id {
+			if m.revoked[id] {
+				return false, nil
+			}
+			m.Sessions[i].LastSeenAt = time.Now()
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/models"
+)
+
+// ErrInvalidCursor возвращается, если курсор пагинации не удалось разобрать
+var ErrInvalidCursor = newError(ErrValidation, "invalid cursor")
+
+// -----------------------------
+// TaskQuery
+// -----------------------------
+// Параметры выборки списка задач.
+// Limit = 0 означает "без ограничения".
+// Cursor — непрозрачный курсор keyset-пагинации по (created_at, id),
+// полученный из TaskPage.NextCursor предыдущей страницы.
+// Курсор работает только с сортировкой по умолчанию (пустой Sort).
+// Нулевые значения фильтров означают "не фильтровать".
+type TaskQuery struct {
+	Limit  int
+	Offset int
+	Cursor string
+
+	Statuses      []string   // status IN (...)
+	UserID        int        // user_id = ...
+	CreatedAfter  *time.Time // created_at > ...
+	CreatedBefore *time.Time // created_at < ...
+	UpdatedSince  *time.Time // updated_at >= ...
+
+	Sort []TaskSort
+}
+
+// TaskSort — одно поле сортировки
+type TaskSort struct {
+	Column string
+	Desc   bool
+}
+
+// ErrInvalidSort возвращается для полей сортировки вне белого списка
+var ErrInvalidSort = newError(ErrValidation, "invalid sort")
+
+// sortableTaskColumns — белый список полей, по которым разрешена сортировка.
+// Только эти имена попадают в текст SQL-запроса.
+var sortableTaskColumns = map[string]bool{
+	"id":         true,
+	"title":      true,
+	"status":     true,
+	"user_id":    true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// ParseTaskSort разбирает строку вида "created_at,-title".
+// Минус перед именем поля означает сортировку по убыванию.
+func ParseTaskSort(s string) ([]TaskSort, error) {
+	var sorts []TaskSort
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		sort := TaskSort{Column: field}
+		if strings.HasPrefix(field, "-") {
+			sort = TaskSort{Column: field[1:], Desc: true}
+		}
+		if !sortableTaskColumns[sort.Column] {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSort, sort.Column)
+		}
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
+
+// orderBy формирует ORDER BY; id добавляется в конец для стабильного порядка
+func (q TaskQuery) orderBy() string {
+	if len(q.Sort) == 0 {
+		return "created_at, id"
+	}
+
+	parts := make([]string, 0, len(q.Sort)+1)
+	hasID := false
+	for _, s := range q.Sort {
+		if !sortableTaskColumns[s.Column] {
+			continue
+		}
+		part := s.Column
+		if s.Desc {
+			part += " DESC"
+		}
+		parts = append(parts, part)
+		hasID = hasID || s.Column == "id"
+	}
+	if !hasID {
+		parts = append(parts, "id")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// -----------------------------
+// TaskPage
+// -----------------------------
+// Страница результатов GetTasks.
+// Total — общее количество задач без учёта limit/offset/cursor.
+// NextCursor пустой, если следующей страницы нет.
+type TaskPage struct {
+	Items      []models.Task
+	NextCursor string
+	Total      int
+}
+
+// taskCursor — позиция последней задачи на странице
+type taskCursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// EncodeTaskCursor кодирует позицию задачи в непрозрачную строку
+func EncodeTaskCursor(t models.Task) string {
+	raw := fmt.Sprintf("%d:%d", t.CreatedAt.UnixMicro(), t.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeTaskCursor разбирает курсор, созданный EncodeTaskCursor
+func decodeTaskCursor(s string) (taskCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return taskCursor{}, ErrInvalidCursor
+	}
+
+	var micros int64
+	var id int
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &micros, &id); err != nil || id <= 0 {
+		return taskCursor{}, ErrInvalidCursor
+	}
+
+	return taskCursor{CreatedAt: time.UnixMicro(micros).UTC(), ID: id}, nil
+}
@@ -2,9 +2,13 @@ package services
 
 import (
 	"database/sql" // стандартная библиотека для работы с SQL-базами
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-portfolio/rest-api/internal/models" // структура Task
+	"github.com/lib/pq"
 )
 
 // -----------------------------
@@ -14,15 +18,51 @@ import (
 // Благодаря интерфейсу можно подставлять разные реализации:
 // - реальную (PostgresTaskService)
 // - мок для тестов (MockTaskService)
+//
+// Методы принимают ownerID — ID пользователя, от имени которого выполняется операция.
+// Чтение, изменение и удаление затрагивают только задачи этого пользователя;
+// для чужих и несуществующих задач возвращается ErrTaskNotFound.
+// ownerID = AnyOwner снимает ограничение (используется для администраторов).
 type TaskService interface {
-	// Получить все задачи
-	GetTasks() ([]models.Task, error)
+	// Получить страницу задач владельца согласно параметрам выборки
+	GetTasks(ownerID int, q TaskQuery) (*TaskPage, error)
+	// Получить задачу владельца по ID; ErrTaskNotFound для удалённых и чужих задач
+	GetTask(id int, ownerID int) (*models.Task, error)
 	// Создать новую задачу и вернуть её ID
 	CreateTask(userID int, title, status string) (int, error)
-	UpdateTask(id int, userID int, title, status string) (*models.Task, error)
-	DeleteTask(id int) error
+	// Изменить задачу владельца: записываются только заданные в changes поля
+	UpdateTask(id int, ownerID int, changes TaskChanges) (*models.Task, error)
+	// Удалить задачу владельца; version — ожидаемая версия (0 — без проверки)
+	DeleteTask(id int, ownerID int, version int) error
 }
 
+// AnyOwner — значение ownerID, при котором доступны задачи всех пользователей
+const AnyOwner = 0
+
+// TaskChanges — изменяемые поля задачи; nil означает «не менять».
+// Version — версия задачи, которую видел клиент: если задачу успели изменить,
+// возвращается ErrVersionConflict. 0 — без проверки.
+type TaskChanges struct {
+	Title   *string
+	Status  *string
+	Version int
+}
+
+// Empty сообщает, что изменений полей нет
+func (c TaskChanges) Empty() bool {
+	return c.Title == nil && c.Status == nil
+}
+
+// ErrTaskNotFound возвращается, если задача не существует, удалена или принадлежит другому пользователю
+var ErrTaskNotFound = newError(ErrNotFound, "task not found")
+
+// ErrTaskOwnerNotFound возвращается при создании задачи для несуществующего пользователя
+var ErrTaskOwnerNotFound = newError(ErrValidation, "task owner does not exist")
+
+// ErrVersionConflict возвращается, если версия задачи не совпала с ожидаемой:
+// задачу изменили после того, как клиент её прочитал
+var ErrVersionConflict = newError(ErrConflict, "task version conflict")
+
 // -----------------------------
 // Реализация TaskService для PostgreSQL
 // -----------------------------
@@ -39,9 +79,62 @@ func NewPostgresTaskService(db *sql.DB) *PostgresTaskService {
 // -----------------------------
 // Метод GetTasks
 // -----------------------------
-func (p *PostgresTaskService) GetTasks() ([]models.Task, error) {
-	// Выполняем SQL-запрос для получения всех задач
-	rows, err := p.DB.Query("SELECT id, title, status FROM tasks  WHERE deleted_at IS NULL")
+// Возвращает страницу задач, отфильтрованных и упорядоченных согласно q.
+// По умолчанию задачи упорядочены по (created_at, id).
+// Поддерживает limit/offset и keyset-пагинацию по курсору.
+func (p *PostgresTaskService) GetTasks(ownerID int, q TaskQuery) (*TaskPage, error) {
+	if q.Cursor != "" && len(q.Sort) > 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	where := &sqlWhere{}
+	where.add("deleted_at IS NULL")
+	if ownerID != AnyOwner {
+		where.add("user_id = %s", ownerID)
+	}
+	if len(q.Statuses) > 0 {
+		where.add("status = ANY(%s)", pq.Array(q.Statuses))
+	}
+	if q.UserID > 0 {
+		where.add("user_id = %s", q.UserID)
+	}
+	if q.CreatedAfter != nil {
+		where.add("created_at > %s", *q.CreatedAfter)
+	}
+	if q.CreatedBefore != nil {
+		where.add("created_at < %s", *q.CreatedBefore)
+	}
+	if q.UpdatedSince != nil {
+		where.add("updated_at >= %s", *q.UpdatedSince)
+	}
+
+	// Общее количество задач считаем без учёта курсора
+	var total int
+	countQuery := "SELECT COUNT(*) FROM tasks WHERE " + where.String()
+	if err := p.DB.QueryRow(countQuery, where.args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	if q.Cursor != "" {
+		c, err := decodeTaskCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		where.add("(created_at, id) > (%s, %s)", c.CreatedAt, c.ID)
+	}
+
+	query := "SELECT id, title, status, user_id, created_at, updated_at, version FROM tasks WHERE " +
+		where.String() + " ORDER BY " + q.orderBy()
+	if q.Limit > 0 {
+		// Запрашиваем на одну строку больше, чтобы понять, есть ли следующая страница
+		query += " LIMIT " + where.arg(q.Limit+1)
+	}
+	if q.Offset > 0 {
+		query += " OFFSET " + where.arg(q.Offset)
+	}
+
+	// Выполняем SQL-запрос для получения задач
+	rows, err := p.DB.Query(query, where.args...)
 	if err != nil {
 		// Если ошибка при запросе — возвращаем её
 		return nil, err
@@ -49,21 +142,89 @@ func (p *PostgresTaskService) GetTasks() ([]models.Task, error) {
 	// Обязательно закрываем rows после использования
 	defer rows.Close()
 
-	var tasks []models.Task
+	tasks := []models.Task{}
 
 	// Проходим по всем строкам результата
 	for rows.Next() {
 		var t models.Task
 		// Сканируем значения в структуру Task
-		if err := rows.Scan(&t.ID, &t.Title, &t.Status); err != nil {
+		if err := rows.Scan(&t.ID, &t.Title, &t.Status, &t.UserID, &t.CreatedAt, &t.UpdatedAt, &t.Version); err != nil {
 			return nil, err
 		}
 		// Добавляем задачу в срез
 		tasks = append(tasks, t)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &TaskPage{Items: tasks, Total: total}
+	if q.Limit > 0 && len(tasks) > q.Limit {
+		page.Items = tasks[:q.Limit]
+		// Курсор имеет смысл только для порядка по (created_at, id)
+		if len(q.Sort) == 0 {
+			page.NextCursor = EncodeTaskCursor(page.Items[q.Limit-1])
+		}
+	}
+
+	// Возвращаем страницу задач
+	return page, nil
+}
+
+// -----------------------------
+// Метод GetTask
+// -----------------------------
+// Возвращает задачу владельца со всеми полями; soft-удалённые задачи не возвращаются
+func (p *PostgresTaskService) GetTask(id int, ownerID int) (*models.Task, error) {
+	var t models.Task
+	err := p.DB.QueryRow(
+		`SELECT id, title, status, user_id, created_at, updated_at, deleted_at, version FROM tasks
+		 WHERE id=$1 AND ($2 = 0 OR user_id=$2) AND deleted_at IS NULL`,
+		id, ownerID,
+	).Scan(&t.ID, &t.Title, &t.Status, &t.UserID, &t.CreatedAt, &t.UpdatedAt, &t.DeletedAt, &t.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// -----------------------------
+// sqlWhere
+// -----------------------------
+// Вспомогательный построитель WHERE-условий с нумерованными
+// плейсхолдерами PostgreSQL ($1, $2, ...).
+type sqlWhere struct {
+	conds []string
+	args  []interface{}
+}
+
+// arg добавляет значение в список аргументов и возвращает его плейсхолдер
+func (w *sqlWhere) arg(v interface{}) string {
+	w.args = append(w.args, v)
+	return fmt.Sprintf("$%d", len(w.args))
+}
+
+// add добавляет условие; каждый %s в cond заменяется плейсхолдером для очередного значения
+func (w *sqlWhere) add(cond string, values ...interface{}) {
+	placeholders := make([]interface{}, len(values))
+	for i, v := range values {
+		placeholders[i] = w.arg(v)
+	}
+	if len(placeholders) > 0 {
+		cond = fmt.Sprintf(cond, placeholders...)
+	}
+	w.conds = append(w.conds, cond)
+}
 
-	// Возвращаем срез задач
-	return tasks, nil
+// String возвращает условия, объединённые через AND
+func (w *sqlWhere) String() string {
+	if len(w.conds) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(w.conds, " AND ")
 }
 
 // -----------------------------
@@ -78,7 +239,12 @@ func (p *PostgresTaskService) CreateTask(userID int, title, status string) (int,
 	).Scan(&id) // сканируем результат (ID) в переменную
 
 	if err != nil {
-		// Если ошибка при вставке — возвращаем её
+		// Нарушение внешнего ключа user_id (код 23503) — владельца не существует
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return 0, ErrTaskOwnerNotFound
+		}
+		// Иначе возвращаем ошибку базы как есть
 		return 0, err
 	}
 
@@ -86,22 +252,89 @@ func (p *PostgresTaskService) CreateTask(userID int, title, status string) (int,
 	return id, nil
 }
 
-func (s *PostgresTaskService) UpdateTask(id int, userID int, title, status string) (*models.Task, error) {
-	_, err := s.DB.Exec(`UPDATE tasks SET title=$1, status=$2, updated_at=NOW(), user_id=$4 WHERE id=$3 AND deleted_at IS NULL`, title, status, id, userID)
-	if err != nil {
-		return nil, err
+// -----------------------------
+// Метод UpdateTask
+// -----------------------------
+// Обновляет задачу владельца и возвращает её актуальное состояние.
+// В UPDATE попадают только колонки, заданные в changes; без изменений
+// задача просто читается, и updated_at не меняется. Каждое изменение
+// увеличивает version; при changes.Version != 0 строка обновляется,
+// только если её версия совпадает (compare-and-swap).
+func (p *PostgresTaskService) UpdateTask(id int, ownerID int, changes TaskChanges) (*models.Task, error) {
+	if changes.Empty() {
+		t, err := p.GetTask(id, ownerID)
+		if err == nil && changes.Version != 0 && t.Version != changes.Version {
+			return nil, ErrVersionConflict
+		}
+		return t, err
+	}
+
+	var sets []string
+	var args []any
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s=$%d", column, len(args)))
+	}
+	if changes.Title != nil {
+		set("title", *changes.Title)
 	}
+	if changes.Status != nil {
+		set("status", *changes.Status)
+	}
+	args = append(args, id, ownerID, changes.Version)
+	n := len(args)
 
 	var t models.Task
-	err = s.DB.QueryRow(`SELECT id, title, status, user_id FROM tasks WHERE id=$1`, id).Scan(&t.ID, &t.Title, &t.Status, &t.UserID)
+	err := p.DB.QueryRow(
+		fmt.Sprintf(`UPDATE tasks SET %s, updated_at=NOW(), version=version+1
+		 WHERE id=$%d AND ($%d = 0 OR user_id=$%d) AND ($%d = 0 OR version=$%d) AND deleted_at IS NULL
+		 RETURNING id, title, status, user_id, created_at, updated_at, version`,
+			strings.Join(sets, ", "), n-2, n-1, n-1, n, n),
+		args...,
+	).Scan(&t.ID, &t.Title, &t.Status, &t.UserID, &t.CreatedAt, &t.UpdatedAt, &t.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, p.missingTaskError(id, ownerID, changes.Version)
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &t, nil
 }
 
-func (s *PostgresTaskService) DeleteTask(id int) error {
+// missingTaskError объясняет, почему условный UPDATE не затронул строк:
+// задачи нет (ErrTaskNotFound) или её версия уже другая (ErrVersionConflict)
+func (p *PostgresTaskService) missingTaskError(id, ownerID, version int) error {
+	if version == 0 {
+		return ErrTaskNotFound
+	}
+	if _, err := p.GetTask(id, ownerID); err != nil {
+		return err
+	}
+	return ErrVersionConflict
+}
+
+// -----------------------------
+// Метод DeleteTask
+// -----------------------------
+// Помечает задачу владельца удалённой (soft-delete).
+// При version != 0 задача удаляется, только если её версия совпадает.
+func (p *PostgresTaskService) DeleteTask(id int, ownerID int, version int) error {
 	now := time.Now()
-	_, err := s.DB.Exec("UPDATE tasks SET deleted_at=$1 WHERE id=$2", now, id)
-	return err
+	res, err := p.DB.Exec(
+		`UPDATE tasks SET deleted_at=$1, version=version+1
+		 WHERE id=$2 AND ($3 = 0 OR user_id=$3) AND ($4 = 0 OR version=$4) AND deleted_at IS NULL`,
+		now, id, ownerID, version,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return p.missingTaskError(id, ownerID, version)
+	}
+	return nil
 }
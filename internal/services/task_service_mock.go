@@ -1,8 +1,6 @@
 package services
 
 import (
-	"errors"
-
 	"github.com/go-portfolio/rest-api/internal/models"
 )
 
@@ -12,54 +10,120 @@ import (
 // Мок-реализация интерфейса TaskService для юнит-тестов.
 // Позволяет тестировать обработчики и другие компоненты
 // без реального подключения к базе данных.
-type MockTaskService struct{
-	Tasks []models.Task
+type MockTaskService struct {
+	Tasks     []models.Task
+	Page      *TaskPage // если задана — GetTasks вернёт её вместо страницы по умолчанию
+	LastQuery TaskQuery
+	LastOwner int
+
+	LastChanges TaskChanges // изменения из последнего вызова UpdateTask
+	Err         error       // если задана — возвращается всеми методами
 }
 
 // -----------------------------
 // GetTasks
 // -----------------------------
-// Возвращает заранее заданный срез задач.
+// Возвращает заранее заданную страницу задач.
 // Не обращается к реальной базе, просто имитирует результат.
 // Возвращаемые данные позволяют проверить, что обработчик правильно декодирует JSON и возвращает список.
-func (m *MockTaskService) GetTasks() ([]models.Task, error) {
-	return []models.Task{
-		{ID: 1, Title: "Test Task", Status: "New"},
+// Последний запрос и владелец сохраняются в LastQuery и LastOwner,
+// чтобы тесты могли проверить разбор параметров.
+func (m *MockTaskService) GetTasks(ownerID int, q TaskQuery) (*TaskPage, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	m.LastQuery = q
+	m.LastOwner = ownerID
+	if m.Page != nil {
+		return m.Page, nil
+	}
+	return &TaskPage{
+		Items: []models.Task{
+			{ID: 1, Title: "Test Task", Status: "New"},
+		},
+		Total: 1,
 	}, nil
 }
 
+// -----------------------------
+// GetTask
+// -----------------------------
+// Возвращает копию задачи из m.Tasks, если она не удалена и принадлежит ownerID
+// (или ownerID = AnyOwner).
+func (m *MockTaskService) GetTask(id int, ownerID int) (*models.Task, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	for _, t := range m.Tasks {
+		if t.ID == id && t.DeletedAt == nil && (ownerID == AnyOwner || t.UserID == ownerID) {
+			return &t, nil
+		}
+	}
+	return nil, ErrTaskNotFound
+}
+
 // -----------------------------
 // CreateTask
 // -----------------------------
 // Имитирует создание задачи и возвращает фиктивный ID (42).
 // Не записывает данные в базу, позволяет проверить работу POST /tasks в тестах.
 func (m *MockTaskService) CreateTask(userID int, title, status string) (int, error) {
+	if m.Err != nil {
+		return 0, m.Err
+	}
 	return 42, nil
 }
 
 // -----------------------------
 // UpdateTask
 // -----------------------------
-func (m *MockTaskService) UpdateTask(id int, userID int, title, status string) (*models.Task, error) {
+// Обновляет задачу из m.Tasks, если она принадлежит ownerID (или ownerID = AnyOwner).
+// Меняются только заданные в changes поля, версия увеличивается на 1;
+// при changes.Version != 0 и несовпадении версии возвращается ErrVersionConflict.
+// Последние изменения сохраняются в LastChanges.
+func (m *MockTaskService) UpdateTask(id int, ownerID int, changes TaskChanges) (*models.Task, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	m.LastChanges = changes
 	for i, t := range m.Tasks {
-		if t.ID == id {
-			m.Tasks[i].Title = title
-			m.Tasks[i].Status = status
+		if t.ID == id && (ownerID == AnyOwner || t.UserID == ownerID) {
+			if changes.Version != 0 && t.Version != changes.Version {
+				return nil, ErrVersionConflict
+			}
+			if changes.Empty() {
+				return &m.Tasks[i], nil
+			}
+			if changes.Title != nil {
+				m.Tasks[i].Title = *changes.Title
+			}
+			if changes.Status != nil {
+				m.Tasks[i].Status = *changes.Status
+			}
+			m.Tasks[i].Version++
 			return &m.Tasks[i], nil
 		}
 	}
-	return nil, errors.New("task not found")
+	return nil, ErrTaskNotFound
 }
 
 // -----------------------------
 // DeleteTask
 // -----------------------------
-func (m *MockTaskService) DeleteTask(id int) error {
+// Удаляет задачу из m.Tasks, если она принадлежит ownerID (или ownerID = AnyOwner)
+// и её версия совпадает с version (0 — без проверки).
+func (m *MockTaskService) DeleteTask(id int, ownerID int, version int) error {
+	if m.Err != nil {
+		return m.Err
+	}
 	for i, t := range m.Tasks {
-		if t.ID == id {
+		if t.ID == id && (ownerID == AnyOwner || t.UserID == ownerID) {
+			if version != 0 && t.Version != version {
+				return ErrVersionConflict
+			}
 			m.Tasks = append(m.Tasks[:i], m.Tasks[i+1:]...)
 			return nil
 		}
 	}
-	return errors.New("task not found")
+	return ErrTaskNotFound
 }
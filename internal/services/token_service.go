@@ -0,0 +1,209 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// DefaultRefreshTokenTTL — срок жизни refresh-токена по умолчанию
+const DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrInvalidRefreshToken — токен не найден, истёк или отозван
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused — уже использованный токен предъявлен повторно;
+	// вся цепочка токенов (family) при этом отзывается
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// -----------------------------
+// Интерфейс TokenService
+// -----------------------------
+// Управляет refresh-токенами и denylist-ом access-токенов.
+// Refresh-токены ротируются при каждом использовании; все токены,
+// полученные от одного входа, образуют цепочку (family). Повторное
+// предъявление уже использованного токена отзывает всю цепочку.
+type TokenService interface {
+	// Выдать refresh-токен, открывающий новую цепочку в сессии sessionID (0 — без сессии)
+	IssueRefreshToken(userID, sessionID int) (string, error)
+	// Обменять refresh-токен на новый в той же цепочке и сессии
+	RotateRefreshToken(token string) (*RotatedRefreshToken, error)
+	// Отозвать цепочку, к которой принадлежит токен пользователя userID
+	RevokeRefreshFamily(userID int, token string) error
+	// Досрочно отозвать access-токен по его jti
+	RevokeAccessToken(jti string, expiresAt time.Time) error
+	// Проверить, отозван ли access-токен (реализует auth.Denylist)
+	IsRevoked(jti string) (bool, error)
+}
+
+// RotatedRefreshToken — результат ротации refresh-токена
+type RotatedRefreshToken struct {
+	UserID    int
+	SessionID int    // 0, если цепочка открыта вне сессии
+	Token     string // новый refresh-токен
+}
+
+// -----------------------------
+// Реализация TokenService для PostgreSQL
+// -----------------------------
+type PostgresTokenService struct {
+	DB         *sql.DB
+	RefreshTTL time.Duration // срок жизни refresh-токена
+}
+
+// Конструктор PostgresTokenService
+func NewPostgresTokenService(db *sql.DB) *PostgresTokenService {
+	return &PostgresTokenService{DB: db, RefreshTTL: DefaultRefreshTokenTTL}
+}
+
+// IssueRefreshToken создаёт токен в новой цепочке
+func (s *PostgresTokenService) IssueRefreshToken(userID, sessionID int) (string, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	return s.insertRefreshToken(s.DB, userID, sessionID, familyID)
+}
+
+// RotateRefreshToken помечает токен использованным и выдаёт следующий в той же цепочке
+func (s *PostgresTokenService) RotateRefreshToken(token string) (*RotatedRefreshToken, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var (
+		id, userID        int
+		sessionID         sql.NullInt64
+		familyID          string
+		expiresAt         time.Time
+		usedAt, revokedAt sql.NullTime
+	)
+	err = tx.QueryRow(
+		`SELECT id, user_id, session_id, family_id, expires_at, used_at, revoked_at
+		 FROM refresh_tokens WHERE token_hash=$1 FOR UPDATE`,
+		HashToken(token),
+	).Scan(&id, &userID, &sessionID, &familyID, &expiresAt, &usedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Повторное использование: токен уже обменян — считаем, что он украден,
+	// и отзываем всю цепочку, включая токен, выданный при ротации
+	if usedAt.Valid && !revokedAt.Valid {
+		if _, err := tx.Exec(
+			`UPDATE refresh_tokens SET revoked_at=NOW() WHERE family_id=$1 AND revoked_at IS NULL`,
+			familyID,
+		); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if revokedAt.Valid || time.Now().After(expiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET used_at=NOW() WHERE id=$1`, id); err != nil {
+		return nil, err
+	}
+	newToken, err := s.insertRefreshToken(tx, userID, int(sessionID.Int64), familyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &RotatedRefreshToken{UserID: userID, SessionID: int(sessionID.Int64), Token: newToken}, nil
+}
+
+// RevokeRefreshFamily отзывает все токены цепочки, если токен принадлежит userID
+func (s *PostgresTokenService) RevokeRefreshFamily(userID int, token string) error {
+	res, err := s.DB.Exec(
+		`UPDATE refresh_tokens SET revoked_at=NOW()
+		 WHERE revoked_at IS NULL AND family_id = (
+		     SELECT family_id FROM refresh_tokens WHERE token_hash=$1 AND user_id=$2
+		 )`,
+		HashToken(token), userID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrInvalidRefreshToken
+	}
+	return nil
+}
+
+// RevokeAccessToken добавляет jti в denylist до момента истечения токена
+func (s *PostgresTokenService) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	// Попутно чистим записи, которые уже не нужны: истёкший токен и так не пройдёт проверку
+	if _, err := s.DB.Exec(`DELETE FROM revoked_tokens WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+	_, err := s.DB.Exec(
+		`INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING`,
+		jti, expiresAt,
+	)
+	return err
+}
+
+// IsRevoked проверяет наличие jti в denylist
+func (s *PostgresTokenService) IsRevoked(jti string) (bool, error) {
+	var exists bool
+	err := s.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti=$1)`, jti).Scan(&exists)
+	return exists, err
+}
+
+// execer — общий интерфейс *sql.DB и *sql.Tx для вставки
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertRefreshToken генерирует токен и сохраняет его хэш
+func (s *PostgresTokenService) insertRefreshToken(db execer, userID, sessionID int, familyID string) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	_, err = db.Exec(
+		`INSERT INTO refresh_tokens (user_id, session_id, family_id, token_hash, expires_at)
+		 VALUES ($1, NULLIF($2, 0), $3, $4, $5)`,
+		userID, sessionID, familyID, HashToken(token), time.Now().Add(s.RefreshTTL),
+	)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// HashToken возвращает SHA-256 токена в hex.
+// Для случайных токенов с высокой энтропией медленный хэш не нужен.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken возвращает n случайных байт в base64url
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// -----------------------------
+// MockTokenService
+// -----------------------------
+// In-memory реализация TokenService для юнит-тестов обработчиков.
+// Повторяет правила ротации и отзыва цепочек PostgresTokenService.
+type MockTokenService struct {
+	RefreshTokens map[string]*MockRefreshToken // ключ — сам токен
+	RevokedJTIs   map[string]time.Time
+	seq           int
+}
+
+// MockRefreshToken — состояние refresh-токена в моке
+type MockRefreshToken struct {
+	UserID    int
+	SessionID int
+	Family    string
+	Used      bool
+	Revoked   bool
+}
+
+// IssueRefreshToken выдаёт токен в новой цепочке
+func (m *MockTokenService) IssueRefreshToken(userID, sessionID int) (string, error) {
+	m.seq++
+	return m.issue(userID, sessionID, fmt.Sprintf("family-%d", m.seq)), nil
+}
+
+// RotateRefreshToken обменивает токен на следующий, обнаруживая повторное использование
+func (m *MockTokenService) RotateRefreshToken(token string) (*RotatedRefreshToken, error) {
+	rt, ok := m.RefreshTokens[token]
+	if !ok {
+		return nil, ErrInvalidRefreshToken
+	}
+	if rt.Used && !rt.Revoked {
+		m.revokeFamily(rt.Family)
+		return nil, ErrRefreshTokenReused
+	}
+	if rt.Revoked {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	rt.Used = true
+	return &RotatedRefreshToken{
+		UserID:    rt.UserID,
+		SessionID: rt.SessionID,
+		Token:     m.issue(rt.UserID, rt.SessionID, rt.Family),
+	}, nil
+}
+
+// RevokeRefreshFamily отзывает цепочку токена, если он принадлежит userID
+func (m *MockTokenService) RevokeRefreshFamily(userID int, token string) error {
+	rt, ok := m.RefreshTokens[token]
+	if !ok || rt.UserID != userID {
+		return ErrInvalidRefreshToken
+	}
+	m.revokeFamily(rt.Family)
+	return nil
+}
+
+// RevokeAccessToken добавляет jti в denylist
+func (m *MockTokenService) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	if m.RevokedJTIs == nil {
+		m.RevokedJTIs = map[string]time.Time{}
+	}
+	m.RevokedJTIs[jti] = expiresAt
+	return nil
+}
+
+// IsRevoked проверяет наличие jti в denylist
+func (m *MockTokenService) IsRevoked(jti string) (bool, error) {
+	_, ok := m.RevokedJTIs[jti]
+	return ok, nil
+}
+
+func (m *MockTokenService) issue(userID, sessionID int, family string) string {
+	if m.RefreshTokens == nil {
+		m.RefreshTokens = map[string]*MockRefreshToken{}
+	}
+	m.seq++
+	token := fmt.Sprintf("refresh-%d", m.seq)
+	m.RefreshTokens[token] = &MockRefreshToken{UserID: userID, SessionID: sessionID, Family: family}
+	return token
+}
+
+// RevokeSession отзывает refresh-токены сессии (используется MockSessionService)
+func (m *MockTokenService) RevokeSession(sessionID int) {
+	for _, rt := range m.RefreshTokens {
+		if rt.SessionID == sessionID {
+			rt.Revoked = true
+		}
+	}
+}
+
+func (m *MockTokenService) revokeFamily(family string) {
+	for _, rt := range m.RefreshTokens {
+		if rt.Family == family {
+			rt.Revoked = true
+		}
+	}
+}
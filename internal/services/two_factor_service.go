@@ -0,0 +1,236 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+)
+
+// RecoveryCodeCount — сколько кодов восстановления выдаётся при подключении 2FA
+const RecoveryCodeCount = 10
+
+var (
+	// ErrTwoFactorEnabled — 2FA уже подключена
+	ErrTwoFactorEnabled = newError(ErrConflict, "two-factor authentication already enabled")
+	// ErrTwoFactorNotEnabled — 2FA не подключена или подключение не начато
+	ErrTwoFactorNotEnabled = newError(ErrConflict, "two-factor authentication not enabled")
+	// ErrInvalidTwoFactorCode — неверный, просроченный или уже использованный код
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+// -----------------------------
+// Интерфейс TwoFactorService
+// -----------------------------
+// TOTP-аутентификация (RFC 6238). Подключение проходит в два шага:
+// BeginEnrollment выдаёт секрет, ConfirmEnrollment включает 2FA после
+// проверки первого кода и возвращает коды восстановления.
+type TwoFactorService interface {
+	// Начать подключение: сгенерировать секрет (предыдущий неподтверждённый заменяется)
+	BeginEnrollment(userID int) (secret string, err error)
+	// Подтвердить подключение кодом из приложения; возвращает коды восстановления
+	ConfirmEnrollment(userID int, code string) ([]string, error)
+	// Подключена ли 2FA
+	Enabled(userID int) (bool, error)
+	// Проверить TOTP-код или одноразовый код восстановления
+	Verify(userID int, code string) error
+	// Отключить 2FA (требует действующий код)
+	Disable(userID int, code string) error
+}
+
+// -----------------------------
+// Реализация TwoFactorService для PostgreSQL
+// -----------------------------
+type PostgresTwoFactorService struct {
+	DB *sql.DB
+}
+
+// Конструктор PostgresTwoFactorService
+func NewPostgresTwoFactorService(db *sql.DB) *PostgresTwoFactorService {
+	return &PostgresTwoFactorService{DB: db}
+}
+
+// BeginEnrollment сохраняет новый неподтверждённый секрет
+func (s *PostgresTwoFactorService) BeginEnrollment(userID int) (string, error) {
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return "", err
+	}
+	// Подтверждённый секрет не перезаписывается: сначала 2FA нужно отключить
+	res, err := s.DB.Exec(
+		`INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		 ON CONFLICT (user_id) DO UPDATE SET secret=EXCLUDED.secret, last_used_step=NULL, created_at=NOW()
+		 WHERE user_totp.confirmed_at IS NULL`,
+		userID, secret,
+	)
+	if err != nil {
+		return "", err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+	if affected == 0 {
+		return "", ErrTwoFactorEnabled
+	}
+	return secret, nil
+}
+
+// ConfirmEnrollment проверяет первый код, включает 2FA и выдаёт коды восстановления
+func (s *PostgresTwoFactorService) ConfirmEnrollment(userID int, code string) ([]string, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var (
+		secret    string
+		confirmed sql.NullTime
+	)
+	err = tx.QueryRow(
+		`SELECT secret, confirmed_at FROM user_totp WHERE user_id=$1 FOR UPDATE`, userID,
+	).Scan(&secret, &confirmed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if confirmed.Valid {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	step, ok := auth.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	if _, err := tx.Exec(
+		`UPDATE user_totp SET confirmed_at=NOW(), last_used_step=$2 WHERE user_id=$1`, userID, step,
+	); err != nil {
+		return nil, err
+	}
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return nil, err
+	}
+	for _, c := range codes {
+		if _, err := tx.Exec(
+			`INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hashRecoveryCode(c),
+		); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Enabled проверяет наличие подтверждённого секрета
+func (s *PostgresTwoFactorService) Enabled(userID int) (bool, error) {
+	var enabled bool
+	err := s.DB.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM user_totp WHERE user_id=$1 AND confirmed_at IS NOT NULL)`, userID,
+	).Scan(&enabled)
+	return enabled, err
+}
+
+// Verify принимает TOTP-код (каждый не более одного раза) или неиспользованный код восстановления
+func (s *PostgresTwoFactorService) Verify(userID int, code string) error {
+	var secret string
+	err := s.DB.QueryRow(
+		`SELECT secret FROM user_totp WHERE user_id=$1 AND confirmed_at IS NOT NULL`, userID,
+	).Scan(&secret)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+
+	if step, ok := auth.ValidateTOTP(secret, code, time.Now()); ok {
+		// Условие на last_used_step не даёт принять тот же код второй раз
+		res, err := s.DB.Exec(
+			`UPDATE user_totp SET last_used_step=$2
+			 WHERE user_id=$1 AND (last_used_step IS NULL OR last_used_step < $2)`,
+			userID, step,
+		)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	res, err := s.DB.Exec(
+		`UPDATE recovery_codes SET used_at=NOW() WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL`,
+		userID, hashRecoveryCode(code),
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// Disable проверяет код и удаляет секрет и коды восстановления
+func (s *PostgresTwoFactorService) Disable(userID int, code string) error {
+	if err := s.Verify(userID, code); err != nil {
+		return err
+	}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM user_totp WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// generateRecoveryCodes возвращает RecoveryCodeCount кодов вида xxxx-xxxx
+func generateRecoveryCodes() ([]string, error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5) // 40 бит → 8 символов base32
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		c := strings.ToLower(enc.EncodeToString(b))
+		codes[i] = c[:4] + "-" + c[4:]
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode хэширует код восстановления без учёта регистра и дефисов
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return HashToken(code)
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"time"
+
+	"github.com/go-portfolio/rest-api/internal/auth"
+)
+
+// -----------------------------
+// MockTwoFactorService
+// -----------------------------
+// In-memory реализация TwoFactorService для юнит-тестов.
+// Коды проверяются настоящим алгоритмом TOTP по текущему времени.
+type MockTwoFactorService struct {
+	Secrets map[int]*MockTOTP
+}
+
+// MockTOTP — состояние 2FA пользователя в моке
+type MockTOTP struct {
+	Secret        string
+	Confirmed     bool
+	LastUsedStep  int64
+	RecoveryCodes map[string]bool // хэш кода → использован
+}
+
+// BeginEnrollment выдаёт новый неподтверждённый секрет
+func (m *MockTwoFactorService) BeginEnrollment(userID int) (string, error) {
+	if m.Secrets == nil {
+		m.Secrets = map[int]*MockTOTP{}
+	}
+	if t, ok := m.Secrets[userID]; ok && t.Confirmed {
+		return "", ErrTwoFactorEnabled
+	}
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return "", err
+	}
+	m.Secrets[userID] = &MockTOTP{Secret: secret}
+	return secret, nil
+}
+
+// ConfirmEnrollment проверяет первый код и выдаёт коды восстановления
+func (m *MockTwoFactorService) ConfirmEnrollment(userID int, code string) ([]string, error) {
+	t, ok := m.Secrets[userID]
+	if !ok {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if t.Confirmed {
+		return nil, ErrTwoFactorEnabled
+	}
+	step, valid := auth.ValidateTOTP(t.Secret, code, time.Now())
+	if !valid {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	t.Confirmed = true
+	t.LastUsedStep = step
+	t.RecoveryCodes = map[string]bool{}
+	for _, c := range codes {
+		t.RecoveryCodes[hashRecoveryCode(c)] = false
+	}
+	return codes, nil
+}
+
+// Enabled проверяет, подтверждена ли 2FA
+func (m *MockTwoFactorService) Enabled(userID int) (bool, error) {
+	t, ok := m.Secrets[userID]
+	return ok && t.Confirmed, nil
+}
+
+// Verify принимает TOTP-код один раз или неиспользованный код восстановления
+func (m *MockTwoFactorService) Verify(userID int, code string) error {
+	t, ok := m.Secrets[userID]
+	if !ok || !t.Confirmed {
+		return ErrTwoFactorNotEnabled
+	}
+	if step, valid := auth.ValidateTOTP(t.Secret, code, time.Now()); valid {
+		if step <= t.LastUsedStep {
+			return ErrInvalidTwoFactorCode
+		}
+		t.LastUsedStep = step
+		return nil
+	}
+	h := hashRecoveryCode(code)
+	if used, ok := t.RecoveryCodes[h]; ok && !used {
+		t.RecoveryCodes[h] = true
+		return nil
+	}
+	return ErrInvalidTwoFactorCode
+}
+
+// Disable проверяет код и отключает 2FA
+func (m *MockTwoFactorService) Disable(userID int, code string) error {
+	if err := m.Verify(userID, code); err != nil {
+		return err
+	}
+	delete(m.Secrets, userID)
+	return nil
+}
@@ -0,0 +1,35 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-portfolio/rest-api/internal/services"
+)
+
+// -----------------------------
+// Категории доменных ошибок
+// -----------------------------
+func TestDomainErrorCategories(t *testing.T) {
+	for err, kind := range map[error]error{
+		services.ErrTaskNotFound:      services.ErrNotFound,
+		services.ErrUserNotFound:      services.ErrNotFound,
+		services.ErrSessionNotFound:   services.ErrNotFound,
+		services.ErrVersionConflict:   services.ErrConflict,
+		services.ErrUsernameTaken:     services.ErrConflict,
+		services.ErrInvalidCursor:     services.ErrValidation,
+		services.ErrTaskOwnerNotFound: services.ErrValidation,
+	} {
+		if !errors.Is(err, kind) {
+			t.Errorf("%q: expected category %q", err, kind)
+		}
+	}
+
+	// Текст конкретной ошибки не меняется
+	if services.ErrTaskNotFound.Error() != "task not found" {
+		t.Errorf("unexpected message %q", services.ErrTaskNotFound.Error())
+	}
+	if errors.Is(services.ErrTaskNotFound, services.ErrConflict) {
+		t.Error("ErrTaskNotFound must not be a conflict")
+	}
+}
@@ -15,10 +15,16 @@ func TestMockTaskService_GetTasks(t *testing.T) {
 	mock := &services.MockTaskService{}
 
 	// Вызываем GetTasks и проверяем результат
-	tasks, err := mock.GetTasks()
+	page, err := mock.GetTasks(7, services.TaskQuery{Limit: 10})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
+	tasks := page.Items
+
+	// Проверяем, что мок запомнил параметры запроса
+	if mock.LastQuery.Limit != 10 || mock.LastOwner != 7 {
+		t.Errorf("expected limit 10 and owner 7 to be recorded, got %d and %d", mock.LastQuery.Limit, mock.LastOwner)
+	}
 
 	// Проверяем, что вернулся ровно один таск
 	if len(tasks) != 1 {
@@ -54,12 +60,13 @@ func TestMockTaskService_CreateTask(t *testing.T) {
 func TestMockTaskService_UpdateTask(t *testing.T) {
 	mock := &services.MockTaskService{
 		Tasks: []models.Task{
-			{ID: 1, Title: "Old Title", Status: "New"},
+			{ID: 1, Title: "Old Title", Status: "New", UserID: 1},
 		},
 	}
 
 	// Обновляем существующую задачу
-	task, err := mock.UpdateTask(1, 1, "Updated Title", "Done")
+	title, status := "Updated Title", "Done"
+	task, err := mock.UpdateTask(1, 1, services.TaskChanges{Title: &title, Status: &status})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -70,9 +77,33 @@ func TestMockTaskService_UpdateTask(t *testing.T) {
 	}
 
 	// Пробуем обновить несуществующую задачу
-	_, err = mock.UpdateTask(99, 1, "X", "Y")
-	if err == nil {
-		t.Errorf("expected error for non-existent task, got nil")
+	_, err = mock.UpdateTask(99, 1, services.TaskChanges{Title: &title})
+	if err != services.ErrTaskNotFound {
+		t.Errorf("expected ErrTaskNotFound for non-existent task, got %v", err)
+	}
+
+	// Пробуем обновить чужую задачу
+	_, err = mock.UpdateTask(1, 2, services.TaskChanges{Title: &title})
+	if err != services.ErrTaskNotFound {
+		t.Errorf("expected ErrTaskNotFound for another user's task, got %v", err)
+	}
+
+	// Незаданные поля не меняются
+	other := "Only Title"
+	task, _ = mock.UpdateTask(1, 1, services.TaskChanges{Title: &other})
+	if task.Title != other || task.Status != status {
+		t.Errorf("status must be left unchanged: %+v", task)
+	}
+
+	// Каждое изменение увеличивает версию; устаревшая версия — конфликт
+	if task.Version != 2 {
+		t.Errorf("expected version 2 after two updates, got %d", task.Version)
+	}
+	if _, err := mock.UpdateTask(1, 1, services.TaskChanges{Title: &title, Version: 1}); err != services.ErrVersionConflict {
+		t.Errorf("expected ErrVersionConflict for stale version, got %v", err)
+	}
+	if err := mock.DeleteTask(1, 1, 1); err != services.ErrVersionConflict {
+		t.Errorf("expected ErrVersionConflict on delete, got %v", err)
 	}
 }
 
@@ -82,13 +113,18 @@ func TestMockTaskService_UpdateTask(t *testing.T) {
 func TestMockTaskService_DeleteTask(t *testing.T) {
 	mock := &services.MockTaskService{
 		Tasks: []models.Task{
-			{ID: 1, Title: "Task 1", Status: "New"},
-			{ID: 2, Title: "Task 2", Status: "Done"},
+			{ID: 1, Title: "Task 1", Status: "New", UserID: 1},
+			{ID: 2, Title: "Task 2", Status: "Done", UserID: 1},
 		},
 	}
 
+	// Чужую задачу удалить нельзя
+	if err := mock.DeleteTask(1, 2, 0); err != services.ErrTaskNotFound {
+		t.Fatalf("expected ErrTaskNotFound for another user's task, got %v", err)
+	}
+
 	// Удаляем существующую задачу
-	err := mock.DeleteTask(1)
+	err := mock.DeleteTask(1, 1, 0)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -99,7 +135,7 @@ func TestMockTaskService_DeleteTask(t *testing.T) {
 	}
 
 	// Пробуем удалить несуществующую задачу
-	err = mock.DeleteTask(99)
+	err = mock.DeleteTask(99, 1, 0)
 	if err == nil {
 		t.Errorf("expected error for non-existent task, got nil")
 	}
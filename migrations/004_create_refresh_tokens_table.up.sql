@@ -0,0 +1,13 @@
+CREATE TABLE refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Владелец токена
+    family_id VARCHAR(64) NOT NULL,          -- Цепочка ротации: все токены, выданные от одного логина
+    token_hash VARCHAR(64) UNIQUE NOT NULL,  -- SHA-256 от токена, сам токен не хранится
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMP NULL,                  -- Время ротации; повторное предъявление — признак кражи
+    revoked_at TIMESTAMP NULL                -- Время отзыва (logout или обнаружение повторного использования)
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
@@ -0,0 +1,7 @@
+-- Denylist access-токенов, отозванных до истечения срока действия
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,       -- Идентификатор JWT (claim jti)
+    expires_at TIMESTAMP NOT NULL      -- После этого момента запись можно удалить
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
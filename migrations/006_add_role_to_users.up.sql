@@ -0,0 +1,4 @@
+-- Роль пользователя: admin, member или viewer
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'member'
+    CHECK (role IN ('admin', 'member', 'viewer'));
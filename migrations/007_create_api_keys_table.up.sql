@@ -0,0 +1,15 @@
+-- Долгоживущие API-ключи для автоматизации (CI, скрипты)
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Владелец ключа
+    name VARCHAR(100) NOT NULL,             -- Название, заданное пользователем
+    prefix VARCHAR(32) NOT NULL,            -- Открытая часть ключа для опознания в списке
+    key_hash VARCHAR(64) UNIQUE NOT NULL,   -- SHA-256 от ключа, сам ключ показывается один раз
+    scopes TEXT[] NOT NULL DEFAULT '{}',    -- Разрешения ключа (tasks:read, tasks:write, ...)
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL,              -- NULL — бессрочный ключ
+    revoked_at TIMESTAMP NULL
+);
+
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
@@ -0,0 +1,11 @@
+-- Одноразовые токены сброса пароля
+CREATE TABLE password_reset_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Чей пароль сбрасывается
+    token_hash VARCHAR(64) UNIQUE NOT NULL,  -- SHA-256 от токена, сам токен отправляется только в письме
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMP NULL                   -- Время использования; повторно токен не принимается
+);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
@@ -0,0 +1,14 @@
+-- Подтверждение email: время подтверждения у пользователя и одноразовые токены из писем
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP NULL;
+
+CREATE TABLE email_verification_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,             -- Подтверждаемый адрес; после смены email токен недействителен
+    token_hash VARCHAR(64) UNIQUE NOT NULL,  -- SHA-256 от токена, сам токен отправляется только в письме
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMP NULL
+);
+
+CREATE INDEX idx_email_verification_tokens_user_id ON email_verification_tokens(user_id);
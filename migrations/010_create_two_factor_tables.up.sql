@@ -0,0 +1,17 @@
+-- TOTP (RFC 6238): секрет пользователя и одноразовые коды восстановления
+CREATE TABLE user_totp (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret VARCHAR(64) NOT NULL,          -- Секрет в base32; нужен в открытом виде для вычисления кодов
+    confirmed_at TIMESTAMP NULL,          -- NULL — подключение начато, но не подтверждено кодом
+    last_used_step BIGINT NULL,           -- Номер последнего принятого интервала, защита от повтора кода
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,       -- SHA-256 от кода, сами коды показываются один раз
+    used_at TIMESTAMP NULL
+);
+
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes(user_id);
@@ -0,0 +1,13 @@
+-- Учётные записи внешних провайдеров (OpenID Connect), привязанные к пользователям
+CREATE TABLE user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(64) NOT NULL,        -- Имя провайдера из config.yaml
+    subject VARCHAR(255) NOT NULL,        -- claim sub: неизменный идентификатор у провайдера
+    email VARCHAR(255) NULL,              -- email на момент привязки, для справки
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP NULL,
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
@@ -0,0 +1,30 @@
+-- Клиенты OAuth 2.0, зарегистрированные администратором
+CREATE TABLE oauth_clients (
+    id SERIAL PRIMARY KEY,
+    client_id VARCHAR(64) UNIQUE NOT NULL,   -- Публичный идентификатор клиента
+    secret_hash VARCHAR(64) NULL,            -- SHA-256 от секрета; NULL у публичных клиентов
+    name VARCHAR(100) NOT NULL,
+    owner_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- От его имени выдаются токены client_credentials
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+    grant_types TEXT[] NOT NULL DEFAULT '{}', -- authorization_code, client_credentials
+    scopes TEXT[] NOT NULL DEFAULT '{}',      -- Максимальный набор разрешений клиента
+    public BOOLEAN NOT NULL DEFAULT FALSE,    -- Публичный клиент (SPA, мобильное приложение): без секрета, только с PKCE
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP NULL
+);
+
+-- Одноразовые коды авторизации (authorization code flow)
+CREATE TABLE oauth_authorization_codes (
+    id SERIAL PRIMARY KEY,
+    code_hash VARCHAR(64) UNIQUE NOT NULL,  -- SHA-256 от кода
+    client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    code_challenge VARCHAR(128) NULL,       -- PKCE (S256)
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_oauth_authorization_codes_expires_at ON oauth_authorization_codes(expires_at);
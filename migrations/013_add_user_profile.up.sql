@@ -0,0 +1,6 @@
+-- Отображаемое имя и soft-удаление учётной записи
+ALTER TABLE users
+    ADD COLUMN display_name VARCHAR(100) NULL,
+    ADD COLUMN deleted_at TIMESTAMP NULL;   -- NULL — учётная запись активна
+
+CREATE INDEX idx_users_deleted_at ON users(deleted_at);
@@ -0,0 +1,18 @@
+-- Сессии входа: одна сессия на каждый успешный логин (устройство)
+CREATE TABLE sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',      -- Заголовок User-Agent при входе
+    ip VARCHAR(45) NOT NULL DEFAULT '',       -- IP клиента при входе (IPv4 или IPv6)
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, -- Последний запрос с токеном сессии
+    revoked_at TIMESTAMP NULL                 -- Время завершения (logout или отзыв с другого устройства)
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+
+-- Цепочка refresh-токенов принадлежит сессии, в которой открыта
+ALTER TABLE refresh_tokens
+    ADD COLUMN session_id INT NULL REFERENCES sessions(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_refresh_tokens_session_id ON refresh_tokens(session_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_impersonation_audit_user_id;
+DROP INDEX IF EXISTS idx_impersonation_audit_admin_id;
+DROP TABLE IF EXISTS impersonation_audit;
@@ -0,0 +1,16 @@
+-- Журнал действий администраторов от имени пользователей (имперсонация)
+CREATE TABLE impersonation_audit (
+    id SERIAL PRIMARY KEY,
+    admin_id INT NOT NULL REFERENCES users(id),  -- Кто фактически выполнил действие
+    user_id INT NOT NULL REFERENCES users(id),   -- От чьего имени
+    token_id VARCHAR(64) NOT NULL,               -- jti токена имперсонации
+    action VARCHAR(16) NOT NULL,                 -- start (выдача токена) или write (изменяющий запрос)
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    status INT NOT NULL DEFAULT 0,               -- HTTP-статус ответа; 0 — запрос не завершился
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_impersonation_audit_admin_id ON impersonation_audit(admin_id);
+CREATE INDEX idx_impersonation_audit_user_id ON impersonation_audit(user_id);
@@ -0,0 +1,2 @@
+-- redirect_uri был явно передан в запросе авторизации: тогда он обязателен при обмене кода
+ALTER TABLE oauth_authorization_codes ADD COLUMN redirect_uri_sent BOOLEAN NOT NULL DEFAULT FALSE;